@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"os"
 	"strconv"
@@ -48,12 +49,11 @@ func main() {
 }
 
 func mustOpenDBConnection() *sql.DB {
-	database, _ := sql.Open("sqlite3", "./persistent_storage/main.db")
+	database, _ := sql.Open("sqlite3", "./persistent_storage/main.db?_foreign_keys=on")
 
-	createTableQuery := db.Migrations
-	_, err := database.Exec(createTableQuery)
+	err := db.Migrate(context.Background(), database)
 	if err != nil {
-		logrus.Fatalf("Failed to create table: %v", err)
+		logrus.Fatalf("Failed to migrate database: %v", err)
 	}
 	return database
 }
@@ -11,24 +11,100 @@ import (
 	"dev-challenge/internal/models"
 )
 
+// Input is a stored cell. Result holds numeric results, while ResultText holds the result of other cells
+// as shown to the user, i.e. a text, TRUE or an error code like #DIV/0!. Booleans keep 1 or 0 in Result as well,
+// numbers of sheets in decimal mode keep their exact digits like 0.30 in ResultText.
+// ResultType is "number" when empty. Cells in an error state describe the error in ResultError,
+// ErrorCell is the cell like "sheet1!b" the error comes from and is empty when the cell's own formula failed.
+// Format is the display mask of the cell, it is only loaded by GetInput and GetSheetInputs and written by SetCellFormat.
 type Input struct {
-	SheetID    string   `db:"sheet_id"`
-	CellID     string   `db:"cell_id"`
-	Value      string   `db:"value"`
-	Result     float64  `db:"result"`
-	UsedParams []string `db:"used_params"`
+	SheetID      string        `db:"sheet_id"`
+	CellID       string        `db:"cell_id"`
+	Value        string        `db:"value"`
+	Result       float64       `db:"result"`
+	ResultType   string        `db:"result_type"`
+	ResultText   string        `db:"result_text"`
+	ResultError  string        `db:"result_error"`
+	ErrorCell    string        `db:"error_cell"`
+	Format       string        `db:"cell_format"`
+	UsedParams   []CellRef     `db:"used_params"`
+	UsedRanges   []CellRange   `db:"used_ranges"`
+	UsedPatterns []CellPattern `db:"used_patterns"`
 }
 
+// CellRef identifies a cell across sheets.
+type CellRef struct {
+	SheetID string
+	CellID  string
+}
+
+func (r CellRef) String() string {
+	return r.SheetID + "!" + r.CellID
+}
+
+// CellRange is a rectangle of grid cells with 1-based inclusive bounds, From is never greater than To.
+type CellRange struct {
+	SheetID string
+	FromCol int
+	FromRow int
+	ToCol   int
+	ToRow   int
+}
+
+// CellPattern matches cell IDs of a sheet, "*" stands for any sequence of characters and "?" for a single one.
+type CellPattern struct {
+	SheetID string
+	Pattern string
+}
+
+// Dependencies are the cells, ranges and patterns referenced by a formula.
+type Dependencies struct {
+	Cells    []CellRef
+	Ranges   []CellRange
+	Patterns []CellPattern
+}
+
+const resultTypeNumber = "number"
+
+// response renders the result of the cell for the API.
+func (in Input) response() *models.Data {
+	resp := &models.Data{Value: in.Value, Result: fmt.Sprintf("%f", in.Result), Type: in.ResultType}
+	if hasTextResult(in.ResultType, in.ResultText) {
+		resp.Result = in.ResultText
+	}
+	if resp.Type == "" {
+		resp.Type = resultTypeNumber
+	}
+	resp.Error = errorDescription(in.ResultError, in.ErrorCell)
+	return resp
+}
+
+// errorDescription renders the error of a cell, prefixed by the cell it comes from, i.e. "sheet1!b: division by zero".
+func errorDescription(resultError, errorCell string) string {
+	if errorCell == "" {
+		return resultError
+	}
+	return errorCell + ": " + resultError
+}
+
+// hasTextResult reports whether a result is shown from result_text rather than cell_result.
+func hasTextResult(resultType, resultText string) bool {
+	return resultText != "" || resultType != "" && resultType != resultTypeNumber
+}
+
+// cellBatchChunkSize keeps the number of query parameters below the SQLite limit.
+const cellBatchChunkSize = 5000
+
 func (s *storage) GetCellInput(ctx context.Context, sheetID, cellID string) (resp *models.Data, err error) {
-	rows, err := s.ext.QueryContext(ctx, "SELECT cell_value, cell_result FROM dev_challenge WHERE sheet_id=$1 AND cell_id=$2", sheetID, cellID)
+	rows, err := s.ext.QueryContext(ctx, "SELECT cell_value, cell_result, result_type, result_text, result_error, error_cell FROM dev_challenge WHERE sheet_id=$1 AND cell_id=$2", sheetID, cellID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var value, result string
+	var value, result, resultType, resultText, resultError, errorCell string
 	for rows.Next() {
-		err = rows.Scan(&value, &result)
+		err = rows.Scan(&value, &result, &resultType, &resultText, &resultError, &errorCell)
 		if err != nil {
 			return nil, err
 		}
@@ -41,10 +117,134 @@ func (s *storage) GetCellInput(ctx context.Context, sheetID, cellID string) (res
 	resp = &models.Data{
 		Value:  value,
 		Result: result,
+		Type:   resultType,
+		Error:  errorDescription(resultError, errorCell),
+	}
+	if hasTextResult(resultType, resultText) {
+		resp.Result = resultText
 	}
 	return resp, nil
 }
 
+// GetInput returns the stored cell with its format, nil when it doesn't exist. tx may be nil outside of transactions.
+func (s *storage) GetInput(ctx context.Context, tx *sql.Tx, sheetID, cellID string) (*Input, error) {
+	query := "SELECT cell_value, cell_result, result_type, result_text, result_error, error_cell, cell_format FROM dev_challenge WHERE sheet_id = $1 AND cell_id = $2"
+	var row *sql.Row
+	if tx != nil {
+		row = tx.QueryRowContext(ctx, query, sheetID, cellID)
+	} else {
+		row = s.ext.QueryRowContext(ctx, query, sheetID, cellID)
+	}
+
+	data := Input{SheetID: sheetID, CellID: cellID}
+	err := row.Scan(&data.Value, &data.Result, &data.ResultType, &data.ResultText, &data.ResultError, &data.ErrorCell, &data.Format)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetSheetInputs returns the cells of a sheet with their formats keyed by cell ID.
+func (s *storage) GetSheetInputs(ctx context.Context, sheetID string) (map[string]Input, error) {
+	rows, err := s.ext.QueryContext(ctx, "SELECT cell_id, cell_value, cell_result, result_type, result_text, result_error, error_cell, cell_format FROM dev_challenge WHERE sheet_id = $1", sheetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string]Input)
+	for rows.Next() {
+		data := Input{SheetID: sheetID}
+		if err := rows.Scan(&data.CellID, &data.Value, &data.Result, &data.ResultType, &data.ResultText, &data.ResultError, &data.ErrorCell, &data.Format); err != nil {
+			return nil, err
+		}
+		res[data.CellID] = data
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SetCellFormat changes the display mask of an existing cell, an empty format removes it.
+func (s *storage) SetCellFormat(ctx context.Context, tx *sql.Tx, sheetID, cellID, format string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE dev_challenge SET cell_format = $1 WHERE sheet_id = $2 AND cell_id = $3", format, sheetID, cellID)
+	return err
+}
+
+// DeleteCell removes a cell with its dependencies, it reports whether there was one.
+func (s *storage) DeleteCell(ctx context.Context, tx *sql.Tx, sheetID, cellID string) (bool, error) {
+	for _, table := range []string{"cell_dependency", "range_dependency", "pattern_dependency"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE sheet_id = $1 AND cell_id = $2", sheetID, cellID); err != nil {
+			return false, err
+		}
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM dev_challenge WHERE sheet_id = $1 AND cell_id = $2", sheetID, cellID)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := res.RowsAffected()
+	return deleted > 0, err
+}
+
+// DeleteSheet removes every cell of a sheet with their dependencies, the settings and the scenarios of the sheet.
+// It returns the number of cells removed.
+func (s *storage) DeleteSheet(ctx context.Context, tx *sql.Tx, sheetID string) (int, error) {
+	for _, table := range []string{"cell_dependency", "range_dependency", "pattern_dependency", "sheet_settings", "scenario"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE sheet_id = $1", sheetID); err != nil {
+			return 0, err
+		}
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM dev_challenge WHERE sheet_id = $1", sheetID)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	return int(deleted), err
+}
+
+// MarkDeletedCells remembers cells deleted while formulas still referenced them, AddCellInput forgets a cell again.
+func (s *storage) MarkDeletedCells(ctx context.Context, tx *sql.Tx, cells []CellRef) error {
+	for _, cell := range cells {
+		if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO deleted_cell(sheet_id, cell_id) VALUES($1,$2)", cell.SheetID, cell.CellID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDeletedCells returns the cells marked by MarkDeletedCells, tx may be nil to read outside of a transaction.
+func (s *storage) GetDeletedCells(ctx context.Context, tx *sql.Tx) ([]CellRef, error) {
+	query := "SELECT sheet_id, cell_id FROM deleted_cell ORDER BY sheet_id, cell_id"
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, query)
+	} else {
+		rows, err = s.ext.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cells []CellRef
+	for rows.Next() {
+		var cell CellRef
+		if err := rows.Scan(&cell.SheetID, &cell.CellID); err != nil {
+			return nil, err
+		}
+		cells = append(cells, cell)
+	}
+	return cells, rows.Err()
+}
+
 func (s *storage) AddCellInput(ctx context.Context, tx *sql.Tx, data Input) (resp *models.Data, wasUpdated bool, err error) {
 	var (
 		maxIDBefore, maxIDAfter int
@@ -55,13 +255,17 @@ func (s *storage) AddCellInput(ctx context.Context, tx *sql.Tx, data Input) (res
 	if err != nil {
 		log.Fatal(err)
 	}
-	stmt, err := tx.PrepareContext(ctx, "INSERT INTO dev_challenge(sheet_id, cell_id, cell_value, cell_result) VALUES($1,$2,$3,$4) "+
-		"ON CONFLICT(sheet_id, cell_id) DO UPDATE SET cell_value = EXCLUDED.cell_value, cell_result = EXCLUDED.cell_result")
+	if data.ResultType == "" {
+		data.ResultType = resultTypeNumber
+	}
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO dev_challenge(sheet_id, cell_id, cell_value, cell_result, result_type, result_text, result_error, error_cell) VALUES($1,$2,$3,$4,$5,$6,$7,$8) "+
+		"ON CONFLICT(sheet_id, cell_id) DO UPDATE SET cell_value = EXCLUDED.cell_value, cell_result = EXCLUDED.cell_result, "+
+		"result_type = EXCLUDED.result_type, result_text = EXCLUDED.result_text, result_error = EXCLUDED.result_error, error_cell = EXCLUDED.error_cell")
 	if err != nil {
 		return nil, wasItUpdate, err
 	}
 	defer stmt.Close()
-	_, err = stmt.Exec(data.SheetID, data.CellID, data.Value, data.Result)
+	_, err = stmt.Exec(data.SheetID, data.CellID, data.Value, data.Result, data.ResultType, data.ResultText, data.ResultError, data.ErrorCell)
 	if err != nil {
 		return nil, wasItUpdate, err
 	}
@@ -71,36 +275,53 @@ func (s *storage) AddCellInput(ctx context.Context, tx *sql.Tx, data Input) (res
 		return nil, wasItUpdate, err
 	}
 
-	if maxIDAfter > maxIDBefore {
-		for _, strValue := range data.UsedParams {
-			_, err = tx.ExecContext(ctx, "INSERT INTO string_array(dev_challenge_id, string_value) VALUES($1,$2)", maxIDAfter, strValue)
-			if err != nil {
-				return nil, wasItUpdate, err
-			}
+	wasItUpdate = maxIDAfter == maxIDBefore
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM deleted_cell WHERE sheet_id = $1 AND cell_id = $2", data.SheetID, data.CellID)
+	if err != nil {
+		return nil, wasItUpdate, err
+	}
+
+	// Replace the dependencies of the cell
+	_, err = tx.ExecContext(ctx, "DELETE FROM cell_dependency WHERE sheet_id = $1 AND cell_id = $2", data.SheetID, data.CellID)
+	if err != nil {
+		return nil, wasItUpdate, err
+	}
+	for _, usedParam := range data.UsedParams {
+		_, err = tx.ExecContext(ctx, "INSERT INTO cell_dependency(sheet_id, cell_id, ref_sheet_id, ref_cell_id) VALUES($1,$2,$3,$4)",
+			data.SheetID, data.CellID, usedParam.SheetID, usedParam.CellID)
+		if err != nil {
+			return nil, wasItUpdate, err
 		}
-	} else {
-		wasItUpdate = true
-		// Delete the old strings
-		_, err = tx.ExecContext(ctx, "DELETE FROM string_array WHERE dev_challenge_id = (SELECT id FROM dev_challenge WHERE sheet_id = $1 AND cell_id = $2)", data.SheetID, data.CellID)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM range_dependency WHERE sheet_id = $1 AND cell_id = $2", data.SheetID, data.CellID)
+	if err != nil {
+		return nil, wasItUpdate, err
+	}
+	for _, usedRange := range data.UsedRanges {
+		_, err = tx.ExecContext(ctx, "INSERT INTO range_dependency(sheet_id, cell_id, ref_sheet_id, from_col, from_row, to_col, to_row) VALUES($1,$2,$3,$4,$5,$6,$7)",
+			data.SheetID, data.CellID, usedRange.SheetID, usedRange.FromCol, usedRange.FromRow, usedRange.ToCol, usedRange.ToRow)
 		if err != nil {
 			return nil, wasItUpdate, err
 		}
-
-		// Then insert the new strings
-		for _, strValue := range data.UsedParams {
-			_, err = tx.ExecContext(ctx, "INSERT INTO string_array(dev_challenge_id, string_value) VALUES((SELECT id FROM dev_challenge WHERE sheet_id = $1 AND cell_id = $2),$3)", data.SheetID, data.CellID, strValue)
-			if err != nil {
-				return nil, wasItUpdate, err
-			}
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM pattern_dependency WHERE sheet_id = $1 AND cell_id = $2", data.SheetID, data.CellID)
+	if err != nil {
+		return nil, wasItUpdate, err
+	}
+	for _, usedPattern := range data.UsedPatterns {
+		_, err = tx.ExecContext(ctx, "INSERT INTO pattern_dependency(sheet_id, cell_id, ref_sheet_id, pattern) VALUES($1,$2,$3,$4)",
+			data.SheetID, data.CellID, usedPattern.SheetID, usedPattern.Pattern)
+		if err != nil {
+			return nil, wasItUpdate, err
 		}
 	}
 
-	resp = &models.Data{Value: data.Value, Result: fmt.Sprintf("%f", data.Result)}
-	return resp, wasItUpdate, nil
+	return data.response(), wasItUpdate, nil
 }
 
 func (s *storage) GetSheetInput(ctx context.Context, sheetID string) (map[string]models.Data, error) {
-	rows, err := s.ext.QueryContext(ctx, "SELECT  cell_id, cell_value, cell_result FROM dev_challenge WHERE sheet_id = $1", sheetID)
+	rows, err := s.ext.QueryContext(ctx, "SELECT  cell_id, cell_value, cell_result, result_type, result_text, result_error, error_cell FROM dev_challenge WHERE sheet_id = $1", sheetID)
 	if err != nil {
 		return nil, err
 	}
@@ -109,13 +330,10 @@ func (s *storage) GetSheetInput(ctx context.Context, sheetID string) (map[string
 	res := make(map[string]models.Data)
 	for rows.Next() {
 		var data Input
-		if err := rows.Scan(&data.CellID, &data.Value, &data.Result); err != nil {
+		if err := rows.Scan(&data.CellID, &data.Value, &data.Result, &data.ResultType, &data.ResultText, &data.ResultError, &data.ErrorCell); err != nil {
 			return nil, err
 		}
-		res[data.CellID] = models.Data{
-			Value:  data.Value,
-			Result: fmt.Sprintf("%f", data.Result),
-		}
+		res[data.CellID] = *data.response()
 	}
 
 	if err := rows.Err(); err != nil {
@@ -129,13 +347,27 @@ func (s *storage) GetSheetInput(ctx context.Context, sheetID string) (map[string
 	return res, nil
 }
 
-func (s *storage) GetCellInputBatch(ctx context.Context, tx *sql.Tx, sheetID string, cells []string) (map[string]string, error) {
+func (s *storage) GetCellInputBatch(ctx context.Context, tx *sql.Tx, sheetID string, cells []string) (map[string]Input, error) {
+	resp := make(map[string]Input)
+	for start := 0; start < len(cells); start += cellBatchChunkSize {
+		end := start + cellBatchChunkSize
+		if end > len(cells) {
+			end = len(cells)
+		}
+		if err := s.getCellInputBatchChunk(ctx, tx, sheetID, cells[start:end], resp); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (s *storage) getCellInputBatchChunk(ctx context.Context, tx *sql.Tx, sheetID string, cells []string, resp map[string]Input) error {
 	placeholders := make([]string, len(cells))
 	for i := range cells {
 		placeholders[i] = fmt.Sprintf("$%d", i+2) // starting from $2 because $1 is used for sheetID
 	}
 
-	query := fmt.Sprintf("SELECT cell_id, cell_result FROM dev_challenge WHERE sheet_id = $1 AND cell_id IN (%s)", strings.Join(placeholders, ", "))
+	query := fmt.Sprintf("SELECT cell_id, cell_value, cell_result, result_type, result_text, result_error, error_cell, cell_format FROM dev_challenge WHERE sheet_id = $1 AND cell_id IN (%s)", strings.Join(placeholders, ", "))
 
 	args := make([]interface{}, len(cells)+1)
 	args[0] = sheetID
@@ -144,87 +376,145 @@ func (s *storage) GetCellInputBatch(ctx context.Context, tx *sql.Tx, sheetID str
 	}
 
 	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data := Input{SheetID: sheetID}
+		if err := rows.Scan(&data.CellID, &data.Value, &data.Result, &data.ResultType, &data.ResultText, &data.ResultError, &data.ErrorCell, &data.Format); err != nil {
+			return err
+		}
+		resp[data.CellID] = data
+	}
+
+	return rows.Err()
+}
+
+// GetCellInputByPattern returns the cells matching the pattern keyed by cell ID.
+func (s *storage) GetCellInputByPattern(ctx context.Context, tx *sql.Tx, pattern CellPattern) (map[string]Input, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT cell_id, cell_value, cell_result, result_type, result_text, result_error, error_cell FROM dev_challenge WHERE sheet_id = $1 AND cell_id GLOB $2",
+		pattern.SheetID, pattern.Pattern)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var datas []Input
+	resp := make(map[string]Input)
 	for rows.Next() {
-		var data Input
-		if err := rows.Scan(&data.CellID, &data.Result); err != nil {
+		data := Input{SheetID: pattern.SheetID}
+		if err := rows.Scan(&data.CellID, &data.Value, &data.Result, &data.ResultType, &data.ResultText, &data.ResultError, &data.ErrorCell); err != nil {
 			return nil, err
 		}
-		datas = append(datas, data)
+		resp[data.CellID] = data
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	resp := make(map[string]string)
-	for _, val := range datas {
-		resp[val.CellID] = fmt.Sprintf("%f", val.Result)
-	}
 	return resp, nil
 }
 
-func (s *storage) GetIDList(ctx context.Context, tx *sql.Tx, cellID string) ([]int, error) {
-	query := "SELECT DISTINCT (dev_challenge_id) FROM string_array WHERE string_value=$1"
+// GetAllInputs returns every stored cell with its formula and the cells, ranges and patterns it references,
+// results are not loaded. tx may be nil outside of transactions.
+func (s *storage) GetAllInputs(ctx context.Context, tx *sql.Tx) ([]Input, error) {
+	query := func(query string) (*sql.Rows, error) {
+		if tx != nil {
+			return tx.QueryContext(ctx, query)
+		}
+		return s.ext.QueryContext(ctx, query)
+	}
 
-	rows, err := tx.QueryContext(ctx, query, cellID)
+	rows, err := query("SELECT sheet_id, cell_id, cell_value FROM dev_challenge ORDER BY id")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var IDs []int
+	var inputs []Input
+	index := make(map[CellRef]int)
 	for rows.Next() {
-		var id int
-		if err := rows.Scan(&id); err != nil {
+		var data Input
+		if err := rows.Scan(&data.SheetID, &data.CellID, &data.Value); err != nil {
 			return nil, err
 		}
-		IDs = append(IDs, id)
+		index[CellRef{SheetID: data.SheetID, CellID: data.CellID}] = len(inputs)
+		inputs = append(inputs, data)
 	}
-
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return IDs, nil
-}
+	cellRows, err := query("SELECT sheet_id, cell_id, ref_sheet_id, ref_cell_id FROM cell_dependency ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer cellRows.Close()
 
-func (s *storage) GetInputBatchByIDs(ctx context.Context, tx *sql.Tx, ids []int) (*[]Input, error) {
-	placeholders := make([]string, len(ids))
-	for i := range ids {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	for cellRows.Next() {
+		var from, to CellRef
+		if err := cellRows.Scan(&from.SheetID, &from.CellID, &to.SheetID, &to.CellID); err != nil {
+			return nil, err
+		}
+		if i, ok := index[from]; ok {
+			inputs[i].UsedParams = append(inputs[i].UsedParams, to)
+		}
+	}
+	if err := cellRows.Err(); err != nil {
+		return nil, err
 	}
 
-	query := fmt.Sprintf("SELECT sheet_id, cell_id, cell_value, cell_result FROM dev_challenge WHERE id IN (%s)", strings.Join(placeholders, ", "))
+	rangeRows, err := query("SELECT sheet_id, cell_id, ref_sheet_id, from_col, from_row, to_col, to_row FROM range_dependency ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rangeRows.Close()
 
-	args := make([]interface{}, len(ids))
-	for i, id := range ids {
-		args[i] = id
+	for rangeRows.Next() {
+		var from CellRef
+		var to CellRange
+		if err := rangeRows.Scan(&from.SheetID, &from.CellID, &to.SheetID, &to.FromCol, &to.FromRow, &to.ToCol, &to.ToRow); err != nil {
+			return nil, err
+		}
+		if i, ok := index[from]; ok {
+			inputs[i].UsedRanges = append(inputs[i].UsedRanges, to)
+		}
+	}
+	if err := rangeRows.Err(); err != nil {
+		return nil, err
 	}
 
-	rows, err := tx.QueryContext(ctx, query, args...)
+	patternRows, err := query("SELECT sheet_id, cell_id, ref_sheet_id, pattern FROM pattern_dependency ORDER BY id")
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	defer patternRows.Close()
 
-	var datas []Input
-	for rows.Next() {
-		var data Input
-		if err := rows.Scan(&data.SheetID, &data.CellID, &data.Value, &data.Result); err != nil {
+	for patternRows.Next() {
+		var from CellRef
+		var to CellPattern
+		if err := patternRows.Scan(&from.SheetID, &from.CellID, &to.SheetID, &to.Pattern); err != nil {
 			return nil, err
 		}
-		datas = append(datas, data)
+		if i, ok := index[from]; ok {
+			inputs[i].UsedPatterns = append(inputs[i].UsedPatterns, to)
+		}
 	}
-
-	if err := rows.Err(); err != nil {
+	if err := patternRows.Err(); err != nil {
 		return nil, err
 	}
 
-	return &datas, nil
+	return inputs, nil
+}
+
+// SaveCellResult updates the result of an existing cell, its formula and dependencies stay as they are.
+func (s *storage) SaveCellResult(ctx context.Context, tx *sql.Tx, data Input) error {
+	if data.ResultType == "" {
+		data.ResultType = resultTypeNumber
+	}
+	_, err := tx.ExecContext(ctx, "UPDATE dev_challenge SET cell_result = $1, result_type = $2, result_text = $3, result_error = $4, error_cell = $5 "+
+		"WHERE sheet_id = $6 AND cell_id = $7", data.Result, data.ResultType, data.ResultText, data.ResultError, data.ErrorCell, data.SheetID, data.CellID)
+	return err
 }
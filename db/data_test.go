@@ -2,7 +2,6 @@ package db
 
 import (
 	"context"
-	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -57,7 +56,7 @@ func TestStorage_AddCellInput(t *testing.T) {
 		CellID:     "cell3",
 		Value:      "=cell1+cell2",
 		Result:     4,
-		UsedParams: []string{"cell1", "cell2"},
+		UsedParams: []CellRef{{SheetID: "sheet1", CellID: "cell1"}, {SheetID: "sheet1", CellID: "cell2"}},
 	})
 	require.NoError(t, err)
 	require.False(t, flag)
@@ -70,7 +69,7 @@ func TestStorage_AddCellInput(t *testing.T) {
 		CellID:     "cell3",
 		Value:      "=cell1+cell2+45",
 		Result:     4,
-		UsedParams: []string{"cell1", "cell2"},
+		UsedParams: []CellRef{{SheetID: "sheet1", CellID: "cell1"}, {SheetID: "sheet1", CellID: "cell2"}},
 	})
 	require.NoError(t, err)
 	require.True(t, flag)
@@ -125,6 +124,133 @@ func TestStorage_GetSheetInput(t *testing.T) {
 	require.Equal(t, 3, len(res))
 }
 
+func TestStorage_AddCellInput_Text(t *testing.T) {
+	defer cleanup()
+
+	store := NewStorage(conn)
+	tx, err := store.BeginTransaction(context.TODO())
+	require.NoError(t, err)
+
+	data, _, err := store.AddCellInput(context.TODO(), tx, Input{
+		SheetID:    "sheet1",
+		CellID:     "name",
+		Value:      `=UPPER("acme") & " Ltd"`,
+		ResultType: "text",
+		ResultText: "ACME Ltd",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ACME Ltd", data.Result)
+	require.Equal(t, "text", data.Type)
+
+	err = tx.Commit()
+	require.NoError(t, err)
+
+	data, err = store.GetCellInput(context.TODO(), "sheet1", "name")
+	require.NoError(t, err)
+	require.Equal(t, "ACME Ltd", data.Result)
+	require.Equal(t, "text", data.Type)
+
+	res, err := store.GetSheetInput(context.TODO(), "sheet1")
+	require.NoError(t, err)
+	require.Equal(t, "ACME Ltd", res["name"].Result)
+	require.Equal(t, "text", res["name"].Type)
+}
+
+func TestStorage_AddCellInput_Boolean(t *testing.T) {
+	defer cleanup()
+
+	store := NewStorage(conn)
+	tx, err := store.BeginTransaction(context.TODO())
+	require.NoError(t, err)
+
+	data, _, err := store.AddCellInput(context.TODO(), tx, Input{
+		SheetID:    "sheet1",
+		CellID:     "discount",
+		Value:      "=qty>100",
+		Result:     1,
+		ResultType: "boolean",
+		ResultText: "TRUE",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "TRUE", data.Result)
+	require.Equal(t, "boolean", data.Type)
+
+	res, err := store.GetCellInputBatch(context.TODO(), tx, "sheet1", []string{"discount"})
+	require.NoError(t, err)
+	require.Equal(t, float64(1), res["discount"].Result)
+	require.Equal(t, "boolean", res["discount"].ResultType)
+
+	err = tx.Commit()
+	require.NoError(t, err)
+}
+
+func TestStorage_AddCellInput_Decimal(t *testing.T) {
+	defer cleanup()
+
+	store := NewStorage(conn)
+	tx, err := store.BeginTransaction(context.TODO())
+	require.NoError(t, err)
+
+	data, _, err := store.AddCellInput(context.TODO(), tx, Input{
+		SheetID:    "sheet1",
+		CellID:     "total",
+		Value:      "=0.1+0.2",
+		Result:     0.3,
+		ResultType: "number",
+		ResultText: "0.30",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "0.30", data.Result)
+	require.Equal(t, "number", data.Type)
+
+	err = tx.Commit()
+	require.NoError(t, err)
+
+	resp, err := store.GetCellInput(context.TODO(), "sheet1", "total")
+	require.NoError(t, err)
+	require.Equal(t, "0.30", resp.Result)
+}
+
+func TestStorage_AddCellInput_Error(t *testing.T) {
+	defer cleanup()
+
+	store := NewStorage(conn)
+	tx, err := store.BeginTransaction(context.TODO())
+	require.NoError(t, err)
+
+	data, _, err := store.AddCellInput(context.TODO(), tx, Input{
+		SheetID:     "sheet1",
+		CellID:      "d",
+		Value:       "=c+1",
+		ResultType:  "error",
+		ResultText:  "#DIV/0!",
+		ResultError: "division by zero",
+		ErrorCell:   "sheet1!c",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "#DIV/0!", data.Result)
+	require.Equal(t, "error", data.Type)
+	require.Equal(t, "sheet1!c: division by zero", data.Error)
+
+	res, err := store.GetCellInputBatch(context.TODO(), tx, "sheet1", []string{"d"})
+	require.NoError(t, err)
+	require.Equal(t, "division by zero", res["d"].ResultError)
+	require.Equal(t, "sheet1!c", res["d"].ErrorCell)
+
+	err = tx.Commit()
+	require.NoError(t, err)
+
+	data, err = store.GetCellInput(context.TODO(), "sheet1", "d")
+	require.NoError(t, err)
+	require.Equal(t, "#DIV/0!", data.Result)
+	require.Equal(t, "sheet1!c: division by zero", data.Error)
+
+	sheet, err := store.GetSheetInput(context.TODO(), "sheet1")
+	require.NoError(t, err)
+	require.Equal(t, "#DIV/0!", sheet["d"].Result)
+	require.Equal(t, "sheet1!c: division by zero", sheet["d"].Error)
+}
+
 func TestStorage_GetCellInputBatch(t *testing.T) {
 	defer cleanup()
 
@@ -173,127 +299,185 @@ func TestStorage_GetCellInputBatch(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Equal(t, 3, len(res))
-	require.Equal(t, "1.000000", res["cell1"])
-	require.Equal(t, "2.000000", res["cell2"])
-	require.Equal(t, "3.000000", res["cell3"])
+	require.Equal(t, 1.0, res["cell1"].Result)
+	require.Equal(t, 2.0, res["cell2"].Result)
+	require.Equal(t, 3.0, res["cell3"].Result)
+	require.Equal(t, "number", res["cell3"].ResultType)
 }
 
-func TestStorage_GetIDList(t *testing.T) {
+func TestStorage_GetCellInputByPattern(t *testing.T) {
 	defer cleanup()
 
 	store := NewStorage(conn)
 	tx, err := store.BeginTransaction(context.TODO())
 	require.NoError(t, err)
 
-	// add raws with named params
-	data, flag, err := store.AddCellInput(context.TODO(), tx, Input{
-		SheetID:    "sheet1",
-		CellID:     "cell1",
-		Value:      "1",
-		Result:     1,
-		UsedParams: nil,
-	})
+	for _, input := range []Input{
+		{SheetID: "sheet1", CellID: "sales_jan", Value: "1", Result: 1},
+		{SheetID: "sheet1", CellID: "sales_feb", Value: "2", Result: 2},
+		{SheetID: "sheet1", CellID: "total_sales", Value: "3", Result: 3},
+		{SheetID: "sheet2", CellID: "sales_jan", Value: "4", Result: 4},
+	} {
+		_, _, err = store.AddCellInput(context.TODO(), tx, input)
+		require.NoError(t, err)
+	}
+
+	res, err := store.GetCellInputByPattern(context.TODO(), tx, CellPattern{SheetID: "sheet1", Pattern: "sales_*"})
+	require.NoError(t, err)
+	res2, err := store.GetCellInputByPattern(context.TODO(), tx, CellPattern{SheetID: "sheet1", Pattern: "*_???"})
 	require.NoError(t, err)
-	require.False(t, flag)
-	require.Equal(t, data.Value, "1")
-	require.Equal(t, data.Result, "1.000000")
 
-	data, flag, err = store.AddCellInput(context.TODO(), tx, Input{
-		SheetID:    "sheet1",
-		CellID:     "cell2",
-		Value:      "=cell1+2",
-		Result:     3,
-		UsedParams: []string{"cell1"},
-	})
+	err = tx.Commit()
 	require.NoError(t, err)
-	require.False(t, flag)
-	require.Equal(t, data.Value, "=cell1+2")
-	require.Equal(t, data.Result, "3.000000")
 
-	data, flag, err = store.AddCellInput(context.TODO(), tx, Input{
-		SheetID:    "sheet1",
-		CellID:     "cell3",
-		Value:      "=cell1+cell2",
-		Result:     4,
-		UsedParams: []string{"cell1", "cell2"},
-	})
+	for _, matched := range []map[string]Input{res, res2} {
+		require.Equal(t, 2, len(matched))
+		require.Equal(t, 1.0, matched["sales_jan"].Result)
+		require.Equal(t, 2.0, matched["sales_feb"].Result)
+	}
+}
+
+func TestStorage_CellFormat(t *testing.T) {
+	defer cleanup()
+
+	store := NewStorage(conn)
+	tx, err := store.BeginTransaction(context.TODO())
 	require.NoError(t, err)
-	require.False(t, flag)
-	require.Equal(t, data.Value, "=cell1+cell2")
-	require.Equal(t, data.Result, "4.000000")
 
-	err = tx.Commit()
+	_, _, err = store.AddCellInput(context.TODO(), tx, Input{SheetID: "sheet1", CellID: "price", Value: "1234.5", Result: 1234.5})
+	require.NoError(t, err)
+	require.NoError(t, store.SetCellFormat(context.TODO(), tx, "sheet1", "price", "#,##0.00"))
+	// updating the value keeps the format
+	_, _, err = store.AddCellInput(context.TODO(), tx, Input{SheetID: "sheet1", CellID: "price", Value: "99", Result: 99})
 	require.NoError(t, err)
 
-	tx2, err := store.BeginTransaction(context.TODO())
+	data, err := store.GetInput(context.TODO(), tx, "sheet1", "price")
 	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	require.Equal(t, &Input{SheetID: "sheet1", CellID: "price", Value: "99", Result: 99, ResultType: "number", Format: "#,##0.00"}, data)
 
-	res, err := store.GetIDList(context.TODO(), tx2, "cell1")
+	missing, err := store.GetInput(context.TODO(), nil, "sheet1", "qty")
 	require.NoError(t, err)
+	require.Nil(t, missing)
 
-	err = tx2.Commit()
+	sheet, err := store.GetSheetInputs(context.TODO(), "sheet1")
 	require.NoError(t, err)
-	require.Equal(t, 2, len(res))
+	require.Equal(t, map[string]Input{"price": *data}, sheet)
 }
 
-func TestStorage_GetInputBatchByIDs(t *testing.T) {
+func TestStorage_GetAllInputs(t *testing.T) {
 	defer cleanup()
 
 	store := NewStorage(conn)
 	tx, err := store.BeginTransaction(context.TODO())
 	require.NoError(t, err)
 
-	// add raws with named params
-	data, flag, err := store.AddCellInput(context.TODO(), tx, Input{
-		SheetID:    "sheet1",
-		CellID:     "cell1",
-		Value:      "1",
-		Result:     1,
-		UsedParams: nil,
-	})
+	for _, input := range []Input{
+		{SheetID: "sheet1", CellID: "a1", Value: "1", Result: 1},
+		{SheetID: "sheet1", CellID: "total", Value: "=sum(a1:a3)+sheet2!x+sheet2!y", Result: 1,
+			UsedParams: []CellRef{{SheetID: "sheet2", CellID: "x"}, {SheetID: "sheet2", CellID: "y"}},
+			UsedRanges: []CellRange{{SheetID: "sheet1", FromCol: 1, FromRow: 1, ToCol: 1, ToRow: 3}}},
+		{SheetID: "sheet2", CellID: "avg", Value: "=average(sales_*)", Result: 0, UsedPatterns: []CellPattern{{SheetID: "sheet2", Pattern: "sales_*"}}},
+	} {
+		_, _, err = store.AddCellInput(context.TODO(), tx, input)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tx.Commit())
+
+	inputs, err := store.GetAllInputs(context.TODO(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []Input{
+		{SheetID: "sheet0", CellID: "cell0", Value: "0"},
+		{SheetID: "sheet1", CellID: "a1", Value: "1"},
+		{SheetID: "sheet1", CellID: "total", Value: "=sum(a1:a3)+sheet2!x+sheet2!y",
+			UsedParams: []CellRef{{SheetID: "sheet2", CellID: "x"}, {SheetID: "sheet2", CellID: "y"}},
+			UsedRanges: []CellRange{{SheetID: "sheet1", FromCol: 1, FromRow: 1, ToCol: 1, ToRow: 3}}},
+		{SheetID: "sheet2", CellID: "avg", Value: "=average(sales_*)", UsedPatterns: []CellPattern{{SheetID: "sheet2", Pattern: "sales_*"}}},
+	}, inputs)
+}
+
+func TestStorage_SaveCellResult(t *testing.T) {
+	defer cleanup()
+
+	store := NewStorage(conn)
+	tx, err := store.BeginTransaction(context.TODO())
 	require.NoError(t, err)
-	require.False(t, flag)
-	require.Equal(t, data.Value, "1")
-	require.Equal(t, data.Result, "1.000000")
 
-	data, flag, err = store.AddCellInput(context.TODO(), tx, Input{
-		SheetID:    "sheet1",
-		CellID:     "cell2",
-		Value:      "=cell1+2",
-		Result:     3,
-		UsedParams: []string{"cell1"},
-	})
+	_, _, err = store.AddCellInput(context.TODO(), tx, Input{SheetID: "sheet1", CellID: "b", Value: "=a*2", Result: 2, UsedParams: []CellRef{{SheetID: "sheet1", CellID: "a"}}})
 	require.NoError(t, err)
-	require.False(t, flag)
-	require.Equal(t, data.Value, "=cell1+2")
-	require.Equal(t, data.Result, "3.000000")
+	require.NoError(t, store.SaveCellResult(context.TODO(), tx, Input{SheetID: "sheet1", CellID: "b", ResultType: "error", ResultText: "#REF!", ResultError: "referenced cell \"a\" not found"}))
 
-	data, flag, err = store.AddCellInput(context.TODO(), tx, Input{
-		SheetID:    "sheet1",
-		CellID:     "cell3",
-		Value:      "=cell1+cell2",
-		Result:     4,
-		UsedParams: []string{"cell1", "cell2"},
-	})
+	data, err := store.GetInput(context.TODO(), tx, "sheet1", "b")
 	require.NoError(t, err)
-	require.False(t, flag)
-	require.Equal(t, data.Value, "=cell1+cell2")
-	require.Equal(t, data.Result, "4.000000")
+	require.NoError(t, tx.Commit())
+	require.Equal(t, &Input{SheetID: "sheet1", CellID: "b", Value: "=a*2", ResultType: "error", ResultText: "#REF!", ResultError: "referenced cell \"a\" not found"}, data)
 
-	err = tx.Commit()
+	inputs, err := store.GetAllInputs(context.TODO(), nil)
 	require.NoError(t, err)
+	require.Equal(t, Input{SheetID: "sheet1", CellID: "b", Value: "=a*2", UsedParams: []CellRef{{SheetID: "sheet1", CellID: "a"}}}, inputs[1])
+}
 
-	tx2, err := store.BeginTransaction(context.TODO())
+func TestStorage_Delete(t *testing.T) {
+	defer cleanup()
+
+	store := NewStorage(conn)
+	tx, err := store.BeginTransaction(context.TODO())
+	require.NoError(t, err)
+	for _, input := range []Input{
+		{SheetID: "sheet1", CellID: "a1", Value: "1", Result: 1},
+		{SheetID: "sheet1", CellID: "total", Value: "=sum(a1:a3)+sheet2!x", Result: 1,
+			UsedParams: []CellRef{{SheetID: "sheet2", CellID: "x"}},
+			UsedRanges: []CellRange{{SheetID: "sheet1", FromCol: 1, FromRow: 1, ToCol: 1, ToRow: 3}}},
+		{SheetID: "sheet2", CellID: "x", Value: "2", Result: 2},
+		{SheetID: "sheet2", CellID: "avg", Value: "=average(sales_*)", Result: 0, UsedPatterns: []CellPattern{{SheetID: "sheet2", Pattern: "sales_*"}}},
+	} {
+		_, _, err = store.AddCellInput(context.TODO(), tx, input)
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.SaveSheetSettings(context.TODO(), tx, SheetSettings{SheetID: "sheet2", NumberMode: "decimal", Precision: 2, Rounding: "half_up", MissingRefs: "ref"}))
+	require.NoError(t, store.SaveScenario(context.TODO(), tx, Scenario{SheetID: "sheet2", Name: "high", Overrides: map[string]string{"x": "3"}}))
+
+	deleted, err := store.DeleteCell(context.TODO(), tx, "sheet1", "total")
+	require.NoError(t, err)
+	require.True(t, deleted)
+	deleted, err = store.DeleteCell(context.TODO(), tx, "sheet1", "total")
 	require.NoError(t, err)
+	require.False(t, deleted)
 
-	res, err := store.GetInputBatchByIDs(context.TODO(), tx2, []int{2, 3}) // 1 is set during applying migration
+	count, err := store.DeleteSheet(context.TODO(), tx, "sheet2")
 	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.NoError(t, tx.Commit())
 
-	err = tx2.Commit()
+	inputs, err := store.GetAllInputs(context.TODO(), nil)
 	require.NoError(t, err)
-	require.Equal(t, 2, len(*res))
-	for id, val := range *res {
-		require.Equal(t, "sheet1", val.SheetID)
-		require.Equal(t, fmt.Sprintf("cell%d", id+1), val.CellID)
+	require.Equal(t, []Input{{SheetID: "sheet0", CellID: "cell0", Value: "0"}, {SheetID: "sheet1", CellID: "a1", Value: "1"}}, inputs)
+	for _, table := range []string{"cell_dependency", "range_dependency", "pattern_dependency", "sheet_settings", "scenario"} {
+		var rows int
+		require.NoError(t, conn.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&rows))
+		require.Zero(t, rows, table)
 	}
 }
+
+func TestStorage_DeletedCells(t *testing.T) {
+	defer cleanup()
+
+	store := NewStorage(conn)
+	tx, err := store.BeginTransaction(context.TODO())
+	require.NoError(t, err)
+	cells := []CellRef{{SheetID: "sheet1", CellID: "a"}, {SheetID: "sheet2", CellID: "b"}}
+	require.NoError(t, store.MarkDeletedCells(context.TODO(), tx, cells))
+	require.NoError(t, store.MarkDeletedCells(context.TODO(), tx, cells[:1]))
+	got, err := store.GetDeletedCells(context.TODO(), tx)
+	require.NoError(t, err)
+	require.Equal(t, cells, got)
+
+	// writing the cell again forgets it was deleted
+	_, _, err = store.AddCellInput(context.TODO(), tx, Input{SheetID: "sheet1", CellID: "a", Value: "1", Result: 1})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	got, err = store.GetDeletedCells(context.TODO(), nil)
+	require.NoError(t, err)
+	require.Equal(t, cells[1:], got)
+}
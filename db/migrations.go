@@ -1,6 +1,15 @@
 package db
 
-var Migrations = `
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Migrations are applied in order, PRAGMA user_version keeps the number of already applied ones.
+// Applied migrations must never be changed, append a new one instead.
+var Migrations = []string{
+	`
 CREATE TABLE IF NOT EXISTS dev_challenge (
 id INTEGER PRIMARY KEY AUTOINCREMENT,
 sheet_id VARCHAR(255),
@@ -18,4 +27,126 @@ dev_challenge_id INTEGER,
 string_value VARCHAR(255),
 FOREIGN KEY(dev_challenge_id) REFERENCES dev_challenge(id)
 );
-INSERT INTO dev_challenge (sheet_id, cell_id, cell_value, cell_result) VALUES ('0','0','0',0) ON CONFLICT DO NOTHING;`
+INSERT INTO dev_challenge (sheet_id, cell_id, cell_value, cell_result) VALUES ('0','0','0',0) ON CONFLICT DO NOTHING;`,
+
+	// cell_dependency replaces string_array: the referencing cell is bound to its row in dev_challenge,
+	// so dependencies are removed together with the cell, while the referenced cell is only indexed
+	// to find the cells to recalculate.
+	`
+CREATE TABLE IF NOT EXISTS cell_dependency (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+sheet_id VARCHAR(255) NOT NULL,
+cell_id VARCHAR(255) NOT NULL,
+ref_sheet_id VARCHAR(255) NOT NULL,
+ref_cell_id VARCHAR(255) NOT NULL,
+FOREIGN KEY(sheet_id, cell_id) REFERENCES dev_challenge(sheet_id, cell_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS unique_cell_dependency_idx ON cell_dependency(sheet_id, cell_id, ref_sheet_id, ref_cell_id);
+CREATE INDEX IF NOT EXISTS cell_dependency_ref_idx ON cell_dependency(ref_sheet_id, ref_cell_id);
+
+INSERT OR IGNORE INTO cell_dependency (sheet_id, cell_id, ref_sheet_id, ref_cell_id)
+SELECT d.sheet_id, d.cell_id, d.sheet_id, s.string_value FROM string_array s JOIN dev_challenge d ON d.id = s.dev_challenge_id;
+DROP TABLE string_array;`,
+
+	// range_dependency keeps ranges used by formulas, so cells created inside a range later
+	// still trigger recalculation of the formulas using it.
+	`
+CREATE TABLE IF NOT EXISTS range_dependency (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+sheet_id VARCHAR(255) NOT NULL,
+cell_id VARCHAR(255) NOT NULL,
+ref_sheet_id VARCHAR(255) NOT NULL,
+from_col INTEGER NOT NULL,
+from_row INTEGER NOT NULL,
+to_col INTEGER NOT NULL,
+to_row INTEGER NOT NULL,
+FOREIGN KEY(sheet_id, cell_id) REFERENCES dev_challenge(sheet_id, cell_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS range_dependency_cell_idx ON range_dependency(sheet_id, cell_id);
+CREATE INDEX IF NOT EXISTS range_dependency_ref_idx ON range_dependency(ref_sheet_id, from_col, to_col);`,
+
+	// pattern_dependency keeps wildcard patterns like sales_* used by formulas, they are matched with GLOB.
+	`
+CREATE TABLE IF NOT EXISTS pattern_dependency (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+sheet_id VARCHAR(255) NOT NULL,
+cell_id VARCHAR(255) NOT NULL,
+ref_sheet_id VARCHAR(255) NOT NULL,
+pattern VARCHAR(255) NOT NULL,
+FOREIGN KEY(sheet_id, cell_id) REFERENCES dev_challenge(sheet_id, cell_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS pattern_dependency_cell_idx ON pattern_dependency(sheet_id, cell_id);
+CREATE INDEX IF NOT EXISTS pattern_dependency_ref_idx ON pattern_dependency(ref_sheet_id);`,
+
+	// result_type tells how to read the result of a cell, text results are kept in result_text.
+	`
+ALTER TABLE dev_challenge ADD COLUMN result_type VARCHAR(16) NOT NULL DEFAULT 'number';
+ALTER TABLE dev_challenge ADD COLUMN result_text TEXT NOT NULL DEFAULT '';`,
+
+	// cells in an error state keep the error code in result_text, its description in result_error
+	// and the cell the error comes from in error_cell.
+	`
+ALTER TABLE dev_challenge ADD COLUMN result_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE dev_challenge ADD COLUMN error_cell TEXT NOT NULL DEFAULT '';`,
+
+	// sheet_settings keeps the number mode of sheets, sheets without a row calculate with float64.
+	`
+CREATE TABLE IF NOT EXISTS sheet_settings (
+sheet_id VARCHAR(255) PRIMARY KEY,
+number_mode VARCHAR(16) NOT NULL DEFAULT 'float',
+decimal_precision INTEGER NOT NULL DEFAULT 2,
+rounding VARCHAR(16) NOT NULL DEFAULT 'half_up'
+);`,
+
+	// cell_format is the display mask of a cell like "0.00", results are formatted with it by the v2 API.
+	`
+ALTER TABLE dev_challenge ADD COLUMN cell_format VARCHAR(255) NOT NULL DEFAULT '';`,
+
+	// missing_refs tells how formulas of a sheet treat references to cells that don't exist yet.
+	`
+ALTER TABLE sheet_settings ADD COLUMN missing_refs VARCHAR(16) NOT NULL DEFAULT 'ref';`,
+
+	// scenario keeps named what-if scenarios of a sheet, overrides and cells are JSON.
+	`
+CREATE TABLE IF NOT EXISTS scenario (
+sheet_id VARCHAR(255) NOT NULL,
+name VARCHAR(255) NOT NULL,
+overrides TEXT NOT NULL,
+cells TEXT NOT NULL,
+PRIMARY KEY (sheet_id, name)
+);`,
+
+	// deleted_cell keeps cells deleted while formulas still referenced them, writing the cell again removes it.
+	`
+CREATE TABLE IF NOT EXISTS deleted_cell (
+sheet_id VARCHAR(255) NOT NULL,
+cell_id VARCHAR(255) NOT NULL,
+PRIMARY KEY (sheet_id, cell_id)
+);`,
+}
+
+// Migrate brings the database schema up to date, every migration runs in its own transaction.
+func Migrate(ctx context.Context, conn *sql.DB) error {
+	var version int
+	if err := conn.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	for i := version; i < len(Migrations); i++ {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		if _, err = tx.ExecContext(ctx, Migrations[i]); err == nil {
+			_, err = tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1))
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	database, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "migrate.db")+"?_foreign_keys=on")
+	require.NoError(t, err)
+	defer database.Close()
+
+	// database created before dependencies were scoped by sheet
+	_, err = database.Exec(Migrations[0])
+	require.NoError(t, err)
+	_, err = database.Exec("PRAGMA user_version = 1")
+	require.NoError(t, err)
+	_, err = database.Exec("INSERT INTO dev_challenge (sheet_id, cell_id, cell_value, cell_result) VALUES " +
+		"('sheet1','a','1',1), ('sheet1','b','=a+1',2), ('sheet2','c','=a',0)")
+	require.NoError(t, err)
+	_, err = database.Exec("INSERT INTO string_array (dev_challenge_id, string_value) VALUES (3,'a'), (4,'a')")
+	require.NoError(t, err)
+
+	err = Migrate(context.TODO(), database)
+	require.NoError(t, err)
+
+	rows, err := database.Query("SELECT sheet_id, cell_id, ref_sheet_id, ref_cell_id FROM cell_dependency ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+	var deps [][4]string
+	for rows.Next() {
+		var dep [4]string
+		require.NoError(t, rows.Scan(&dep[0], &dep[1], &dep[2], &dep[3]))
+		deps = append(deps, dep)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, [][4]string{
+		{"sheet1", "b", "sheet1", "a"},
+		{"sheet2", "c", "sheet2", "a"},
+	}, deps)
+
+	var version int
+	require.NoError(t, database.QueryRow("PRAGMA user_version").Scan(&version))
+	require.Equal(t, len(Migrations), version)
+
+	// applying again is a no-op
+	require.NoError(t, Migrate(context.TODO(), database))
+
+	// dependencies are removed together with the cell
+	_, err = database.Exec("DELETE FROM dev_challenge WHERE sheet_id = 'sheet1' AND cell_id = 'b'")
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, database.QueryRow("SELECT COUNT(*) FROM cell_dependency").Scan(&count))
+	require.Equal(t, 1, count)
+}
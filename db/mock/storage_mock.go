@@ -68,6 +68,66 @@ func (mr *MockStorageMockRecorder) BeginTransaction(ctx interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTransaction", reflect.TypeOf((*MockStorage)(nil).BeginTransaction), ctx)
 }
 
+// DeleteCell mocks base method.
+func (m *MockStorage) DeleteCell(ctx context.Context, tx *sql.Tx, sheetID, cellID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCell", ctx, tx, sheetID, cellID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteCell indicates an expected call of DeleteCell.
+func (mr *MockStorageMockRecorder) DeleteCell(ctx, tx, sheetID, cellID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCell", reflect.TypeOf((*MockStorage)(nil).DeleteCell), ctx, tx, sheetID, cellID)
+}
+
+// DeleteScenario mocks base method.
+func (m *MockStorage) DeleteScenario(ctx context.Context, tx *sql.Tx, sheetID, name string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteScenario", ctx, tx, sheetID, name)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteScenario indicates an expected call of DeleteScenario.
+func (mr *MockStorageMockRecorder) DeleteScenario(ctx, tx, sheetID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScenario", reflect.TypeOf((*MockStorage)(nil).DeleteScenario), ctx, tx, sheetID, name)
+}
+
+// DeleteSheet mocks base method.
+func (m *MockStorage) DeleteSheet(ctx context.Context, tx *sql.Tx, sheetID string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSheet", ctx, tx, sheetID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSheet indicates an expected call of DeleteSheet.
+func (mr *MockStorageMockRecorder) DeleteSheet(ctx, tx, sheetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSheet", reflect.TypeOf((*MockStorage)(nil).DeleteSheet), ctx, tx, sheetID)
+}
+
+// GetAllInputs mocks base method.
+func (m *MockStorage) GetAllInputs(ctx context.Context, tx *sql.Tx) ([]db.Input, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllInputs", ctx, tx)
+	ret0, _ := ret[0].([]db.Input)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllInputs indicates an expected call of GetAllInputs.
+func (mr *MockStorageMockRecorder) GetAllInputs(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllInputs", reflect.TypeOf((*MockStorage)(nil).GetAllInputs), ctx, tx)
+}
+
 // GetCellInput mocks base method.
 func (m *MockStorage) GetCellInput(ctx context.Context, sheetID, cellID string) (*models.Data, error) {
 	m.ctrl.T.Helper()
@@ -84,10 +144,10 @@ func (mr *MockStorageMockRecorder) GetCellInput(ctx, sheetID, cellID interface{}
 }
 
 // GetCellInputBatch mocks base method.
-func (m *MockStorage) GetCellInputBatch(ctx context.Context, tx *sql.Tx, sheetID string, cells []string) (map[string]string, error) {
+func (m *MockStorage) GetCellInputBatch(ctx context.Context, tx *sql.Tx, sheetID string, cells []string) (map[string]db.Input, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetCellInputBatch", ctx, tx, sheetID, cells)
-	ret0, _ := ret[0].(map[string]string)
+	ret0, _ := ret[0].(map[string]db.Input)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -98,34 +158,79 @@ func (mr *MockStorageMockRecorder) GetCellInputBatch(ctx, tx, sheetID, cells int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCellInputBatch", reflect.TypeOf((*MockStorage)(nil).GetCellInputBatch), ctx, tx, sheetID, cells)
 }
 
-// GetIDList mocks base method.
-func (m *MockStorage) GetIDList(ctx context.Context, tx *sql.Tx, cellID string) ([]int, error) {
+// GetCellInputByPattern mocks base method.
+func (m *MockStorage) GetCellInputByPattern(ctx context.Context, tx *sql.Tx, pattern db.CellPattern) (map[string]db.Input, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCellInputByPattern", ctx, tx, pattern)
+	ret0, _ := ret[0].(map[string]db.Input)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCellInputByPattern indicates an expected call of GetCellInputByPattern.
+func (mr *MockStorageMockRecorder) GetCellInputByPattern(ctx, tx, pattern interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCellInputByPattern", reflect.TypeOf((*MockStorage)(nil).GetCellInputByPattern), ctx, tx, pattern)
+}
+
+// GetDeletedCells mocks base method.
+func (m *MockStorage) GetDeletedCells(ctx context.Context, tx *sql.Tx) ([]db.CellRef, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedCells", ctx, tx)
+	ret0, _ := ret[0].([]db.CellRef)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedCells indicates an expected call of GetDeletedCells.
+func (mr *MockStorageMockRecorder) GetDeletedCells(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedCells", reflect.TypeOf((*MockStorage)(nil).GetDeletedCells), ctx, tx)
+}
+
+// GetInput mocks base method.
+func (m *MockStorage) GetInput(ctx context.Context, tx *sql.Tx, sheetID, cellID string) (*db.Input, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInput", ctx, tx, sheetID, cellID)
+	ret0, _ := ret[0].(*db.Input)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInput indicates an expected call of GetInput.
+func (mr *MockStorageMockRecorder) GetInput(ctx, tx, sheetID, cellID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInput", reflect.TypeOf((*MockStorage)(nil).GetInput), ctx, tx, sheetID, cellID)
+}
+
+// GetScenario mocks base method.
+func (m *MockStorage) GetScenario(ctx context.Context, sheetID, name string) (*db.Scenario, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetIDList", ctx, tx, cellID)
-	ret0, _ := ret[0].([]int)
+	ret := m.ctrl.Call(m, "GetScenario", ctx, sheetID, name)
+	ret0, _ := ret[0].(*db.Scenario)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetIDList indicates an expected call of GetIDList.
-func (mr *MockStorageMockRecorder) GetIDList(ctx, tx, cellID interface{}) *gomock.Call {
+// GetScenario indicates an expected call of GetScenario.
+func (mr *MockStorageMockRecorder) GetScenario(ctx, sheetID, name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIDList", reflect.TypeOf((*MockStorage)(nil).GetIDList), ctx, tx, cellID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScenario", reflect.TypeOf((*MockStorage)(nil).GetScenario), ctx, sheetID, name)
 }
 
-// GetInputBatchByIDs mocks base method.
-func (m *MockStorage) GetInputBatchByIDs(ctx context.Context, tx *sql.Tx, IDs []int) (*[]db.Input, error) {
+// GetScenarios mocks base method.
+func (m *MockStorage) GetScenarios(ctx context.Context, sheetID string) ([]db.Scenario, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetInputBatchByIDs", ctx, tx, IDs)
-	ret0, _ := ret[0].(*[]db.Input)
+	ret := m.ctrl.Call(m, "GetScenarios", ctx, sheetID)
+	ret0, _ := ret[0].([]db.Scenario)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetInputBatchByIDs indicates an expected call of GetInputBatchByIDs.
-func (mr *MockStorageMockRecorder) GetInputBatchByIDs(ctx, tx, IDs interface{}) *gomock.Call {
+// GetScenarios indicates an expected call of GetScenarios.
+func (mr *MockStorageMockRecorder) GetScenarios(ctx, sheetID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInputBatchByIDs", reflect.TypeOf((*MockStorage)(nil).GetInputBatchByIDs), ctx, tx, IDs)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScenarios", reflect.TypeOf((*MockStorage)(nil).GetScenarios), ctx, sheetID)
 }
 
 // GetSheetInput mocks base method.
@@ -142,3 +247,103 @@ func (mr *MockStorageMockRecorder) GetSheetInput(ctx, sheetID interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSheetInput", reflect.TypeOf((*MockStorage)(nil).GetSheetInput), ctx, sheetID)
 }
+
+// GetSheetInputs mocks base method.
+func (m *MockStorage) GetSheetInputs(ctx context.Context, sheetID string) (map[string]db.Input, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSheetInputs", ctx, sheetID)
+	ret0, _ := ret[0].(map[string]db.Input)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSheetInputs indicates an expected call of GetSheetInputs.
+func (mr *MockStorageMockRecorder) GetSheetInputs(ctx, sheetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSheetInputs", reflect.TypeOf((*MockStorage)(nil).GetSheetInputs), ctx, sheetID)
+}
+
+// GetSheetSettings mocks base method.
+func (m *MockStorage) GetSheetSettings(ctx context.Context, tx *sql.Tx, sheetID string) (db.SheetSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSheetSettings", ctx, tx, sheetID)
+	ret0, _ := ret[0].(db.SheetSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSheetSettings indicates an expected call of GetSheetSettings.
+func (mr *MockStorageMockRecorder) GetSheetSettings(ctx, tx, sheetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSheetSettings", reflect.TypeOf((*MockStorage)(nil).GetSheetSettings), ctx, tx, sheetID)
+}
+
+// MarkDeletedCells mocks base method.
+func (m *MockStorage) MarkDeletedCells(ctx context.Context, tx *sql.Tx, cells []db.CellRef) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDeletedCells", ctx, tx, cells)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDeletedCells indicates an expected call of MarkDeletedCells.
+func (mr *MockStorageMockRecorder) MarkDeletedCells(ctx, tx, cells interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDeletedCells", reflect.TypeOf((*MockStorage)(nil).MarkDeletedCells), ctx, tx, cells)
+}
+
+// SaveCellResult mocks base method.
+func (m *MockStorage) SaveCellResult(ctx context.Context, tx *sql.Tx, data db.Input) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCellResult", ctx, tx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCellResult indicates an expected call of SaveCellResult.
+func (mr *MockStorageMockRecorder) SaveCellResult(ctx, tx, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCellResult", reflect.TypeOf((*MockStorage)(nil).SaveCellResult), ctx, tx, data)
+}
+
+// SaveScenario mocks base method.
+func (m *MockStorage) SaveScenario(ctx context.Context, tx *sql.Tx, scenario db.Scenario) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveScenario", ctx, tx, scenario)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveScenario indicates an expected call of SaveScenario.
+func (mr *MockStorageMockRecorder) SaveScenario(ctx, tx, scenario interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveScenario", reflect.TypeOf((*MockStorage)(nil).SaveScenario), ctx, tx, scenario)
+}
+
+// SaveSheetSettings mocks base method.
+func (m *MockStorage) SaveSheetSettings(ctx context.Context, tx *sql.Tx, settings db.SheetSettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSheetSettings", ctx, tx, settings)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSheetSettings indicates an expected call of SaveSheetSettings.
+func (mr *MockStorageMockRecorder) SaveSheetSettings(ctx, tx, settings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSheetSettings", reflect.TypeOf((*MockStorage)(nil).SaveSheetSettings), ctx, tx, settings)
+}
+
+// SetCellFormat mocks base method.
+func (m *MockStorage) SetCellFormat(ctx context.Context, tx *sql.Tx, sheetID, cellID, format string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCellFormat", ctx, tx, sheetID, cellID, format)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCellFormat indicates an expected call of SetCellFormat.
+func (mr *MockStorageMockRecorder) SetCellFormat(ctx, tx, sheetID, cellID, format interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCellFormat", reflect.TypeOf((*MockStorage)(nil).SetCellFormat), ctx, tx, sheetID, cellID, format)
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// Scenario is a named set of cell overrides of a sheet. Overrides map cells like "growth" or "sheet2!rate"
+// to the values they take in the scenario, Cells are the cells it reports.
+type Scenario struct {
+	SheetID   string
+	Name      string
+	Overrides map[string]string
+	Cells     []string
+}
+
+// GetScenarios returns the scenarios of a sheet sorted by name.
+func (s *storage) GetScenarios(ctx context.Context, sheetID string) ([]Scenario, error) {
+	rows, err := s.ext.QueryContext(ctx, "SELECT name, overrides, cells FROM scenario WHERE sheet_id = $1 ORDER BY name", sheetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Scenario
+	for rows.Next() {
+		scenario, err := scanScenario(sheetID, rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, scenario)
+	}
+	return res, rows.Err()
+}
+
+// GetScenario returns a scenario of a sheet, nil when there is no such scenario.
+func (s *storage) GetScenario(ctx context.Context, sheetID, name string) (*Scenario, error) {
+	row := s.ext.QueryRowContext(ctx, "SELECT name, overrides, cells FROM scenario WHERE sheet_id = $1 AND name = $2", sheetID, name)
+	scenario, err := scanScenario(sheetID, row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &scenario, nil
+}
+
+// SaveScenario stores a scenario, replacing the one with the same name.
+func (s *storage) SaveScenario(ctx context.Context, tx *sql.Tx, scenario Scenario) error {
+	overrides, err := json.Marshal(scenario.Overrides)
+	if err != nil {
+		return err
+	}
+	cells, err := json.Marshal(scenario.Cells)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO scenario(sheet_id, name, overrides, cells) VALUES($1,$2,$3,$4) "+
+		"ON CONFLICT(sheet_id, name) DO UPDATE SET overrides = EXCLUDED.overrides, cells = EXCLUDED.cells",
+		scenario.SheetID, scenario.Name, string(overrides), string(cells))
+	return err
+}
+
+// DeleteScenario removes a scenario, it reports whether there was one.
+func (s *storage) DeleteScenario(ctx context.Context, tx *sql.Tx, sheetID, name string) (bool, error) {
+	res, err := tx.ExecContext(ctx, "DELETE FROM scenario WHERE sheet_id = $1 AND name = $2", sheetID, name)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := res.RowsAffected()
+	return deleted > 0, err
+}
+
+func scanScenario(sheetID string, row interface{ Scan(dest ...any) error }) (Scenario, error) {
+	scenario := Scenario{SheetID: sheetID}
+	var overrides, cells string
+	if err := row.Scan(&scenario.Name, &overrides, &cells); err != nil {
+		return Scenario{}, err
+	}
+	if err := json.Unmarshal([]byte(overrides), &scenario.Overrides); err != nil {
+		return Scenario{}, err
+	}
+	if err := json.Unmarshal([]byte(cells), &scenario.Cells); err != nil {
+		return Scenario{}, err
+	}
+	return scenario, nil
+}
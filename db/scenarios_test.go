@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_Scenarios(t *testing.T) {
+	defer cleanup()
+
+	store := NewStorage(conn)
+	scenario, err := store.GetScenario(context.TODO(), "sheet1", "high")
+	require.NoError(t, err)
+	require.Nil(t, scenario)
+
+	tx, err := store.BeginTransaction(context.TODO())
+	require.NoError(t, err)
+	high := Scenario{SheetID: "sheet1", Name: "high", Overrides: map[string]string{"growth": "0.05", "sheet2!rate": "=2*1"}, Cells: []string{"profit"}}
+	low := Scenario{SheetID: "sheet1", Name: "low", Overrides: map[string]string{"growth": "0.01"}}
+	require.NoError(t, store.SaveScenario(context.TODO(), tx, low))
+	require.NoError(t, store.SaveScenario(context.TODO(), tx, Scenario{SheetID: "sheet1", Name: "high", Overrides: map[string]string{}}))
+	require.NoError(t, store.SaveScenario(context.TODO(), tx, high))
+	require.NoError(t, store.SaveScenario(context.TODO(), tx, Scenario{SheetID: "sheet2", Name: "other", Overrides: map[string]string{}}))
+	require.NoError(t, tx.Commit())
+
+	scenario, err = store.GetScenario(context.TODO(), "sheet1", "high")
+	require.NoError(t, err)
+	require.Equal(t, &high, scenario)
+	scenarios, err := store.GetScenarios(context.TODO(), "sheet1")
+	require.NoError(t, err)
+	require.Equal(t, []Scenario{high, low}, scenarios)
+
+	tx, err = store.BeginTransaction(context.TODO())
+	require.NoError(t, err)
+	deleted, err := store.DeleteScenario(context.TODO(), tx, "sheet1", "high")
+	require.NoError(t, err)
+	require.True(t, deleted)
+	deleted, err = store.DeleteScenario(context.TODO(), tx, "sheet1", "high")
+	require.NoError(t, err)
+	require.False(t, deleted)
+	require.NoError(t, tx.Commit())
+
+	scenarios, err = store.GetScenarios(context.TODO(), "sheet1")
+	require.NoError(t, err)
+	require.Equal(t, []Scenario{low}, scenarios)
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// SheetSettings configures how numbers of a sheet are calculated. NumberMode is "float" or "decimal",
+// Precision and Rounding ("half_up" or "half_even") tell how decimal results are rounded.
+// MissingRefs is "ref" when references to cells that don't exist yet fail with #REF! and "zero" when they count as 0.
+type SheetSettings struct {
+	SheetID     string `db:"sheet_id"`
+	NumberMode  string `db:"number_mode"`
+	Precision   int    `db:"decimal_precision"`
+	Rounding    string `db:"rounding"`
+	MissingRefs string `db:"missing_refs"`
+}
+
+// DefaultSheetSettings are used by sheets without stored settings.
+func DefaultSheetSettings(sheetID string) SheetSettings {
+	return SheetSettings{SheetID: sheetID, NumberMode: "float", Precision: 2, Rounding: "half_up", MissingRefs: "ref"}
+}
+
+// GetSheetSettings returns the settings of a sheet or DefaultSheetSettings, tx may be nil outside of transactions.
+func (s *storage) GetSheetSettings(ctx context.Context, tx *sql.Tx, sheetID string) (SheetSettings, error) {
+	query := "SELECT number_mode, decimal_precision, rounding, missing_refs FROM sheet_settings WHERE sheet_id = $1"
+	var row *sql.Row
+	if tx != nil {
+		row = tx.QueryRowContext(ctx, query, sheetID)
+	} else {
+		row = s.ext.QueryRowContext(ctx, query, sheetID)
+	}
+
+	settings := SheetSettings{SheetID: sheetID}
+	err := row.Scan(&settings.NumberMode, &settings.Precision, &settings.Rounding, &settings.MissingRefs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultSheetSettings(sheetID), nil
+	}
+	if err != nil {
+		return SheetSettings{}, err
+	}
+	return settings, nil
+}
+
+func (s *storage) SaveSheetSettings(ctx context.Context, tx *sql.Tx, settings SheetSettings) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO sheet_settings(sheet_id, number_mode, decimal_precision, rounding, missing_refs) VALUES($1,$2,$3,$4,$5) "+
+		"ON CONFLICT(sheet_id) DO UPDATE SET number_mode = EXCLUDED.number_mode, decimal_precision = EXCLUDED.decimal_precision, "+
+		"rounding = EXCLUDED.rounding, missing_refs = EXCLUDED.missing_refs",
+		settings.SheetID, settings.NumberMode, settings.Precision, settings.Rounding, settings.MissingRefs)
+	return err
+}
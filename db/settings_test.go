@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_SheetSettings(t *testing.T) {
+	defer cleanup()
+
+	store := NewStorage(conn)
+	settings, err := store.GetSheetSettings(context.TODO(), nil, "sheet1")
+	require.NoError(t, err)
+	require.Equal(t, DefaultSheetSettings("sheet1"), settings)
+
+	tx, err := store.BeginTransaction(context.TODO())
+	require.NoError(t, err)
+	want := SheetSettings{SheetID: "sheet1", NumberMode: "decimal", Precision: 4, Rounding: "half_even", MissingRefs: "zero"}
+	require.NoError(t, store.SaveSheetSettings(context.TODO(), tx, want))
+	want.Precision = 6
+	require.NoError(t, store.SaveSheetSettings(context.TODO(), tx, want))
+
+	settings, err = store.GetSheetSettings(context.TODO(), tx, "sheet1")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	require.Equal(t, want, settings)
+
+	settings, err = store.GetSheetSettings(context.TODO(), nil, "sheet2")
+	require.NoError(t, err)
+	require.Equal(t, "float", settings.NumberMode)
+}
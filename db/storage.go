@@ -14,9 +14,23 @@ type Storage interface {
 	GetCellInput(ctx context.Context, sheetID, cellID string) (*models.Data, error)
 	AddCellInput(ctx context.Context, tx *sql.Tx, data Input) (resp *models.Data, wasUpdated bool, err error)
 	GetSheetInput(ctx context.Context, sheetID string) (map[string]models.Data, error)
-	GetCellInputBatch(ctx context.Context, tx *sql.Tx, sheetID string, cells []string) (map[string]string, error)
-	GetIDList(ctx context.Context, tx *sql.Tx, cellID string) ([]int, error)
-	GetInputBatchByIDs(ctx context.Context, tx *sql.Tx, IDs []int) (*[]Input, error)
+	GetCellInputBatch(ctx context.Context, tx *sql.Tx, sheetID string, cells []string) (map[string]Input, error)
+	GetCellInputByPattern(ctx context.Context, tx *sql.Tx, pattern CellPattern) (map[string]Input, error)
+	GetAllInputs(ctx context.Context, tx *sql.Tx) ([]Input, error)
+	SaveCellResult(ctx context.Context, tx *sql.Tx, data Input) error
+	GetInput(ctx context.Context, tx *sql.Tx, sheetID, cellID string) (*Input, error)
+	GetSheetInputs(ctx context.Context, sheetID string) (map[string]Input, error)
+	SetCellFormat(ctx context.Context, tx *sql.Tx, sheetID, cellID, format string) error
+	DeleteCell(ctx context.Context, tx *sql.Tx, sheetID, cellID string) (bool, error)
+	DeleteSheet(ctx context.Context, tx *sql.Tx, sheetID string) (int, error)
+	MarkDeletedCells(ctx context.Context, tx *sql.Tx, cells []CellRef) error
+	GetDeletedCells(ctx context.Context, tx *sql.Tx) ([]CellRef, error)
+	GetSheetSettings(ctx context.Context, tx *sql.Tx, sheetID string) (SheetSettings, error)
+	SaveSheetSettings(ctx context.Context, tx *sql.Tx, settings SheetSettings) error
+	GetScenarios(ctx context.Context, sheetID string) ([]Scenario, error)
+	GetScenario(ctx context.Context, sheetID, name string) (*Scenario, error)
+	SaveScenario(ctx context.Context, tx *sql.Tx, scenario Scenario) error
+	DeleteScenario(ctx context.Context, tx *sql.Tx, sheetID, name string) (bool, error)
 	BeginTransaction(ctx context.Context) (*sql.Tx, error)
 }
 
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"os"
 	"testing"
@@ -20,15 +21,14 @@ func TestMain(m *testing.M) {
 
 func setup() {
 	var err error
-	conn, err = sql.Open("sqlite3", "./test_db.db")
+	conn, err = sql.Open("sqlite3", "./test_db.db?_foreign_keys=on")
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	createTableQuery := Migrations
-	_, err = conn.Exec(createTableQuery)
+	err = Migrate(context.Background(), conn)
 	if err != nil {
-		log.Fatalf("Failed to create table: %v", err)
+		log.Fatalf("Failed to migrate database: %v", err)
 	}
 }
 
@@ -38,10 +38,17 @@ func shutdown() {
 }
 
 func cleanup() {
-	_, _ = conn.Exec("DELETE FROM string_array")
+	_, _ = conn.Exec("DELETE FROM cell_dependency")
+	_, _ = conn.Exec("DELETE FROM range_dependency")
+	_, _ = conn.Exec("DELETE FROM pattern_dependency")
 	_, _ = conn.Exec("DELETE FROM dev_challenge")
+	_, _ = conn.Exec("DELETE FROM sheet_settings")
+	_, _ = conn.Exec("DELETE FROM scenario")
+	_, _ = conn.Exec("DELETE FROM deleted_cell")
 
-	_, _ = conn.Exec("UPDATE sqlite_sequence SET seq = 0 WHERE name = 'string_array'")
+	_, _ = conn.Exec("UPDATE sqlite_sequence SET seq = 0 WHERE name = 'cell_dependency'")
+	_, _ = conn.Exec("UPDATE sqlite_sequence SET seq = 0 WHERE name = 'range_dependency'")
+	_, _ = conn.Exec("UPDATE sqlite_sequence SET seq = 0 WHERE name = 'pattern_dependency'")
 	_, _ = conn.Exec("UPDATE sqlite_sequence SET seq = 0 WHERE name = 'dev_challenge'")
 
 	_, _ = conn.Exec("INSERT INTO dev_challenge (sheet_id, cell_id, cell_value, cell_result) VALUES ('sheet0','cell0','0',0) ON CONFLICT DO NOTHING")
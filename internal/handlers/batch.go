@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// addValues writes a map of cell IDs to values in one transaction, all of them or none.
+func (h *ExcelLikeHandler) addValues(w http.ResponseWriter, r *http.Request) {
+	sheetID, ok := h.batchSheetID(w, r)
+	if !ok {
+		return
+	}
+	var requestBody map[string]models.Data
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.Log.WithError(err).Error("can't read request body")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't read request body", http.StatusUnprocessableEntity))
+		return
+	}
+	defer r.Body.Close()
+
+	if err = json.Unmarshal(body, &requestBody); err != nil || requestBody == nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't unmarshal request body", http.StatusUnprocessableEntity))
+		return
+	}
+	cellIDs := make([]string, 0, len(requestBody))
+	for cellID := range requestBody {
+		cellIDs = append(cellIDs, cellID)
+	}
+	if !h.validCellIDs(w, r, cellIDs) {
+		return
+	}
+
+	resp, err := h.ELS.AddCellInputsTX(r.Context(), sheetID, requestBody)
+	if err != nil {
+		h.batchError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+// addCells is addValues of the v2 API, cells may change their format too.
+func (h *ExcelLikeHandler) addCells(w http.ResponseWriter, r *http.Request) {
+	sheetID, ok := h.batchSheetID(w, r)
+	if !ok {
+		return
+	}
+	var requestBody map[string]models.CellInput
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.Log.WithError(err).Error("can't read request body")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't read request body", http.StatusUnprocessableEntity))
+		return
+	}
+	defer r.Body.Close()
+
+	if err = json.Unmarshal(body, &requestBody); err != nil || requestBody == nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't unmarshal request body", http.StatusUnprocessableEntity))
+		return
+	}
+	cellIDs := make([]string, 0, len(requestBody))
+	for cellID := range requestBody {
+		cellIDs = append(cellIDs, cellID)
+	}
+	if !h.validCellIDs(w, r, cellIDs) {
+		return
+	}
+
+	cells, err := h.ELS.AddCellsTX(r.Context(), sheetID, requestBody)
+	if err != nil {
+		h.batchError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	render.JSON(w, r, cells)
+}
+
+func (h *ExcelLikeHandler) batchSheetID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	sheetID := strings.ToLower(chi.URLParam(r, "sheet_id"))
+	if !writableSheetID(sheetID) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("not correct params", http.StatusUnprocessableEntity))
+		return "", false
+	}
+	return sheetID, true
+}
+
+// validCellIDs checks the cell IDs of a batch like the IDs of written cells.
+func (h *ExcelLikeHandler) validCellIDs(w http.ResponseWriter, r *http.Request, cellIDs []string) bool {
+	for _, cellID := range cellIDs {
+		if !writableCellID(strings.ToLower(cellID)) {
+			h.Log.Error("not correct data in params")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			render.JSON(w, r, models.Error("not correct cell id "+cellID, http.StatusUnprocessableEntity))
+			return false
+		}
+	}
+	return true
+}
+
+func (h *ExcelLikeHandler) batchError(w http.ResponseWriter, r *http.Request, err error) {
+	h.Log.WithError(err).Error("failed to add values")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	var batchErr *services.BatchError
+	if errors.As(err, &batchErr) {
+		render.JSON(w, r, models.RejectedBatch{Code: "422", Message: batchErr.Error(), Errors: batchErr.Errors})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidBatch) {
+		render.JSON(w, r, models.Error(err.Error(), http.StatusUnprocessableEntity))
+		return
+	}
+	render.JSON(w, r, models.Error("can't add values", http.StatusUnprocessableEntity))
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+	mock_services "dev-challenge/internal/services/mock"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_addValues(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+	format := "0.00"
+
+	tests := []struct {
+		Name                 string
+		url                  string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name:      "Written",
+			url:       "/api/v1/Sheet1",
+			inputBody: `{"a1":{"value":"1"},"b1":{"value":"=a1+1"}}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellInputsTX(gomock.Any(), "sheet1", map[string]models.Data{"a1": {Value: "1"}, "b1": {Value: "=a1+1"}}).
+					Return(map[string]models.Data{
+						"a1": {Value: "1", Result: "1.000000", Type: "number"},
+						"b1": {Value: "=a1+1", Result: "2.000000", Type: "number"},
+					}, nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedResponseBody: "{\"a1\":{\"value\":\"1\",\"result\":\"1.000000\",\"type\":\"number\"}," +
+				"\"b1\":{\"value\":\"=a1+1\",\"result\":\"2.000000\",\"type\":\"number\"}}\n",
+		},
+		{
+			Name:      "Written with formats",
+			url:       "/api/v2/sheet1",
+			inputBody: `{"a1":{"value":"1","format":"0.00"}}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellsTX(gomock.Any(), "sheet1", map[string]models.CellInput{"a1": {Value: "1", Format: &format}}).
+					Return(map[string]models.Cell{"a1": {Value: "1", Result: float64(1), Type: "number", Format: "0.00", Formatted: "1.00"}}, nil)
+			},
+			expectedStatusCode:   http.StatusCreated,
+			expectedResponseBody: "{\"a1\":{\"value\":\"1\",\"result\":1,\"type\":\"number\",\"format\":\"0.00\",\"formatted\":\"1.00\"}}\n",
+		},
+		{
+			Name:      "Rejected",
+			url:       "/api/v1/sheet1",
+			inputBody: `{"a1":{"value":"=1/0"}}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellInputsTX(gomock.Any(), "sheet1", gomock.Any()).Return(nil, &services.BatchError{Errors: map[string]models.ValidationError{
+					"a1": {Code: "#DIV/0!", Message: "division by zero", Position: 2},
+				}})
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"batch rejected: 1 of the cells can't be written\"," +
+				"\"errors\":{\"a1\":{\"code\":\"#DIV/0!\",\"message\":\"division by zero\",\"position\":2}}}\n",
+		},
+		{
+			Name:      "Invalid",
+			url:       "/api/v2/sheet1",
+			inputBody: `{}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellsTX(gomock.Any(), "sheet1", gomock.Any()).Return(nil, fmt.Errorf("%w: cells are required", services.ErrInvalidBatch))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"invalid batch: cells are required\"}\n",
+		},
+		{
+			Name:                 "Wrong cell id",
+			url:                  "/api/v1/sheet1",
+			inputBody:            `{"a 1":{"value":"1"}}`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"not correct cell id a 1\"}\n",
+		},
+		{
+			Name:                 "Reserved cell id",
+			url:                  "/api/v2/sheet1",
+			inputBody:            `{"_settings":{"value":"1"}}`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"not correct cell id _settings\"}\n",
+		},
+		{
+			Name:                 "Reserved sheet id",
+			url:                  "/api/v1/_functions",
+			inputBody:            `{"a1":{"value":"1"}}`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"not correct params\"}\n",
+		},
+		{
+			Name:                 "Wrong body",
+			url:                  "/api/v1/sheet1",
+			inputBody:            `["a1"]`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't unmarshal request body\"}\n",
+		},
+		{
+			Name:      "Store error",
+			url:       "/api/v1/sheet1",
+			inputBody: `{"a1":{"value":"1"}}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellInputsTX(gomock.Any(), "sheet1", gomock.Any()).Return(nil, errors.New("error"))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't add values\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v1", h.RegisterRoutes)
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.url, bytes.NewBufferString(test.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
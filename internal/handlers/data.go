@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,9 +23,29 @@ type ExcelLikeHandler struct {
 }
 
 func (h *ExcelLikeHandler) RegisterRoutes(router chi.Router) {
+	router.Get("/_functions", h.getFunctions)
+	router.Get("/{sheet_id}/_settings", h.getSettings)
+	router.Put("/{sheet_id}/_settings", h.updateSettings)
+	router.Get("/{sheet_id}/_graph", h.getSheetGraph)
+	router.Get("/{sheet_id}/{cell_id}/precedents", h.getPrecedents)
+	router.Get("/{sheet_id}/{cell_id}/dependents", h.getDependents)
+	router.Get("/{sheet_id}/{cell_id}/explain", h.explainCell)
+	router.Post("/{sheet_id}/{cell_id}/_validate", h.validateCell)
+	router.Post("/{sheet_id}/_whatif", h.evaluateScenario)
+	router.Post("/{sheet_id}/_goalseek", h.goalSeek)
+	router.Post("/{sheet_id}/_solve", h.solve)
+	router.Post("/{sheet_id}/_simulate", h.simulate)
+	router.Get("/{sheet_id}/_scenarios", h.getScenarios)
+	router.Get("/{sheet_id}/_scenarios/_compare", h.compareScenarios)
+	router.Put("/{sheet_id}/_scenarios/{name}", h.saveScenario)
+	router.Get("/{sheet_id}/_scenarios/{name}", h.getScenario)
+	router.Delete("/{sheet_id}/_scenarios/{name}", h.deleteScenario)
 	router.Post("/{sheet_id}/{cell_id}", h.addValue)
+	router.Post("/{sheet_id}", h.addValues)
 	router.Get("/{sheet_id}/{cell_id}", h.getValue)
 	router.Get("/{sheet_id}", h.getAllValues)
+	router.Delete("/{sheet_id}/{cell_id}", h.deleteCell)
+	router.Delete("/{sheet_id}", h.deleteSheet)
 }
 
 func (h *ExcelLikeHandler) getValue(w http.ResponseWriter, r *http.Request) {
@@ -55,7 +76,7 @@ func (h *ExcelLikeHandler) getValue(w http.ResponseWriter, r *http.Request) {
 func (h *ExcelLikeHandler) addValue(w http.ResponseWriter, r *http.Request) {
 	sheetID := chi.URLParam(r, "sheet_id")
 	cellID := chi.URLParam(r, "cell_id")
-	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) || !containsOnlyURLAllowedChars(strings.ToLower(cellID)) {
+	if !writableSheetID(strings.ToLower(sheetID)) || !writableCellID(strings.ToLower(cellID)) {
 		h.Log.Error("not correct data in params")
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		render.JSON(w, r, models.Error("not correct params", http.StatusUnprocessableEntity))
@@ -82,6 +103,16 @@ func (h *ExcelLikeHandler) addValue(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		h.Log.WithError(err).Error("failed to add value")
 		w.WriteHeader(http.StatusUnprocessableEntity)
+		var cycleErr *services.CircularReferenceError
+		if errors.As(err, &cycleErr) {
+			render.JSON(w, r, models.CircularReferencePOSTResponse(requestBody.Value, cycleErr.Path))
+			return
+		}
+		var formulaErr *services.FormulaError
+		if errors.As(err, &formulaErr) {
+			render.JSON(w, r, models.FormulaErrorPOSTResponse(requestBody.Value, string(formulaErr.Code), formulaErr.Error()))
+			return
+		}
 		render.JSON(w, r, models.ErrorPOSTResponse(requestBody.Value))
 		return
 	}
@@ -113,6 +144,78 @@ func (h *ExcelLikeHandler) getAllValues(w http.ResponseWriter, r *http.Request)
 	render.JSON(w, r, cellInput)
 }
 
+func (h *ExcelLikeHandler) getFunctions(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, h.ELS.GetFunctions(r.Context()))
+}
+
+func (h *ExcelLikeHandler) getSettings(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	settings, err := h.ELS.GetSheetSettings(r.Context(), strings.ToLower(sheetID))
+	if err != nil {
+		h.Log.WithError(err).Error("failed to get settings")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("store not responded", http.StatusNotFound))
+		return
+	}
+	render.JSON(w, r, settings)
+}
+
+func (h *ExcelLikeHandler) updateSettings(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	if !writableSheetID(strings.ToLower(sheetID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("not correct params", http.StatusUnprocessableEntity))
+		return
+	}
+	var requestBody *models.SheetSettings
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.Log.WithError(err).Error("can't read request body")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't read request body", http.StatusUnprocessableEntity))
+		return
+	}
+	defer r.Body.Close()
+
+	if err = json.Unmarshal(body, &requestBody); err != nil || requestBody == nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't unmarshal request body", http.StatusUnprocessableEntity))
+		return
+	}
+
+	settings, err := h.ELS.UpdateSheetSettingsTX(r.Context(), strings.ToLower(sheetID), requestBody)
+	if err != nil {
+		h.Log.WithError(err).Error("failed to update settings")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if errors.Is(err, services.ErrInvalidSettings) {
+			render.JSON(w, r, models.Error(err.Error(), http.StatusUnprocessableEntity))
+			return
+		}
+		render.JSON(w, r, models.Error("can't update settings", http.StatusUnprocessableEntity))
+		return
+	}
+	render.JSON(w, r, settings)
+}
+
+// writableCellID reports whether a cell can be written under cellID. IDs starting with "_" are kept
+// for the sheet endpoints like _graph and _settings, a cell with such an ID couldn't be read back.
+func writableCellID(cellID string) bool {
+	return containsOnlyURLAllowedChars(cellID) && !strings.HasPrefix(cellID, "_")
+}
+
+// writableSheetID reports whether cells or settings can be written under sheetID. IDs starting with "_" are
+// kept for endpoints like _functions, a sheet with such an ID couldn't be read back.
+func writableSheetID(sheetID string) bool {
+	return containsOnlyURLAllowedChars(sheetID) && !strings.HasPrefix(sheetID, "_")
+}
+
 func containsOnlyURLAllowedChars(s string) bool {
 	pattern := "^[a-z0-9-_.~%!$&'()*+,;=:@/\\[\\]?#]+$"
 	matched, err := regexp.MatchString(pattern, s)
@@ -3,12 +3,14 @@ package handlers
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
 	mock_services "dev-challenge/internal/services/mock"
 
 	"github.com/go-chi/chi/v5"
@@ -153,6 +155,22 @@ func TestHandler_addValue(t *testing.T) {
 			expectedStatusCode:   http.StatusUnprocessableEntity,
 			expectedResponseBody: "{\"code\":\"422\",\"message\":\"not correct params\"}\n",
 		},
+		{
+			Name:                 "Reserved cell ID",
+			url:                  "/api/v1/sheetID1/_cell1",
+			inputBody:            `{"value": "1"}`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"not correct params\"}\n",
+		},
+		{
+			Name:                 "Reserved sheet ID",
+			url:                  "/api/v1/_functions/cell1",
+			inputBody:            `{"value": "1"}`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"not correct params\"}\n",
+		},
 		{
 			Name:      "Mistake in adding",
 			url:       "/api/v1/sheetID1/cellID1",
@@ -163,6 +181,28 @@ func TestHandler_addValue(t *testing.T) {
 			expectedStatusCode:   http.StatusUnprocessableEntity,
 			expectedResponseBody: "{\"value\":\"2\",\"result\":\"ERROR\"}\n",
 		},
+		{
+			Name:      "Circular reference",
+			url:       "/api/v1/sheetID1/a",
+			inputBody: `{"value": "=b+1"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellInputTX(gomock.Any(), strings.ToLower("sheetID1"), "a", &models.Data{Value: "=b+1"}).Return(nil,
+					fmt.Errorf("wrapped: %w", &services.CircularReferenceError{Path: []string{"a", "b", "a"}}))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"value\":\"=b+1\",\"result\":\"#CIRCULAR!\",\"type\":\"error\",\"error\":\"circular reference\",\"cycle\":[\"a\",\"b\",\"a\"]}\n",
+		},
+		{
+			Name:      "Formula error",
+			url:       "/api/v1/sheetID1/c",
+			inputBody: `{"value": "=a/b"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellInputTX(gomock.Any(), strings.ToLower("sheetID1"), "c", &models.Data{Value: "=a/b"}).Return(nil,
+					&services.FormulaError{Code: services.ErrDivZero, Message: "division by zero", Cell: "sheetid1!b"})
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"value\":\"=a/b\",\"result\":\"#DIV/0!\",\"type\":\"error\",\"error\":\"sheetid1!b: division by zero\"}\n",
+		},
 		{
 			Name:                 "Not correct input",
 			url:                  "/api/v1/sheetID1/cellID1",
@@ -269,3 +309,118 @@ func TestHandler_getAllValues(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_getFunctions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mock_services.NewMockExcelLikeService(ctrl)
+	m.EXPECT().GetFunctions(gomock.Any()).Return([]models.Function{
+		{
+			Name:        "ABS",
+			Signature:   "ABS(number)",
+			Description: "Returns the absolute value of a number.",
+			Args:        []models.FunctionArg{{Name: "number", Type: "number"}},
+			MinArgs:     1,
+			MaxArgs:     1,
+		},
+	})
+
+	r := chi.NewRouter()
+	h := &ExcelLikeHandler{
+		ELS: m,
+		Log: mockLogger,
+	}
+	r.Route("/api/v1", h.RegisterRoutes)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/_functions", bytes.NewBuffer(nil))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[{\"name\":\"ABS\",\"signature\":\"ABS(number)\",\"description\":\"Returns the absolute value of a number.\","+
+		"\"args\":[{\"name\":\"number\",\"type\":\"number\"}],\"min_args\":1,\"max_args\":1}]\n", w.Body.String())
+}
+
+func TestHandler_updateSettings(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+
+	precision := 4
+	tests := []struct {
+		Name                 string
+		sheetID              string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name:      "Decimal mode",
+			inputBody: `{"mode":"decimal","precision":4}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().UpdateSheetSettingsTX(gomock.Any(), "sheet1", &models.SheetSettings{Mode: "decimal", Precision: &precision}).
+					Return(&models.SheetSettings{Mode: "decimal", Precision: &precision, Rounding: "half_up"}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"mode\":\"decimal\",\"precision\":4,\"rounding\":\"half_up\"}\n",
+		},
+		{
+			Name:      "Invalid settings",
+			inputBody: `{"rounding":"down"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().UpdateSheetSettingsTX(gomock.Any(), "sheet1", &models.SheetSettings{Rounding: "down"}).
+					Return(nil, fmt.Errorf("%w: unknown rounding", services.ErrInvalidSettings))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"invalid sheet settings: unknown rounding\"}\n",
+		},
+		{
+			Name:      "Store not responded",
+			inputBody: `{"mode":"float"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().UpdateSheetSettingsTX(gomock.Any(), "sheet1", &models.SheetSettings{Mode: "float"}).
+					Return(nil, errors.New("database is locked"))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't update settings\"}\n",
+		},
+		{
+			Name:                 "Invalid body",
+			inputBody:            `{"mode":`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't unmarshal request body\"}\n",
+		},
+		{
+			Name:                 "Reserved sheet ID",
+			sheetID:              "_functions",
+			inputBody:            `{"mode":"float"}`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"not correct params\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v1", h.RegisterRoutes)
+			w := httptest.NewRecorder()
+			sheetID := test.sheetID
+			if sheetID == "" {
+				sheetID = "Sheet1"
+			}
+			req, _ := http.NewRequest("PUT", "/api/v1/"+sheetID+"/_settings", strings.NewReader(test.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
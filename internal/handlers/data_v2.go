@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// RegisterRoutesV2 registers the v2 API, which returns typed results and formats them by the mask of each cell.
+func (h *ExcelLikeHandler) RegisterRoutesV2(router chi.Router) {
+	router.Get("/_functions", h.getFunctions)
+	router.Get("/{sheet_id}/_settings", h.getSettings)
+	router.Put("/{sheet_id}/_settings", h.updateSettings)
+	router.Get("/{sheet_id}/_graph", h.getSheetGraph)
+	router.Get("/{sheet_id}/{cell_id}/precedents", h.getPrecedents)
+	router.Get("/{sheet_id}/{cell_id}/dependents", h.getDependents)
+	router.Get("/{sheet_id}/{cell_id}/explain", h.explainCell)
+	router.Post("/{sheet_id}/{cell_id}/_validate", h.validateCell)
+	router.Post("/{sheet_id}/_whatif", h.evaluateScenario)
+	router.Post("/{sheet_id}/_goalseek", h.goalSeek)
+	router.Post("/{sheet_id}/_solve", h.solve)
+	router.Post("/{sheet_id}/_simulate", h.simulate)
+	router.Get("/{sheet_id}/_scenarios", h.getScenarios)
+	router.Get("/{sheet_id}/_scenarios/_compare", h.compareScenarios)
+	router.Put("/{sheet_id}/_scenarios/{name}", h.saveScenario)
+	router.Get("/{sheet_id}/_scenarios/{name}", h.getScenario)
+	router.Delete("/{sheet_id}/_scenarios/{name}", h.deleteScenario)
+	router.Post("/{sheet_id}/{cell_id}", h.addCell)
+	router.Post("/{sheet_id}", h.addCells)
+	router.Get("/{sheet_id}/{cell_id}", h.getCell)
+	router.Get("/{sheet_id}", h.getSheetCells)
+	router.Delete("/{sheet_id}/{cell_id}", h.deleteCell)
+	router.Delete("/{sheet_id}", h.deleteSheet)
+}
+
+func (h *ExcelLikeHandler) getCell(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	cellID := chi.URLParam(r, "cell_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) || !containsOnlyURLAllowedChars(strings.ToLower(cellID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	cell, err := h.ELS.GetCell(r.Context(), strings.ToLower(sheetID), strings.ToLower(cellID))
+	if err != nil {
+		h.Log.WithError(err).Error("failed to get cell")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("store not responded", http.StatusNotFound))
+		return
+	}
+	if cell == nil {
+		h.Log.Error(fmt.Sprintf("value on sheetID=%s and cellID=%s not found", sheetID, cellID))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("value not found", http.StatusNotFound))
+		return
+	}
+	render.JSON(w, r, cell)
+}
+
+func (h *ExcelLikeHandler) addCell(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	cellID := chi.URLParam(r, "cell_id")
+	if !writableSheetID(strings.ToLower(sheetID)) || !writableCellID(strings.ToLower(cellID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("not correct params", http.StatusUnprocessableEntity))
+		return
+	}
+	var requestBody *models.CellInput
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.Log.WithError(err).Error("can't read request body")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't read request body", http.StatusUnprocessableEntity))
+		return
+	}
+	defer r.Body.Close()
+
+	if err = json.Unmarshal(body, &requestBody); err != nil || requestBody == nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't unmarshal request body", http.StatusUnprocessableEntity))
+		return
+	}
+
+	// ?dependents=true adds the cells recalculated by the write to the response
+	withDependents, _ := strconv.ParseBool(r.URL.Query().Get("dependents"))
+	cell, err := h.ELS.AddCellTX(r.Context(), strings.ToLower(sheetID), strings.ToLower(cellID), requestBody, withDependents)
+	if err != nil {
+		h.Log.WithError(err).Error("failed to add value")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		var cycleErr *services.CircularReferenceError
+		if errors.As(err, &cycleErr) {
+			render.JSON(w, r, models.FormulaErrorCell(requestBody.Value, "#CIRCULAR!", "circular reference", cycleErr.Path))
+			return
+		}
+		var formulaErr *services.FormulaError
+		if errors.As(err, &formulaErr) {
+			render.JSON(w, r, models.FormulaErrorCell(requestBody.Value, string(formulaErr.Code), formulaErr.Error(), nil))
+			return
+		}
+		if errors.Is(err, services.ErrInvalidFormat) {
+			render.JSON(w, r, models.Error(err.Error(), http.StatusUnprocessableEntity))
+			return
+		}
+		render.JSON(w, r, models.Error("can't add value", http.StatusUnprocessableEntity))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	render.JSON(w, r, cell)
+}
+
+func (h *ExcelLikeHandler) getSheetCells(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	cells, err := h.ELS.GetSheetCells(r.Context(), strings.ToLower(sheetID))
+	if err != nil {
+		h.Log.WithError(err).Error("failed to get sheet")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("store not responded", http.StatusNotFound))
+		return
+	}
+	if cells == nil {
+		h.Log.Error(fmt.Sprintf("sheetID=%s not found", sheetID))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("value not found", http.StatusNotFound))
+		return
+	}
+	render.JSON(w, r, cells)
+}
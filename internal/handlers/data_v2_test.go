@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+	mock_services "dev-challenge/internal/services/mock"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_getCell(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+
+	tests := []struct {
+		Name                 string
+		url                  string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name: "Typed number",
+			url:  "/api/v2/sheet1/price",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetCell(gomock.Any(), "sheet1", "price").Return(&models.Cell{
+					Value: "1234.5", Result: 1234.5, Type: "number", Format: "#,##0.00", Formatted: "1,234.50",
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"value\":\"1234.5\",\"result\":1234.5,\"type\":\"number\",\"format\":\"#,##0.00\",\"formatted\":\"1,234.50\"}\n",
+		},
+		{
+			Name: "Error result",
+			url:  "/api/v2/sheet1/c",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetCell(gomock.Any(), "sheet1", "c").Return(&models.Cell{
+					Value: "=a/b", Result: models.CellError{Code: "#DIV/0!", Message: "division by zero"}, Type: "error", Formatted: "#DIV/0!",
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"value\":\"=a/b\",\"result\":{\"code\":\"#DIV/0!\",\"message\":\"division by zero\"},\"type\":\"error\",\"formatted\":\"#DIV/0!\"}\n",
+		},
+		{
+			Name: "Value not found",
+			url:  "/api/v2/sheet1/missing",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetCell(gomock.Any(), "sheet1", "missing").Return(nil, nil)
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: "{\"code\":\"404\",\"message\":\"value not found\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", test.url, bytes.NewBuffer(nil))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_addCell(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+
+	format := "0.0%"
+	tests := []struct {
+		Name                 string
+		query                string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name:      "Value with format",
+			inputBody: `{"value":"0.256","format":"0.0%"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellTX(gomock.Any(), "sheet1", "rate", &models.CellInput{Value: "0.256", Format: &format}, false).Return(&models.Cell{
+					Value: "0.256", Result: 0.256, Type: "number", Format: "0.0%", Formatted: "25.6%",
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusCreated,
+			expectedResponseBody: "{\"value\":\"0.256\",\"result\":0.256,\"type\":\"number\",\"format\":\"0.0%\",\"formatted\":\"25.6%\"}\n",
+		},
+		{
+			Name:      "Recalculated dependents",
+			query:     "?dependents=true",
+			inputBody: `{"value":"2"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellTX(gomock.Any(), "sheet1", "rate", &models.CellInput{Value: "2"}, true).Return(&models.Cell{
+					Value: "2", Result: 2.0, Type: "number", Formatted: "2",
+					Dependents: []models.RecalculatedCell{
+						{Sheet: "sheet1", ID: "tax", Cell: models.Cell{Value: "=rate*10", Result: 20.0, Type: "number", Formatted: "20"}},
+						{Sheet: "report", ID: "total", Cell: models.Cell{Value: "=sheet1!tax+1", Result: 21.0, Type: "number", Format: "0.0", Formatted: "21.0"}},
+					},
+				}, nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedResponseBody: "{\"value\":\"2\",\"result\":2,\"type\":\"number\",\"formatted\":\"2\",\"dependents\":[" +
+				"{\"sheet\":\"sheet1\",\"id\":\"tax\",\"value\":\"=rate*10\",\"result\":20,\"type\":\"number\",\"formatted\":\"20\"}," +
+				"{\"sheet\":\"report\",\"id\":\"total\",\"value\":\"=sheet1!tax+1\",\"result\":21,\"type\":\"number\",\"format\":\"0.0\",\"formatted\":\"21.0\"}]}\n",
+		},
+		{
+			Name:      "Boolean result",
+			inputBody: `{"value":"=1<2"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellTX(gomock.Any(), "sheet1", "rate", &models.CellInput{Value: "=1<2"}, false).Return(&models.Cell{
+					Value: "=1<2", Result: true, Type: "boolean", Formatted: "TRUE",
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusCreated,
+			expectedResponseBody: "{\"value\":\"=1\\u003c2\",\"result\":true,\"type\":\"boolean\",\"formatted\":\"TRUE\"}\n",
+		},
+		{
+			Name:      "Formula error",
+			inputBody: `{"value":"=1/0"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellTX(gomock.Any(), "sheet1", "rate", &models.CellInput{Value: "=1/0"}, false).
+					Return(nil, &services.FormulaError{Code: services.ErrDivZero, Message: "division by zero"})
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"value\":\"=1/0\",\"result\":{\"code\":\"#DIV/0!\",\"message\":\"division by zero\"},\"type\":\"error\",\"formatted\":\"#DIV/0!\"}\n",
+		},
+		{
+			Name:      "Circular reference",
+			inputBody: `{"value":"=rate"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellTX(gomock.Any(), "sheet1", "rate", &models.CellInput{Value: "=rate"}, false).
+					Return(nil, &services.CircularReferenceError{Path: []string{"rate", "rate"}})
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"value\":\"=rate\",\"result\":{\"code\":\"#CIRCULAR!\",\"message\":\"circular reference\"},\"type\":\"error\"," +
+				"\"formatted\":\"#CIRCULAR!\",\"cycle\":[\"rate\",\"rate\"]}\n",
+		},
+		{
+			Name:      "Invalid format",
+			inputBody: `{"value":"1","format":"0.0%"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().AddCellTX(gomock.Any(), "sheet1", "rate", &models.CellInput{Value: "1", Format: &format}, false).
+					Return(nil, fmt.Errorf("%w %q", services.ErrInvalidFormat, "x"))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"invalid format \\\"x\\\"\"}\n",
+		},
+		{
+			Name:                 "Invalid body",
+			inputBody:            `{"value":`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't unmarshal request body\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/v2/Sheet1/Rate"+test.query, strings.NewReader(test.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// deleteCell deletes a cell. ?policy=reject, the default, refuses when other cells use it,
+// ?policy=ref deletes it anyway and recalculates them.
+func (h *ExcelLikeHandler) deleteCell(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	cellID := chi.URLParam(r, "cell_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) || !containsOnlyURLAllowedChars(strings.ToLower(cellID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	deletion, err := h.ELS.DeleteCellTX(r.Context(), strings.ToLower(sheetID), strings.ToLower(cellID), deletePolicy(r))
+	h.renderDeletion(w, r, deletion, err)
+}
+
+// deleteSheet deletes every cell of a sheet with its settings and scenarios, ?policy works like for deleteCell.
+func (h *ExcelLikeHandler) deleteSheet(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	deletion, err := h.ELS.DeleteSheetTX(r.Context(), strings.ToLower(sheetID), deletePolicy(r))
+	h.renderDeletion(w, r, deletion, err)
+}
+
+func deletePolicy(r *http.Request) services.DeletePolicy {
+	return services.DeletePolicy(strings.ToLower(r.URL.Query().Get("policy")))
+}
+
+func (h *ExcelLikeHandler) renderDeletion(w http.ResponseWriter, r *http.Request, deletion *models.Deletion, err error) {
+	if err != nil {
+		h.Log.WithError(err).Error("failed to delete")
+		var dependentsErr *services.DependentsError
+		switch {
+		case errors.As(err, &dependentsErr):
+			w.WriteHeader(http.StatusConflict)
+			render.JSON(w, r, models.DeleteConflict{Code: "409", Message: "other cells depend on the deleted cells", Dependents: dependentsErr.Cells})
+		case errors.Is(err, services.ErrInvalidDeletePolicy):
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			render.JSON(w, r, models.Error(err.Error(), http.StatusUnprocessableEntity))
+		default:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			render.JSON(w, r, models.Error("can't delete", http.StatusUnprocessableEntity))
+		}
+		return
+	}
+	if deletion == nil {
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("value not found", http.StatusNotFound))
+		return
+	}
+	render.JSON(w, r, deletion)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+	mock_services "dev-challenge/internal/services/mock"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_delete(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+
+	tests := []struct {
+		Name                 string
+		url                  string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name: "Cell deleted",
+			url:  "/api/v1/Sheet1/A1?policy=REF",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().DeleteCellTX(gomock.Any(), "sheet1", "a1", services.DeleteRef).Return(&models.Deletion{Deleted: 1, Dependents: []models.RecalculatedCell{
+					{Sheet: "sheet1", ID: "b1", Cell: models.Cell{Value: "=a1+1", Result: models.CellError{Code: "#REF!"}, Type: "error", Formatted: "#REF!"}},
+				}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: "{\"deleted\":1,\"dependents\":[{\"sheet\":\"sheet1\",\"id\":\"b1\",\"value\":\"=a1+1\"," +
+				"\"result\":{\"code\":\"#REF!\"},\"type\":\"error\",\"formatted\":\"#REF!\"}]}\n",
+		},
+		{
+			Name: "Sheet deleted",
+			url:  "/api/v2/sheet1",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().DeleteSheetTX(gomock.Any(), "sheet1", services.DeletePolicy("")).Return(&models.Deletion{Deleted: 3, Dependents: []models.RecalculatedCell{}}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"deleted\":3,\"dependents\":[]}\n",
+		},
+		{
+			Name: "Dependents",
+			url:  "/api/v1/sheet1/a1",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().DeleteCellTX(gomock.Any(), "sheet1", "a1", services.DeletePolicy("")).Return(nil, &services.DependentsError{Cells: []string{"b1", "sheet2!c"}})
+			},
+			expectedStatusCode:   http.StatusConflict,
+			expectedResponseBody: "{\"code\":\"409\",\"message\":\"other cells depend on the deleted cells\",\"dependents\":[\"b1\",\"sheet2!c\"]}\n",
+		},
+		{
+			Name: "Wrong policy",
+			url:  "/api/v1/sheet1?policy=cascade",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().DeleteSheetTX(gomock.Any(), "sheet1", services.DeletePolicy("cascade")).
+					Return(nil, fmt.Errorf("%w: \"cascade\" is neither \"reject\" nor \"ref\"", services.ErrInvalidDeletePolicy))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"invalid delete policy: \\\"cascade\\\" is neither \\\"reject\\\" nor \\\"ref\\\"\"}\n",
+		},
+		{
+			Name: "Not found",
+			url:  "/api/v1/sheet1/zz",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().DeleteCellTX(gomock.Any(), "sheet1", "zz", services.DeletePolicy("")).Return(nil, nil)
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: "{\"code\":\"404\",\"message\":\"value not found\"}\n",
+		},
+		{
+			Name: "Store error",
+			url:  "/api/v1/sheet1/a1",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().DeleteCellTX(gomock.Any(), "sheet1", "a1", services.DeletePolicy("")).Return(nil, errors.New("error"))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't delete\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v1", h.RegisterRoutes)
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", test.url, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"dev-challenge/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// explainCell traces the calculation of a cell: its formula tree with intermediate values,
+// the values of the referenced cells and the origin of an error.
+func (h *ExcelLikeHandler) explainCell(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	cellID := chi.URLParam(r, "cell_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) || !containsOnlyURLAllowedChars(strings.ToLower(cellID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	explanation, err := h.ELS.ExplainCell(r.Context(), strings.ToLower(sheetID), strings.ToLower(cellID))
+	if err != nil {
+		h.Log.WithError(err).Error("failed to explain cell")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("store not responded", http.StatusNotFound))
+		return
+	}
+	if explanation == nil {
+		h.Log.Error(fmt.Sprintf("value on sheetID=%s and cellID=%s not found", sheetID, cellID))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("value not found", http.StatusNotFound))
+		return
+	}
+	render.JSON(w, r, explanation)
+}
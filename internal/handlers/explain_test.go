@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev-challenge/internal/models"
+	mock_services "dev-challenge/internal/services/mock"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_explainCell(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+
+	tests := []struct {
+		Name                 string
+		url                  string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name: "Explanation",
+			url:  "/api/v1/Sheet1/B/explain",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().ExplainCell(gomock.Any(), "sheet1", "b").Return(&models.Explanation{
+					Sheet: "sheet1", ID: "b", Value: "=a*2", Result: float64(4), Type: "number",
+					Formula: models.ExplainNode{Kind: "operator", Expression: "a*2", Position: 2, Evaluated: true, Result: float64(4), Type: "number",
+						Children: []models.ExplainNode{
+							{Kind: "reference", Expression: "a", Position: 1, Evaluated: true, Result: float64(2), Type: "number"},
+							{Kind: "number", Expression: "2", Position: 3, Evaluated: true, Result: float64(2), Type: "number"},
+						}},
+					References: []models.ExplainedCell{{Sheet: "sheet1", ID: "a", Result: float64(2), Type: "number"}},
+				}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: "{\"sheet\":\"sheet1\",\"id\":\"b\",\"value\":\"=a*2\",\"result\":4,\"type\":\"number\"," +
+				"\"formula\":{\"kind\":\"operator\",\"expression\":\"a*2\",\"position\":2,\"evaluated\":true,\"result\":4,\"type\":\"number\",\"children\":[" +
+				"{\"kind\":\"reference\",\"expression\":\"a\",\"position\":1,\"evaluated\":true,\"result\":2,\"type\":\"number\"}," +
+				"{\"kind\":\"number\",\"expression\":\"2\",\"position\":3,\"evaluated\":true,\"result\":2,\"type\":\"number\"}]}," +
+				"\"references\":[{\"sheet\":\"sheet1\",\"id\":\"a\",\"result\":2,\"type\":\"number\"}]}\n",
+		},
+		{
+			Name: "Cell not found",
+			url:  "/api/v2/sheet1/missing/explain",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().ExplainCell(gomock.Any(), "sheet1", "missing").Return(nil, nil)
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: "{\"code\":\"404\",\"message\":\"value not found\"}\n",
+		},
+		{
+			Name: "Store error",
+			url:  "/api/v1/sheet1/a/explain",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().ExplainCell(gomock.Any(), "sheet1", "a").Return(nil, errors.New("error"))
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: "{\"code\":\"404\",\"message\":\"store not responded\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v1", h.RegisterRoutes)
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", test.url, bytes.NewBuffer(nil))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// goalSeek finds the value of a changing cell making a target cell result in a value, "commit" writes it.
+func (h *ExcelLikeHandler) goalSeek(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("not correct params", http.StatusUnprocessableEntity))
+		return
+	}
+	var requestBody *models.GoalSeek
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.Log.WithError(err).Error("can't read request body")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't read request body", http.StatusUnprocessableEntity))
+		return
+	}
+	defer r.Body.Close()
+
+	if err = json.Unmarshal(body, &requestBody); err != nil || requestBody == nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't unmarshal request body", http.StatusUnprocessableEntity))
+		return
+	}
+
+	result, err := h.ELS.GoalSeekTX(r.Context(), strings.ToLower(sheetID), requestBody)
+	if err != nil {
+		h.Log.WithError(err).Error("failed to seek goal")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if errors.Is(err, services.ErrInvalidGoalSeek) || errors.Is(err, services.ErrGoalNotReached) {
+			render.JSON(w, r, models.Error(err.Error(), http.StatusUnprocessableEntity))
+			return
+		}
+		render.JSON(w, r, models.Error("can't seek goal", http.StatusUnprocessableEntity))
+		return
+	}
+	render.JSON(w, r, result)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+	mock_services "dev-challenge/internal/services/mock"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_goalSeek(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+
+	tests := []struct {
+		Name                 string
+		url                  string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name:      "Found",
+			url:       "/api/v1/Sheet1/_goalseek",
+			inputBody: `{"target":"profit","value":0,"changing":"price","commit":true}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GoalSeekTX(gomock.Any(), "sheet1", &models.GoalSeek{Target: "profit", Changing: "price", Commit: true}).
+					Return(&models.GoalSeekResult{Target: "profit", Changing: "price", Input: 12, Iterations: 3, Committed: true}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"target\":\"profit\",\"changing\":\"price\",\"input\":12,\"result\":0,\"iterations\":3,\"committed\":true}\n",
+		},
+		{
+			Name:      "Not reached",
+			url:       "/api/v2/sheet1/_goalseek",
+			inputBody: `{"target":"profit","value":0,"changing":"price","min":20,"max":30}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GoalSeekTX(gomock.Any(), "sheet1", gomock.Any()).
+					Return(nil, fmt.Errorf("%w: target doesn't reach the value between 20 and 30", services.ErrGoalNotReached))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"goal not reached: target doesn't reach the value between 20 and 30\"}\n",
+		},
+		{
+			Name:      "Invalid",
+			url:       "/api/v1/sheet1/_goalseek",
+			inputBody: `{"target":"profit","value":0}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GoalSeekTX(gomock.Any(), "sheet1", gomock.Any()).
+					Return(nil, fmt.Errorf("%w: target and changing cells are required", services.ErrInvalidGoalSeek))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"invalid goal seek: target and changing cells are required\"}\n",
+		},
+		{
+			Name:                 "Wrong body",
+			url:                  "/api/v1/sheet1/_goalseek",
+			inputBody:            `{"target":`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't unmarshal request body\"}\n",
+		},
+		{
+			Name:      "Store error",
+			url:       "/api/v1/sheet1/_goalseek",
+			inputBody: `{"target":"profit","value":0,"changing":"price"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GoalSeekTX(gomock.Any(), "sheet1", gomock.Any()).Return(nil, errors.New("error"))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't seek goal\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v1", h.RegisterRoutes)
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.url, bytes.NewBufferString(test.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
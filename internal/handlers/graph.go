@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dev-challenge/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func (h *ExcelLikeHandler) getPrecedents(w http.ResponseWriter, r *http.Request) {
+	h.getCellLinks(w, r, h.ELS.GetPrecedents)
+}
+
+func (h *ExcelLikeHandler) getDependents(w http.ResponseWriter, r *http.Request) {
+	h.getCellLinks(w, r, h.ELS.GetDependents)
+}
+
+// getCellLinks answers the precedents and dependents requests, ?depth=N stops at N levels, 1 for direct links only.
+func (h *ExcelLikeHandler) getCellLinks(w http.ResponseWriter, r *http.Request,
+	get func(ctx context.Context, sheetID, cellID string, depth int) (*models.CellLinks, error)) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	cellID := chi.URLParam(r, "cell_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) || !containsOnlyURLAllowedChars(strings.ToLower(cellID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	depth := 0
+	if param := r.URL.Query().Get("depth"); param != "" {
+		var err error
+		if depth, err = strconv.Atoi(param); err != nil || depth < 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			render.JSON(w, r, models.Error("depth must be a non-negative integer", http.StatusUnprocessableEntity))
+			return
+		}
+	}
+	links, err := get(r.Context(), strings.ToLower(sheetID), strings.ToLower(cellID), depth)
+	if err != nil {
+		h.Log.WithError(err).Error("failed to get cell links")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("store not responded", http.StatusNotFound))
+		return
+	}
+	if links == nil {
+		h.Log.Error(fmt.Sprintf("value on sheetID=%s and cellID=%s not found", sheetID, cellID))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("value not found", http.StatusNotFound))
+		return
+	}
+	render.JSON(w, r, links)
+}
+
+// getSheetGraph exports the dependency graph of a sheet as JSON, or for GraphViz with ?format=dot.
+func (h *ExcelLikeHandler) getSheetGraph(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format != "" && format != "json" && format != "dot" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("format must be json or dot", http.StatusUnprocessableEntity))
+		return
+	}
+	graph, err := h.ELS.GetSheetGraph(r.Context(), strings.ToLower(sheetID))
+	if err != nil {
+		h.Log.WithError(err).Error("failed to get sheet graph")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("store not responded", http.StatusNotFound))
+		return
+	}
+	if graph == nil {
+		h.Log.Error(fmt.Sprintf("sheetID=%s not found", sheetID))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("value not found", http.StatusNotFound))
+		return
+	}
+	if format == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Write([]byte(graph.DOT()))
+		return
+	}
+	render.JSON(w, r, graph)
+}
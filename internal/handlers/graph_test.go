@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev-challenge/internal/models"
+	mock_services "dev-challenge/internal/services/mock"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_getCellLinks(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+
+	tests := []struct {
+		Name                 string
+		url                  string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name: "Transitive precedents",
+			url:  "/api/v1/Sheet1/C/precedents",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetPrecedents(gomock.Any(), "sheet1", "c", 0).Return(&models.CellLinks{
+					Sheet: "sheet1", ID: "c", Cells: []models.LinkedCell{
+						{Sheet: "sheet1", ID: "b", Depth: 1},
+						{Sheet: "sheet1", ID: "a", Depth: 2, Missing: true},
+					},
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"sheet\":\"sheet1\",\"id\":\"c\",\"cells\":[{\"sheet\":\"sheet1\",\"id\":\"b\",\"depth\":1},{\"sheet\":\"sheet1\",\"id\":\"a\",\"depth\":2,\"missing\":true}]}\n",
+		},
+		{
+			Name: "Direct dependents",
+			url:  "/api/v2/sheet1/a/dependents?depth=1",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetDependents(gomock.Any(), "sheet1", "a", 1).Return(&models.CellLinks{
+					Sheet: "sheet1", ID: "a", Cells: []models.LinkedCell{{Sheet: "sheet2", ID: "x", Depth: 1}},
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"sheet\":\"sheet1\",\"id\":\"a\",\"cells\":[{\"sheet\":\"sheet2\",\"id\":\"x\",\"depth\":1}]}\n",
+		},
+		{
+			Name:                 "Negative depth",
+			url:                  "/api/v1/sheet1/a/dependents?depth=-1",
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"depth must be a non-negative integer\"}\n",
+		},
+		{
+			Name:                 "Not a number depth",
+			url:                  "/api/v1/sheet1/a/precedents?depth=all",
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"depth must be a non-negative integer\"}\n",
+		},
+		{
+			Name: "Cell not found",
+			url:  "/api/v1/sheet1/missing/dependents",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetDependents(gomock.Any(), "sheet1", "missing", 0).Return(nil, nil)
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: "{\"code\":\"404\",\"message\":\"value not found\"}\n",
+		},
+		{
+			Name: "Store error",
+			url:  "/api/v1/sheet1/a/precedents",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetPrecedents(gomock.Any(), "sheet1", "a", 0).Return(nil, errors.New("error"))
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: "{\"code\":\"404\",\"message\":\"store not responded\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v1", h.RegisterRoutes)
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", test.url, bytes.NewBuffer(nil))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getSheetGraph(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+
+	graph := &models.SheetGraph{
+		Sheet: "sheet1",
+		Nodes: []models.GraphNode{
+			{Ref: "sheet1!a", Sheet: "sheet1", ID: "a", Value: "1"},
+			{Ref: "sheet1!b", Sheet: "sheet1", ID: "b", Value: "=a+c"},
+			{Ref: "sheet1!c", Sheet: "sheet1", ID: "c", Missing: true},
+			{Ref: "sheet2!x", Sheet: "sheet2", ID: "x", Value: "=sheet1!b"},
+			{Ref: "sheet2!y", Sheet: "sheet2", ID: "y", Missing: true},
+		},
+		Edges: []models.GraphEdge{
+			{From: "sheet1!a", To: "sheet1!b"},
+			{From: "sheet1!b", To: "sheet2!x"},
+			{From: "sheet1!c", To: "sheet1!b"},
+			{From: "sheet2!y", To: "sheet1!b"},
+		},
+	}
+
+	tests := []struct {
+		Name                 string
+		url                  string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedContentType  string
+		expectedResponseBody string
+	}{
+		{
+			Name: "JSON by default",
+			url:  "/api/v1/sheet1/_graph",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetSheetGraph(gomock.Any(), "sheet1").Return(&models.SheetGraph{
+					Sheet: "sheet1",
+					Nodes: []models.GraphNode{{Ref: "sheet1!a", Sheet: "sheet1", ID: "a", Value: "1"}},
+					Edges: []models.GraphEdge{},
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedContentType:  "application/json",
+			expectedResponseBody: "{\"sheet\":\"sheet1\",\"nodes\":[{\"ref\":\"sheet1!a\",\"sheet\":\"sheet1\",\"id\":\"a\",\"value\":\"1\"}],\"edges\":[]}\n",
+		},
+		{
+			Name: "DOT",
+			url:  "/api/v2/Sheet1/_graph?format=dot",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetSheetGraph(gomock.Any(), "sheet1").Return(graph, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: "text/vnd.graphviz; charset=utf-8",
+			expectedResponseBody: "digraph \"sheet1\" {\n" +
+				"  \"sheet1!a\" [label=\"a\"];\n" +
+				"  \"sheet1!b\" [label=\"b\"];\n" +
+				"  \"sheet1!c\" [label=\"c\", style=dotted];\n" +
+				"  \"sheet2!x\" [label=\"sheet2!x\", style=dashed];\n" +
+				"  \"sheet2!y\" [label=\"sheet2!y\", style=dotted];\n" +
+				"  \"sheet1!a\" -> \"sheet1!b\";\n" +
+				"  \"sheet1!b\" -> \"sheet2!x\";\n" +
+				"  \"sheet1!c\" -> \"sheet1!b\";\n" +
+				"  \"sheet2!y\" -> \"sheet1!b\";\n" +
+				"}\n",
+		},
+		{
+			Name:                 "Unknown format",
+			url:                  "/api/v1/sheet1/_graph?format=svg",
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedContentType:  "application/json",
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"format must be json or dot\"}\n",
+		},
+		{
+			Name: "Sheet not found",
+			url:  "/api/v1/empty/_graph",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetSheetGraph(gomock.Any(), "empty").Return(nil, nil)
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedContentType:  "application/json",
+			expectedResponseBody: "{\"code\":\"404\",\"message\":\"value not found\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v1", h.RegisterRoutes)
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", test.url, bytes.NewBuffer(nil))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Contains(t, w.Header().Get("Content-Type"), test.expectedContentType)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// evaluateScenario calculates the cells of a sheet with the posted overrides, nothing is stored.
+func (h *ExcelLikeHandler) evaluateScenario(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("not correct params", http.StatusUnprocessableEntity))
+		return
+	}
+	scenario, ok := h.decodeScenario(w, r)
+	if !ok {
+		return
+	}
+	result, err := h.ELS.EvaluateScenario(r.Context(), strings.ToLower(sheetID), scenario)
+	if err != nil {
+		h.scenarioError(w, r, err, "can't evaluate scenario")
+		return
+	}
+	render.JSON(w, r, result)
+}
+
+func (h *ExcelLikeHandler) getScenarios(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	scenarios, err := h.ELS.GetScenarios(r.Context(), strings.ToLower(sheetID))
+	if err != nil {
+		h.Log.WithError(err).Error("failed to get scenarios")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("store not responded", http.StatusNotFound))
+		return
+	}
+	render.JSON(w, r, scenarios)
+}
+
+// saveScenario stores a scenario under its name, replacing the scenario stored before.
+func (h *ExcelLikeHandler) saveScenario(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	name := chi.URLParam(r, "name")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) || !containsOnlyURLAllowedChars(strings.ToLower(name)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("not correct params", http.StatusUnprocessableEntity))
+		return
+	}
+	scenario, ok := h.decodeScenario(w, r)
+	if !ok {
+		return
+	}
+	result, err := h.ELS.SaveScenarioTX(r.Context(), strings.ToLower(sheetID), strings.ToLower(name), scenario)
+	if err != nil {
+		h.scenarioError(w, r, err, "can't save scenario")
+		return
+	}
+	render.JSON(w, r, result)
+}
+
+// getScenario calculates a stored scenario.
+func (h *ExcelLikeHandler) getScenario(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	name := chi.URLParam(r, "name")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) || !containsOnlyURLAllowedChars(strings.ToLower(name)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	result, err := h.ELS.GetScenario(r.Context(), strings.ToLower(sheetID), strings.ToLower(name))
+	if err != nil {
+		h.scenarioError(w, r, err, "can't evaluate scenario")
+		return
+	}
+	if result == nil {
+		h.Log.Error(fmt.Sprintf("scenario %s on sheetID=%s not found", name, sheetID))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("scenario not found", http.StatusNotFound))
+		return
+	}
+	render.JSON(w, r, result)
+}
+
+func (h *ExcelLikeHandler) deleteScenario(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	name := chi.URLParam(r, "name")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) || !containsOnlyURLAllowedChars(strings.ToLower(name)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	deleted, err := h.ELS.DeleteScenarioTX(r.Context(), strings.ToLower(sheetID), strings.ToLower(name))
+	if err != nil {
+		h.Log.WithError(err).Error("failed to delete scenario")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't delete scenario", http.StatusUnprocessableEntity))
+		return
+	}
+	if !deleted {
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("scenario not found", http.StatusNotFound))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// compareScenarios puts stored scenarios side by side, ?names=a,b picks the scenarios and ?cells=x,y the cells.
+func (h *ExcelLikeHandler) compareScenarios(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error("not correct params", http.StatusNotFound))
+		return
+	}
+	names := splitParam(r.URL.Query().Get("names"))
+	cells := splitParam(r.URL.Query().Get("cells"))
+	comparison, err := h.ELS.CompareScenarios(r.Context(), strings.ToLower(sheetID), names, cells)
+	if err != nil {
+		h.scenarioError(w, r, err, "can't compare scenarios")
+		return
+	}
+	render.JSON(w, r, comparison)
+}
+
+func (h *ExcelLikeHandler) decodeScenario(w http.ResponseWriter, r *http.Request) (*models.Scenario, bool) {
+	var requestBody *models.Scenario
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.Log.WithError(err).Error("can't read request body")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't read request body", http.StatusUnprocessableEntity))
+		return nil, false
+	}
+	defer r.Body.Close()
+
+	if err = json.Unmarshal(body, &requestBody); err != nil || requestBody == nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't unmarshal request body", http.StatusUnprocessableEntity))
+		return nil, false
+	}
+	for key := range requestBody.Overrides {
+		if !writableCellID(strings.ToLower(key)) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			render.JSON(w, r, models.Error("not correct override "+key, http.StatusUnprocessableEntity))
+			return nil, false
+		}
+	}
+	return requestBody, true
+}
+
+func (h *ExcelLikeHandler) scenarioError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	h.Log.WithError(err).Error(message)
+	switch {
+	case errors.Is(err, services.ErrScenarioNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, models.Error(err.Error(), http.StatusNotFound))
+	case errors.Is(err, services.ErrInvalidScenario):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error(err.Error(), http.StatusUnprocessableEntity))
+	default:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error(message, http.StatusUnprocessableEntity))
+	}
+}
+
+// splitParam splits a comma separated query parameter, lower casing it like the IDs in paths.
+func splitParam(param string) []string {
+	var res []string
+	for _, value := range strings.Split(param, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			res = append(res, strings.ToLower(value))
+		}
+	}
+	return res
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+	mock_services "dev-challenge/internal/services/mock"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_scenarios(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+
+	revenue := models.Cell{Value: "=100*(1+growth)", Result: float64(105), Type: "number", Formatted: "105"}
+	growth := &models.Scenario{Overrides: map[string]string{"Growth": "0.05"}, Cells: []string{"revenue"}}
+
+	tests := []struct {
+		Name                 string
+		method               string
+		url                  string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name:      "What if",
+			method:    "POST",
+			url:       "/api/v1/Sheet1/_whatif",
+			inputBody: `{"overrides":{"Growth":"0.05"},"cells":["revenue"]}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().EvaluateScenario(gomock.Any(), "sheet1", growth).Return(&models.ScenarioResult{
+					Scenario: models.Scenario{Overrides: map[string]string{"growth": "0.05"}, Cells: []string{"revenue"}},
+					Results:  map[string]models.Cell{"revenue": revenue},
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"overrides\":{\"growth\":\"0.05\"},\"cells\":[\"revenue\"],\"results\":{\"revenue\":{\"value\":\"=100*(1+growth)\",\"result\":105,\"type\":\"number\",\"formatted\":\"105\"}}}\n",
+		},
+		{
+			Name:      "What if with a wrong override",
+			method:    "POST",
+			url:       "/api/v2/sheet1/_whatif",
+			inputBody: `{"overrides":{"growth":"=1/0"}}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().EvaluateScenario(gomock.Any(), "sheet1", gomock.Any()).
+					Return(nil, fmt.Errorf("%w: override of growth: division by zero", services.ErrInvalidScenario))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"invalid scenario: override of growth: division by zero\"}\n",
+		},
+		{
+			Name:                 "What if with a wrong body",
+			method:               "POST",
+			url:                  "/api/v1/sheet1/_whatif",
+			inputBody:            `{"overrides":`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't unmarshal request body\"}\n",
+		},
+		{
+			Name:   "List",
+			method: "GET",
+			url:    "/api/v1/sheet1/_scenarios",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetScenarios(gomock.Any(), "sheet1").Return([]models.Scenario{
+					{Name: "growth", Overrides: map[string]string{"growth": "0.05"}},
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "[{\"name\":\"growth\",\"overrides\":{\"growth\":\"0.05\"}}]\n",
+		},
+		{
+			Name:      "Save",
+			method:    "PUT",
+			url:       "/api/v2/sheet1/_scenarios/Growth",
+			inputBody: `{"overrides":{"Growth":"0.05"},"cells":["revenue"]}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().SaveScenarioTX(gomock.Any(), "sheet1", "growth", growth).Return(&models.ScenarioResult{
+					Scenario: models.Scenario{Name: "growth", Overrides: map[string]string{"growth": "0.05"}, Cells: []string{"revenue"}},
+					Results:  map[string]models.Cell{"revenue": revenue},
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"name\":\"growth\",\"overrides\":{\"growth\":\"0.05\"},\"cells\":[\"revenue\"],\"results\":{\"revenue\":{\"value\":\"=100*(1+growth)\",\"result\":105,\"type\":\"number\",\"formatted\":\"105\"}}}\n",
+		},
+		{
+			Name:   "Get",
+			method: "GET",
+			url:    "/api/v1/sheet1/_scenarios/growth",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetScenario(gomock.Any(), "sheet1", "growth").Return(&models.ScenarioResult{
+					Scenario: models.Scenario{Name: "growth", Overrides: map[string]string{"growth": "0.05"}},
+					Results:  map[string]models.Cell{"revenue": revenue},
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"name\":\"growth\",\"overrides\":{\"growth\":\"0.05\"},\"results\":{\"revenue\":{\"value\":\"=100*(1+growth)\",\"result\":105,\"type\":\"number\",\"formatted\":\"105\"}}}\n",
+		},
+		{
+			Name:   "Get missing",
+			method: "GET",
+			url:    "/api/v1/sheet1/_scenarios/growth",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().GetScenario(gomock.Any(), "sheet1", "growth").Return(nil, nil)
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: "{\"code\":\"404\",\"message\":\"scenario not found\"}\n",
+		},
+		{
+			Name:   "Delete",
+			method: "DELETE",
+			url:    "/api/v1/sheet1/_scenarios/growth",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().DeleteScenarioTX(gomock.Any(), "sheet1", "growth").Return(true, nil)
+			},
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:   "Delete missing",
+			method: "DELETE",
+			url:    "/api/v2/sheet1/_scenarios/growth",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().DeleteScenarioTX(gomock.Any(), "sheet1", "growth").Return(false, nil)
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: "{\"code\":\"404\",\"message\":\"scenario not found\"}\n",
+		},
+		{
+			Name:   "Compare",
+			method: "GET",
+			url:    "/api/v1/sheet1/_scenarios/_compare?names=low,High&cells=revenue",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().CompareScenarios(gomock.Any(), "sheet1", []string{"low", "high"}, []string{"revenue"}).Return(&models.ScenarioComparison{
+					Scenarios: []string{"low", "high"},
+					Cells: []models.ComparedCell{{ID: "revenue", Actual: &revenue, Results: map[string]models.Cell{
+						"low": {Value: revenue.Value, Result: float64(101), Type: "number", Formatted: "101"},
+					}}},
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"scenarios\":[\"low\",\"high\"],\"cells\":[{\"id\":\"revenue\",\"actual\":{\"value\":\"=100*(1+growth)\",\"result\":105,\"type\":\"number\",\"formatted\":\"105\"},\"results\":{\"low\":{\"value\":\"=100*(1+growth)\",\"result\":101,\"type\":\"number\",\"formatted\":\"101\"}}}]}\n",
+		},
+		{
+			Name:   "Compare unknown scenario",
+			method: "GET",
+			url:    "/api/v2/sheet1/_scenarios/_compare?names=low",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().CompareScenarios(gomock.Any(), "sheet1", []string{"low"}, nil).
+					Return(nil, fmt.Errorf("%w: low", services.ErrScenarioNotFound))
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: "{\"code\":\"404\",\"message\":\"scenario not found: low\"}\n",
+		},
+		{
+			Name:   "Store error",
+			method: "GET",
+			url:    "/api/v1/sheet1/_scenarios/_compare",
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().CompareScenarios(gomock.Any(), "sheet1", nil, nil).Return(nil, errors.New("error"))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't compare scenarios\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v1", h.RegisterRoutes)
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(test.method, test.url, bytes.NewBufferString(test.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
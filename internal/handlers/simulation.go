@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// simulate runs a Monte Carlo simulation of the sheet and returns the statistics of the output cells.
+func (h *ExcelLikeHandler) simulate(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("not correct params", http.StatusUnprocessableEntity))
+		return
+	}
+	var requestBody *models.Simulation
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.Log.WithError(err).Error("can't read request body")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't read request body", http.StatusUnprocessableEntity))
+		return
+	}
+	defer r.Body.Close()
+
+	if err = json.Unmarshal(body, &requestBody); err != nil || requestBody == nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't unmarshal request body", http.StatusUnprocessableEntity))
+		return
+	}
+
+	result, err := h.ELS.Simulate(r.Context(), strings.ToLower(sheetID), requestBody)
+	if err != nil {
+		h.Log.WithError(err).Error("failed to simulate")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if errors.Is(err, services.ErrInvalidSimulation) {
+			render.JSON(w, r, models.Error(err.Error(), http.StatusUnprocessableEntity))
+			return
+		}
+		render.JSON(w, r, models.Error("can't simulate", http.StatusUnprocessableEntity))
+		return
+	}
+	render.JSON(w, r, result)
+}
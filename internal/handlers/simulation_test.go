@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+	mock_services "dev-challenge/internal/services/mock"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_simulate(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+	mean, stddev := 10.0, 2.0
+	seed := int64(42)
+
+	tests := []struct {
+		Name                 string
+		url                  string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name:      "Simulated",
+			url:       "/api/v1/Sheet1/_simulate",
+			inputBody: `{"inputs":[{"cell":"price","distribution":"normal","mean":10,"stddev":2}],"outputs":["profit"],"iterations":2,"seed":42,"bins":1,"percentiles":[50]}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().Simulate(gomock.Any(), "sheet1", &models.Simulation{
+					Inputs:  []models.UncertainInput{{Cell: "price", Distribution: "normal", Mean: &mean, StdDev: &stddev}},
+					Outputs: []string{"profit"}, Iterations: 2, Seed: &seed, Bins: 1, Percentiles: []float64{50},
+				}).Return(&models.SimulationResult{Iterations: 2, Seed: 42, Outputs: map[string]models.OutputStats{
+					"profit": {Mean: 15, StdDev: 7.0710678118654755, Min: 10, Max: 20, Percentiles: map[string]float64{"p50": 15},
+						Histogram: []models.HistogramBin{{From: 10, To: 20, Count: 2}}},
+				}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: "{\"iterations\":2,\"seed\":42,\"outputs\":{\"profit\":{\"mean\":15,\"stddev\":7.0710678118654755,\"min\":10,\"max\":20," +
+				"\"percentiles\":{\"p50\":15},\"histogram\":[{\"from\":10,\"to\":20,\"count\":2}],\"errors\":0}}}\n",
+		},
+		{
+			Name:      "Invalid",
+			url:       "/api/v2/sheet1/_simulate",
+			inputBody: `{"inputs":[{"cell":"price","distribution":"normal"}],"outputs":["profit"]}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().Simulate(gomock.Any(), "sheet1", gomock.Any()).Return(nil,
+					fmt.Errorf("%w: price: the distribution needs mean and stddev", services.ErrInvalidSimulation))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"invalid simulation: price: the distribution needs mean and stddev\"}\n",
+		},
+		{
+			Name:                 "Wrong body",
+			url:                  "/api/v1/sheet1/_simulate",
+			inputBody:            `{"inputs":`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't unmarshal request body\"}\n",
+		},
+		{
+			Name:      "Store error",
+			url:       "/api/v1/sheet1/_simulate",
+			inputBody: `{"inputs":[{"cell":"price","distribution":"uniform","min":1,"max":2}],"outputs":["profit"]}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().Simulate(gomock.Any(), "sheet1", gomock.Any()).Return(nil, errors.New("error"))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't simulate\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v1", h.RegisterRoutes)
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.url, bytes.NewBufferString(test.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
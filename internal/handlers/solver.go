@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// solve optimizes an objective cell by changing variable cells under constraints, "commit" writes the values found.
+func (h *ExcelLikeHandler) solve(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	if !containsOnlyURLAllowedChars(strings.ToLower(sheetID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("not correct params", http.StatusUnprocessableEntity))
+		return
+	}
+	var requestBody *models.Solve
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.Log.WithError(err).Error("can't read request body")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't read request body", http.StatusUnprocessableEntity))
+		return
+	}
+	defer r.Body.Close()
+
+	if err = json.Unmarshal(body, &requestBody); err != nil || requestBody == nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't unmarshal request body", http.StatusUnprocessableEntity))
+		return
+	}
+
+	result, err := h.ELS.SolveTX(r.Context(), strings.ToLower(sheetID), requestBody)
+	if err != nil {
+		h.Log.WithError(err).Error("failed to solve")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if errors.Is(err, services.ErrInvalidSolve) || errors.Is(err, services.ErrNoSolution) {
+			render.JSON(w, r, models.Error(err.Error(), http.StatusUnprocessableEntity))
+			return
+		}
+		render.JSON(w, r, models.Error("can't solve", http.StatusUnprocessableEntity))
+		return
+	}
+	render.JSON(w, r, result)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev-challenge/internal/models"
+	"dev-challenge/internal/services"
+	mock_services "dev-challenge/internal/services/mock"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_solve(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+
+	tests := []struct {
+		Name                 string
+		url                  string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name:      "Solved",
+			url:       "/api/v1/Sheet1/_solve",
+			inputBody: `{"objective":"profit","goal":"max","variables":[{"cell":"x"}],"constraints":[{"cell":"machine","op":"<=","value":18}]}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().SolveTX(gomock.Any(), "sheet1", &models.Solve{Objective: "profit", Goal: "max",
+					Variables:   []models.SolverVariable{{Cell: "x"}},
+					Constraints: []models.SolverConstraint{{Cell: "machine", Op: "<=", Value: 18}},
+				}).Return(&models.SolveResult{Method: "simplex", Status: "optimal", Objective: 18, Variables: map[string]float64{"x": 6},
+					Constraints: []models.ConstraintResult{{SolverConstraint: models.SolverConstraint{Cell: "machine", Op: "<=", Value: 18}, Result: 18}},
+					Iterations:  4,
+				}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: "{\"method\":\"simplex\",\"status\":\"optimal\",\"objective\":18,\"variables\":{\"x\":6}," +
+				"\"constraints\":[{\"cell\":\"machine\",\"op\":\"\\u003c=\",\"value\":18,\"result\":18,\"slack\":0}],\"iterations\":4,\"committed\":false}\n",
+		},
+		{
+			Name:      "No solution",
+			url:       "/api/v2/sheet1/_solve",
+			inputBody: `{"objective":"profit","goal":"max","variables":[{"cell":"x"}]}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().SolveTX(gomock.Any(), "sheet1", gomock.Any()).Return(nil, fmt.Errorf("%w: objective is unbounded", services.ErrNoSolution))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"no solution: objective is unbounded\"}\n",
+		},
+		{
+			Name:      "Invalid",
+			url:       "/api/v1/sheet1/_solve",
+			inputBody: `{"objective":"profit","goal":"best"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().SolveTX(gomock.Any(), "sheet1", gomock.Any()).Return(nil, fmt.Errorf("%w: goal must be max or min", services.ErrInvalidSolve))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"invalid solve: goal must be max or min\"}\n",
+		},
+		{
+			Name:                 "Wrong body",
+			url:                  "/api/v1/sheet1/_solve",
+			inputBody:            `{"objective":`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't unmarshal request body\"}\n",
+		},
+		{
+			Name:      "Store error",
+			url:       "/api/v1/sheet1/_solve",
+			inputBody: `{"objective":"profit","goal":"max","variables":[{"cell":"x"}]}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().SolveTX(gomock.Any(), "sheet1", gomock.Any()).Return(nil, errors.New("error"))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't solve\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v1", h.RegisterRoutes)
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.url, bytes.NewBufferString(test.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
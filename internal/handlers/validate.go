@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"dev-challenge/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// validateCell runs a write without storing it, the response tells what the write would store
+// and recalculate or why it would be rejected.
+func (h *ExcelLikeHandler) validateCell(w http.ResponseWriter, r *http.Request) {
+	sheetID := chi.URLParam(r, "sheet_id")
+	cellID := chi.URLParam(r, "cell_id")
+	if !writableSheetID(strings.ToLower(sheetID)) || !writableCellID(strings.ToLower(cellID)) {
+		h.Log.Error("not correct data in params")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("not correct params", http.StatusUnprocessableEntity))
+		return
+	}
+	var requestBody *models.CellInput
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.Log.WithError(err).Error("can't read request body")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't read request body", http.StatusUnprocessableEntity))
+		return
+	}
+	defer r.Body.Close()
+
+	if err = json.Unmarshal(body, &requestBody); err != nil || requestBody == nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't unmarshal request body", http.StatusUnprocessableEntity))
+		return
+	}
+
+	validation, err := h.ELS.ValidateCellTX(r.Context(), strings.ToLower(sheetID), strings.ToLower(cellID), requestBody)
+	if err != nil {
+		h.Log.WithError(err).Error("failed to validate value")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, models.Error("can't validate value", http.StatusUnprocessableEntity))
+		return
+	}
+	render.JSON(w, r, validation)
+}
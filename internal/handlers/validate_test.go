@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev-challenge/internal/models"
+	mock_services "dev-challenge/internal/services/mock"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_validateCell(t *testing.T) {
+	type mockBehavior func(r *mock_services.MockExcelLikeService)
+
+	tests := []struct {
+		Name                 string
+		url                  string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			Name:      "Valid",
+			url:       "/api/v1/Sheet1/A/_validate",
+			inputBody: `{"value":"=b+1"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().ValidateCellTX(gomock.Any(), "sheet1", "a", &models.CellInput{Value: "=b+1"}).Return(&models.Validation{
+					Valid: true, Cell: &models.Cell{Value: "=b+1", Result: float64(3), Type: "number", Formatted: "3"},
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"valid\":true,\"cell\":{\"value\":\"=b+1\",\"result\":3,\"type\":\"number\",\"formatted\":\"3\"}}\n",
+		},
+		{
+			Name:      "Invalid",
+			url:       "/api/v2/sheet1/a/_validate",
+			inputBody: `{"value":"=b+(1"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().ValidateCellTX(gomock.Any(), "sheet1", "a", &models.CellInput{Value: "=b+(1"}).Return(&models.Validation{
+					Errors: []models.ValidationError{{Code: "#NAME?", Message: "missing closing parenthesis for position 3", Position: 3}},
+				}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "{\"valid\":false,\"errors\":[{\"code\":\"#NAME?\",\"message\":\"missing closing parenthesis for position 3\",\"position\":3}]}\n",
+		},
+		{
+			Name:                 "Wrong body",
+			url:                  "/api/v1/sheet1/a/_validate",
+			inputBody:            `{"value":`,
+			mockBehavior:         func(r *mock_services.MockExcelLikeService) {},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't unmarshal request body\"}\n",
+		},
+		{
+			Name:      "Store error",
+			url:       "/api/v1/sheet1/a/_validate",
+			inputBody: `{"value":"1"}`,
+			mockBehavior: func(r *mock_services.MockExcelLikeService) {
+				r.EXPECT().ValidateCellTX(gomock.Any(), "sheet1", "a", &models.CellInput{Value: "1"}).Return(nil, errors.New("error"))
+			},
+			expectedStatusCode:   http.StatusUnprocessableEntity,
+			expectedResponseBody: "{\"code\":\"422\",\"message\":\"can't validate value\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock_services.NewMockExcelLikeService(ctrl)
+			test.mockBehavior(m)
+
+			r := chi.NewRouter()
+			h := &ExcelLikeHandler{
+				ELS: m,
+				Log: mockLogger,
+			}
+			r.Route("/api/v1", h.RegisterRoutes)
+			r.Route("/api/v2", h.RegisterRoutesV2)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.url, bytes.NewBufferString(test.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package models
+
+// RejectedBatch is the response to a batch write that wasn't applied, Errors holds the reason
+// of every rejected cell by its ID.
+type RejectedBatch struct {
+	Code    string                     `json:"code"`
+	Message string                     `json:"message"`
+	Errors  map[string]ValidationError `json:"errors"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// Cell is a cell of the v2 API. Result is typed: a number, a string, a boolean or a CellError,
+// Formatted is the result as shown to the user, formatted by the Format mask of the cell when it has one.
+// Dependents are the cells a write recalculated when the client asked for them.
+type Cell struct {
+	Value      string             `json:"value"`
+	Result     any                `json:"result"`
+	Type       string             `json:"type"`
+	Format     string             `json:"format,omitempty"`
+	Formatted  string             `json:"formatted"`
+	Cycle      []string           `json:"cycle,omitempty"`
+	Dependents []RecalculatedCell `json:"dependents,omitempty"`
+}
+
+// RecalculatedCell is a cell recalculated by a write of another cell.
+type RecalculatedCell struct {
+	Sheet string `json:"sheet"`
+	ID    string `json:"id"`
+	Cell
+}
+
+// CellError is the result of a cell in an error state.
+type CellError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// CellInput is the body of a v2 write. A missing Format keeps the format of the cell, an empty one removes it.
+type CellInput struct {
+	Value  string  `json:"value"`
+	Format *string `json:"format,omitempty"`
+}
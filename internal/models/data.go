@@ -1,6 +1,9 @@
 package models
 
 type Data struct {
-	Value  string `json:"value"`
-	Result string `json:"result"`
+	Value  string   `json:"value"`
+	Result string   `json:"result"`
+	Type   string   `json:"type,omitempty"`
+	Error  string   `json:"error,omitempty"`
+	Cycle  []string `json:"cycle,omitempty"`
 }
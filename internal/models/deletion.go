@@ -0,0 +1,15 @@
+package models
+
+// Deletion tells how many cells a delete removed, Dependents are the cells using them, recalculated
+// with the removed cells missing.
+type Deletion struct {
+	Deleted    int                `json:"deleted"`
+	Dependents []RecalculatedCell `json:"dependents"`
+}
+
+// DeleteConflict is the response to a delete rejected because other cells use the removed cells.
+type DeleteConflict struct {
+	Code       string   `json:"code"`
+	Message    string   `json:"message"`
+	Dependents []string `json:"dependents"`
+}
@@ -17,3 +17,32 @@ func ErrorPOSTResponse(inputValue string) *Data {
 		Result: "ERROR",
 	}
 }
+
+// FormulaErrorPOSTResponse is ErrorPOSTResponse with a typed spreadsheet error like #DIV/0! as the result
+// and the description of what went wrong.
+func FormulaErrorPOSTResponse(inputValue, code, description string) *Data {
+	return &Data{
+		Value:  inputValue,
+		Result: code,
+		Type:   "error",
+		Error:  description,
+	}
+}
+
+// CircularReferencePOSTResponse is a #CIRCULAR! error extended with the loop of cells the input would create.
+func CircularReferencePOSTResponse(inputValue string, cycle []string) *Data {
+	resp := FormulaErrorPOSTResponse(inputValue, "#CIRCULAR!", "circular reference")
+	resp.Cycle = cycle
+	return resp
+}
+
+// FormulaErrorCell is the v2 response for a formula that can't be calculated.
+func FormulaErrorCell(inputValue, code, description string, cycle []string) *Cell {
+	return &Cell{
+		Value:     inputValue,
+		Result:    CellError{Code: code, Message: description},
+		Type:      "error",
+		Formatted: code,
+		Cycle:     cycle,
+	}
+}
@@ -59,3 +59,108 @@ func TestErrorPOSTResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestFormulaErrorPOSTResponse(t *testing.T) {
+	type args struct {
+		inputValue  string
+		code        string
+		description string
+	}
+	tests := []struct {
+		name string
+		args args
+		want *Data
+	}{
+		{
+			name: "normal flow",
+			args: args{inputValue: "=a/0", code: "#DIV/0!", description: "division by zero"},
+			want: &Data{
+				Value:  "=a/0",
+				Result: "#DIV/0!",
+				Type:   "error",
+				Error:  "division by zero",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormulaErrorPOSTResponse(tt.args.inputValue, tt.args.code, tt.args.description); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FormulaErrorPOSTResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircularReferencePOSTResponse(t *testing.T) {
+	type args struct {
+		inputValue string
+		cycle      []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want *Data
+	}{
+		{
+			name: "normal flow",
+			args: args{inputValue: "=b+1", cycle: []string{"a", "b", "a"}},
+			want: &Data{
+				Value:  "=b+1",
+				Result: "#CIRCULAR!",
+				Type:   "error",
+				Error:  "circular reference",
+				Cycle:  []string{"a", "b", "a"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CircularReferencePOSTResponse(tt.args.inputValue, tt.args.cycle); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CircularReferencePOSTResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormulaErrorCell(t *testing.T) {
+	type args struct {
+		inputValue  string
+		code        string
+		description string
+		cycle       []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want *Cell
+	}{
+		{
+			name: "normal flow",
+			args: args{inputValue: "=a/0", code: "#DIV/0!", description: "division by zero"},
+			want: &Cell{
+				Value:     "=a/0",
+				Result:    CellError{Code: "#DIV/0!", Message: "division by zero"},
+				Type:      "error",
+				Formatted: "#DIV/0!",
+			},
+		},
+		{
+			name: "circular reference",
+			args: args{inputValue: "=b+1", code: "#CIRCULAR!", description: "circular reference", cycle: []string{"a", "b", "a"}},
+			want: &Cell{
+				Value:     "=b+1",
+				Result:    CellError{Code: "#CIRCULAR!", Message: "circular reference"},
+				Type:      "error",
+				Formatted: "#CIRCULAR!",
+				Cycle:     []string{"a", "b", "a"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormulaErrorCell(tt.args.inputValue, tt.args.code, tt.args.description, tt.args.cycle); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FormulaErrorCell() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+// Explanation shows how the result of a cell is calculated: the formula tree with the value of every node,
+// the values of the referenced cells and where an error comes from.
+type Explanation struct {
+	Sheet      string          `json:"sheet"`
+	ID         string          `json:"id"`
+	Value      string          `json:"value"`
+	Result     any             `json:"result"`
+	Type       string          `json:"type"`
+	Formula    ExplainNode     `json:"formula"`
+	References []ExplainedCell `json:"references"`
+	Error      *ExplainError   `json:"error,omitempty"`
+}
+
+// ExplainNode is a node of a formula tree. Position is where the node starts in the value of the cell.
+// Nodes skipped by the evaluation, like the branch IF doesn't take, aren't Evaluated and have no result,
+// ranges and patterns passed to functions have no result of their own either.
+type ExplainNode struct {
+	Kind       string        `json:"kind"`
+	Expression string        `json:"expression"`
+	Position   int           `json:"position"`
+	Evaluated  bool          `json:"evaluated"`
+	Result     any           `json:"result,omitempty"`
+	Type       string        `json:"type,omitempty"`
+	Children   []ExplainNode `json:"children,omitempty"`
+}
+
+// ExplainedCell is a cell the formula references with its stored result, Missing cells don't exist.
+type ExplainedCell struct {
+	Sheet   string `json:"sheet"`
+	ID      string `json:"id"`
+	Result  any    `json:"result,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// ExplainError tells where an error originated: the innermost failing node of the formula,
+// and the cell the error comes from when it was raised by a referenced cell.
+type ExplainError struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Cell       string `json:"cell,omitempty"`
+	Expression string `json:"expression"`
+	Position   int    `json:"position"`
+}
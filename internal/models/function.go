@@ -0,0 +1,17 @@
+package models
+
+type FunctionArg struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+type Function struct {
+	Name        string        `json:"name"`
+	Signature   string        `json:"signature"`
+	Description string        `json:"description"`
+	Args        []FunctionArg `json:"args"`
+	Variadic    bool          `json:"variadic,omitempty"`
+	MinArgs     int           `json:"min_args"`
+	MaxArgs     int           `json:"max_args"`
+}
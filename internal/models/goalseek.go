@@ -0,0 +1,25 @@
+package models
+
+// GoalSeek asks for the value of the Changing cell making the Target cell result in Value.
+// Min and Max bound the search, both or neither are given. Zero Tolerance and MaxIterations take the defaults.
+type GoalSeek struct {
+	Target        string   `json:"target"`
+	Value         float64  `json:"value"`
+	Changing      string   `json:"changing"`
+	Min           *float64 `json:"min,omitempty"`
+	Max           *float64 `json:"max,omitempty"`
+	Tolerance     float64  `json:"tolerance,omitempty"`
+	MaxIterations int      `json:"max_iterations,omitempty"`
+	Commit        bool     `json:"commit,omitempty"`
+}
+
+// GoalSeekResult is the Input found for the changing cell and the Result of the target with it.
+// Committed tells whether the input was written to the sheet.
+type GoalSeekResult struct {
+	Target     string  `json:"target"`
+	Changing   string  `json:"changing"`
+	Input      float64 `json:"input"`
+	Result     float64 `json:"result"`
+	Iterations int     `json:"iterations"`
+	Committed  bool    `json:"committed"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CellLinks are the precedents or the dependents of a cell.
+type CellLinks struct {
+	Sheet string       `json:"sheet"`
+	ID    string       `json:"id"`
+	Cells []LinkedCell `json:"cells"`
+}
+
+// LinkedCell is a cell reached from another one, Depth is 1 for cells linked directly.
+// Missing cells are referenced by formulas without being created yet.
+type LinkedCell struct {
+	Sheet   string `json:"sheet"`
+	ID      string `json:"id"`
+	Depth   int    `json:"depth"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// SheetGraph is the dependency graph of a sheet. Nodes are referred to by Ref like "sheet1!a",
+// edges go from the cell used by a formula to the cell of the formula.
+type SheetGraph struct {
+	Sheet string      `json:"sheet"`
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a cell of the graph, cells of other sheets are included when they are linked to the sheet.
+type GraphNode struct {
+	Ref     string `json:"ref"`
+	Sheet   string `json:"sheet"`
+	ID      string `json:"id"`
+	Value   string `json:"value,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DOT renders the graph for GraphViz. Cells of other sheets are dashed and missing cells dotted,
+// a missing cell of another sheet is dotted.
+func (g *SheetGraph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(g.Sheet))
+	for _, node := range g.Nodes {
+		label := node.ID
+		var attrs []string
+		if node.Sheet != g.Sheet {
+			label = node.Ref
+		}
+		switch {
+		case node.Missing:
+			attrs = append(attrs, "style=dotted")
+		case node.Sheet != g.Sheet:
+			attrs = append(attrs, "style=dashed")
+		}
+		attrs = append([]string{"label=" + dotQuote(label)}, attrs...)
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(node.Ref), strings.Join(attrs, ", "))
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
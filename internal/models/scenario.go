@@ -0,0 +1,30 @@
+package models
+
+// Scenario is a set of cell overrides calculated without changing the sheet. Overrides map cell IDs, or
+// "sheet!cell" for cells of other sheets, to the values they take. Cells lists the cells of the sheet to report,
+// all of them when empty.
+type Scenario struct {
+	Name      string            `json:"name,omitempty"`
+	Overrides map[string]string `json:"overrides"`
+	Cells     []string          `json:"cells,omitempty"`
+}
+
+// ScenarioResult is a scenario with the reported cells as they are calculated with its overrides.
+type ScenarioResult struct {
+	Scenario
+	Results map[string]Cell `json:"results"`
+}
+
+// ScenarioComparison shows cells side by side, their stored results and their results in each of Scenarios.
+type ScenarioComparison struct {
+	Scenarios []string       `json:"scenarios"`
+	Cells     []ComparedCell `json:"cells"`
+}
+
+// ComparedCell is a cell of a comparison, Results are keyed by scenario name.
+// Actual is nil and results are left out where the cell doesn't exist.
+type ComparedCell struct {
+	ID      string          `json:"id"`
+	Actual  *Cell           `json:"actual"`
+	Results map[string]Cell `json:"results"`
+}
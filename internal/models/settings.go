@@ -0,0 +1,9 @@
+package models
+
+// SheetSettings configures how numbers of a sheet are calculated, fields left out of an update keep their value.
+type SheetSettings struct {
+	Mode        string `json:"mode,omitempty"`
+	Precision   *int   `json:"precision,omitempty"`
+	Rounding    string `json:"rounding,omitempty"`
+	MissingRefs string `json:"missing_refs,omitempty"`
+}
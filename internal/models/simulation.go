@@ -0,0 +1,50 @@
+package models
+
+// Simulation asks for Iterations evaluations of the sheet with the Inputs drawn from their distributions
+// and the statistics of the Outputs cells. A missing Seed is picked and returned, zero values take the defaults.
+type Simulation struct {
+	Inputs      []UncertainInput `json:"inputs"`
+	Outputs     []string         `json:"outputs"`
+	Iterations  int              `json:"iterations,omitempty"`
+	Seed        *int64           `json:"seed,omitempty"`
+	Bins        int              `json:"bins,omitempty"`
+	Percentiles []float64        `json:"percentiles,omitempty"`
+}
+
+// UncertainInput draws the value of Cell from a distribution: "normal" by Mean and StdDev, "uniform" by Min
+// and Max, "triangular" by Min, Mode and Max, "lognormal" by Mean and StdDev of the logarithm of the value.
+type UncertainInput struct {
+	Cell         string   `json:"cell"`
+	Distribution string   `json:"distribution"`
+	Mean         *float64 `json:"mean,omitempty"`
+	StdDev       *float64 `json:"stddev,omitempty"`
+	Min          *float64 `json:"min,omitempty"`
+	Max          *float64 `json:"max,omitempty"`
+	Mode         *float64 `json:"mode,omitempty"`
+}
+
+// SimulationResult holds the statistics of every output cell by its ID.
+type SimulationResult struct {
+	Iterations int                    `json:"iterations"`
+	Seed       int64                  `json:"seed"`
+	Outputs    map[string]OutputStats `json:"outputs"`
+}
+
+// OutputStats describes the results of a cell over the iterations where it was a number, Errors counts the others.
+// Percentiles are keyed like "p95".
+type OutputStats struct {
+	Mean        float64            `json:"mean"`
+	StdDev      float64            `json:"stddev"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Percentiles map[string]float64 `json:"percentiles"`
+	Histogram   []HistogramBin     `json:"histogram"`
+	Errors      int                `json:"errors"`
+}
+
+// HistogramBin counts the results from From up to To, the last bin includes To.
+type HistogramBin struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
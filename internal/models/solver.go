@@ -0,0 +1,49 @@
+package models
+
+// Solve asks for the values of the Variables cells that maximize or minimize, by Goal "max" or "min",
+// the Objective cell while every constraint holds. Method is "simplex", "nonlinear" or empty to pick by the model.
+// Zero Tolerance and MaxIterations take the defaults, Commit writes the found values.
+type Solve struct {
+	Objective     string             `json:"objective"`
+	Goal          string             `json:"goal"`
+	Variables     []SolverVariable   `json:"variables"`
+	Constraints   []SolverConstraint `json:"constraints,omitempty"`
+	Method        string             `json:"method,omitempty"`
+	Tolerance     float64            `json:"tolerance,omitempty"`
+	MaxIterations int                `json:"max_iterations,omitempty"`
+	Commit        bool               `json:"commit,omitempty"`
+}
+
+// SolverVariable is a decision cell with its optional bounds.
+type SolverVariable struct {
+	Cell string   `json:"cell"`
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+}
+
+// SolverConstraint requires the result of Cell to be Op ("<=", ">=" or "=") Value.
+type SolverConstraint struct {
+	Cell  string  `json:"cell"`
+	Op    string  `json:"op"`
+	Value float64 `json:"value"`
+}
+
+// SolveResult is the assignment found for the variables. Status is "optimal" for linear models solved
+// by simplex and "locally_optimal" for the nonlinear method, Iterations counts the evaluations of the sheet.
+type SolveResult struct {
+	Method      string             `json:"method"`
+	Status      string             `json:"status"`
+	Objective   float64            `json:"objective"`
+	Variables   map[string]float64 `json:"variables"`
+	Constraints []ConstraintResult `json:"constraints"`
+	Iterations  int                `json:"iterations"`
+	Committed   bool               `json:"committed"`
+}
+
+// ConstraintResult is a constraint with the Result of its cell at the solution. Slack is how far the result
+// is from the bound, positive while inside it.
+type ConstraintResult struct {
+	SolverConstraint
+	Result float64 `json:"result"`
+	Slack  float64 `json:"slack"`
+}
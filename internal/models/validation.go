@@ -0,0 +1,21 @@
+package models
+
+// Validation is the outcome of a dry-run write. Valid tells whether the write would be accepted, Cell is the
+// cell as it would be stored with the cells it would recalculate in Dependents. Errors lists what is wrong
+// with the formula, including errors a valid write stores as the result like #REF! for cells not created yet.
+type Validation struct {
+	Valid  bool              `json:"valid"`
+	Cell   *Cell             `json:"cell,omitempty"`
+	Errors []ValidationError `json:"errors,omitempty"`
+}
+
+// ValidationError is an error of a validated formula. Position is where it was raised in the value,
+// it is left out when the error isn't tied to a place in the formula. Cell is the cell an error comes from
+// when another cell raised it.
+type ValidationError struct {
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	Cell     string   `json:"cell,omitempty"`
+	Position int      `json:"position,omitempty"`
+	Cycle    []string `json:"cycle,omitempty"`
+}
@@ -21,27 +21,39 @@ import (
 )
 
 type Server struct {
-	conn    *sql.DB
-	cfg     *config.Config
-	log     logrus.FieldLogger
-	storage db.Storage
+	conn      *sql.DB
+	cfg       *config.Config
+	log       logrus.FieldLogger
+	storage   db.Storage
+	functions *services.FunctionRegistry
 }
 
 func NewServer(conn *sql.DB, cfg *config.Config) (*Server, error) {
 	s := &Server{
-		conn:    conn,
-		cfg:     cfg,
-		storage: db.NewStorage(conn),
-		log:     logrus.New(),
+		conn:      conn,
+		cfg:       cfg,
+		storage:   db.NewStorage(conn),
+		log:       logrus.New(),
+		functions: services.NewDefaultFunctionRegistry(),
 	}
 
 	return s, nil
 }
 
+// Functions returns the registry used by formulas, custom functions must be registered before Run.
+func (s *Server) Functions() *services.FunctionRegistry {
+	return s.functions
+}
+
 func (s *Server) Run() {
+	service := services.NewExcelLikeService(s.storage, s.functions)
+	if err := service.LoadDependencyGraph(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
 	router := chi.NewRouter()
 
-	s.setupHandlers(router)
+	s.setupHandlers(router, service)
 
 	srv := http.Server{
 		Handler:      router,
@@ -78,12 +90,11 @@ func (s *Server) Run() {
 	logrus.Exit(0)
 }
 
-func (s *Server) setupHandlers(router chi.Router) {
-	router.Route("/api/v1", func(r chi.Router) {
-		handler := handlers.ExcelLikeHandler{
-			ELS: services.NewExcelLikeService(s.storage),
-			Log: s.log,
-		}
-		handler.RegisterRoutes(r)
-	})
+func (s *Server) setupHandlers(router chi.Router, service services.ExcelLikeService) {
+	handler := handlers.ExcelLikeHandler{
+		ELS: service,
+		Log: s.log,
+	}
+	router.Route("/api/v1", handler.RegisterRoutes)
+	router.Route("/api/v2", handler.RegisterRoutesV2)
 }
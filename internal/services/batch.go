@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+// ErrInvalidBatch is returned for batches that can't be written at all, like an empty one.
+var ErrInvalidBatch = errors.New("invalid batch")
+
+// BatchError rejects a whole batch write, Errors holds the reason of every rejected cell by its ID.
+type BatchError struct {
+	Errors map[string]models.ValidationError
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch rejected: %d of the cells can't be written", len(e.Errors))
+}
+
+// AddCellInputsTX writes several cells of a sheet in one transaction, all of them or none. Cells of the batch
+// may reference each other in any order, every cell depending on the batch is recalculated once.
+func (s *excelLikeService) AddCellInputsTX(ctx context.Context, sheetID string, inputs map[string]models.Data) (map[string]models.Data, error) {
+	cells := make(map[string]models.CellInput, len(inputs))
+	for cellID, input := range inputs {
+		cells[cellID] = models.CellInput{Value: input.Value}
+	}
+	var res map[string]models.Data
+	err := s.inTransaction(ctx, func(tx *sql.Tx) (err error) {
+		res, err = s.addCells(ctx, tx, sheetID, cells)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// AddCellsTX is AddCellInputsTX of the v2 API, it also changes the formats of cells given one.
+func (s *excelLikeService) AddCellsTX(ctx context.Context, sheetID string, inputs map[string]models.CellInput) (map[string]models.Cell, error) {
+	var res map[string]models.Cell
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		written, err := s.addCells(ctx, tx, sheetID, inputs)
+		if err != nil {
+			return err
+		}
+		res = make(map[string]models.Cell, len(written))
+		for cellID := range written {
+			stored, err := s.storage.GetInput(ctx, tx, sheetID, cellID)
+			if err != nil {
+				return err
+			}
+			if stored == nil {
+				return fmt.Errorf("cell %s!%s not stored", sheetID, cellID)
+			}
+			res[cellID] = cellResponse(*stored)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// addCells writes the cells within tx. They are put into the dependency graph first, so cycles and the order
+// to calculate them in take the whole batch into account. A BatchError lists every cell rejected, the caller
+// has to roll tx back then.
+func (s *excelLikeService) addCells(ctx context.Context, tx *sql.Tx, sheetID string, inputs map[string]models.CellInput) (map[string]models.Data, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: cells are required", ErrInvalidBatch)
+	}
+	cellIDs := make([]string, 0, len(inputs))
+	byID := make(map[string]models.CellInput, len(inputs))
+	for cellID, input := range inputs {
+		lower := strings.ToLower(cellID)
+		if _, ok := byID[lower]; ok {
+			return nil, fmt.Errorf("%w: cell %s is repeated", ErrInvalidBatch, lower)
+		}
+		cellIDs, byID[lower] = append(cellIDs, lower), input
+	}
+	sort.Strings(cellIDs)
+
+	if err := s.loadGraph(ctx, tx); err != nil {
+		return nil, err
+	}
+	rejected := make(map[string]models.ValidationError)
+	formulas := make(map[db.CellRef]node, len(cellIDs))
+	batch := make([]db.CellRef, 0, len(cellIDs))
+	for _, cellID := range cellIDs {
+		input := byID[cellID]
+		if input.Format != nil {
+			if err := validateFormat(*input.Format); err != nil {
+				rejected[cellID] = models.ValidationError{Code: string(ErrValue), Message: err.Error()}
+				continue
+			}
+		}
+		if !isValid(input.Value) {
+			rejected[cellID] = models.ValidationError{Code: string(ErrName), Message: "input value is not correct"}
+			continue
+		}
+		value := normalizeValue(input.Value)
+		formula, err := parseFormula(value)
+		if err != nil {
+			validationErr := models.ValidationError{Code: string(ErrName), Message: err.Error()}
+			var syntaxErr *SyntaxError
+			if errors.As(err, &syntaxErr) {
+				validationErr.Position = syntaxErr.Position
+			}
+			rejected[cellID] = validationErr
+			continue
+		}
+		cell := db.CellRef{SheetID: sheetID, CellID: cellID}
+		s.graph.set(cell, value, extractDependencies(formula, sheetID))
+		formulas[cell] = formula
+		batch = append(batch, cell)
+	}
+	for cellID, cycle := range s.batchCycles(batch) {
+		rejected[cellID] = models.ValidationError{Code: string(ErrCircular), Message: (&CircularReferenceError{Path: cycle}).Error(), Cycle: cycle}
+	}
+	if len(rejected) > 0 {
+		return nil, &BatchError{Errors: rejected}
+	}
+
+	calc := newCalculation()
+	res := make(map[string]models.Data, len(batch))
+	for _, cell := range s.graph.recalculationOrder(batch) {
+		formula, written := formulas[cell]
+		if !written {
+			// a dependent of the batch
+			if err := s.refresh(ctx, tx, calc, cell); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		value, _ := s.graph.value(cell)
+		input, err := s.calculate(ctx, tx, calc, cell, value, formula, false)
+		var formulaErr *FormulaError
+		if errors.As(err, &formulaErr) {
+			validationErr, evalErr := s.evaluationError(ctx, tx, cell, value)
+			if evalErr != nil {
+				return nil, evalErr
+			}
+			if validationErr == nil {
+				validationErr = &models.ValidationError{Code: string(formulaErr.Code), Message: formulaErr.Message, Cell: formulaErr.Cell}
+			}
+			rejected[cell.CellID] = *validationErr
+			// cells of the batch using this one are checked with its error
+			input, err = s.calculate(ctx, tx, calc, cell, value, formula, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		resp, _, err := s.storage.AddCellInput(ctx, tx, input)
+		if err != nil {
+			return nil, err
+		}
+		if format := byID[cell.CellID].Format; format != nil {
+			if err := s.storage.SetCellFormat(ctx, tx, sheetID, cell.CellID, *format); err != nil {
+				return nil, err
+			}
+		}
+		res[cell.CellID] = *resp
+	}
+	if len(rejected) > 0 {
+		return nil, &BatchError{Errors: rejected}
+	}
+	return res, nil
+}
+
+// batchCycles finds the cells of the batch, already put into the graph, whose formulas close a loop,
+// with the loop starting at each of them.
+func (s *excelLikeService) batchCycles(batch []db.CellRef) map[string][]string {
+	precedents := make(map[db.CellRef][]db.CellRef)
+	queue := append([]db.CellRef(nil), batch...)
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		if _, ok := precedents[cell]; ok {
+			continue
+		}
+		precedents[cell] = s.graph.directPrecedents(cell)
+		queue = append(queue, precedents[cell]...)
+	}
+	res := make(map[string][]string)
+	for _, cell := range batch {
+		path := findCycle(precedents, cell, precedents[cell])
+		if path == nil {
+			continue
+		}
+		formatted := make([]string, len(path))
+		for i, ref := range path {
+			formatted[i] = formatRef(ref, cell.SheetID)
+		}
+		res[cell.CellID] = formatted
+	}
+	return res
+}
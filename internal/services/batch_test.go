@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+	"dev-challenge/internal/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcelLikeService_addCells(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+	storage.EXPECT().GetSheetSettings(gomock.Any(), tx, gomock.Any()).DoAndReturn(defaultSettings).AnyTimes()
+	graph := []db.Input{{SheetID: "sheet1", CellID: "d1", Value: "=b1*2", UsedParams: sheet1Cells("b1")}}
+	stored := func() {
+		storage.EXPECT().AddCellInput(gomock.Any(), tx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ *sql.Tx, input db.Input) (*models.Data, bool, error) {
+				return &models.Data{Value: input.Value, Result: fmt.Sprintf("%f", input.Result), Type: input.ResultType}, false, nil
+			}).AnyTimes()
+	}
+	format := "0.0.0"
+
+	tests := []struct {
+		name          string
+		inputs        map[string]models.CellInput
+		mockBehavior  func()
+		expected      map[string]models.Data
+		expectedError error
+	}{
+		{
+			name: "Cells referencing each other",
+			inputs: map[string]models.CellInput{
+				"b1": {Value: "=a1+c1"},
+				"A1": {Value: "1"},
+				"c1": {Value: "2"},
+			},
+			mockBehavior: func() {
+				stored()
+				storage.EXPECT().SaveCellResult(gomock.Any(), tx, gomock.Any()).DoAndReturn(func(_ context.Context, _ *sql.Tx, input db.Input) error {
+					assert.Equal(t, "d1", input.CellID)
+					assert.Equal(t, float64(6), input.Result)
+					return nil
+				})
+			},
+			expected: map[string]models.Data{
+				"a1": {Value: "1", Result: "1.000000", Type: "number"},
+				"b1": {Value: "=a1+c1", Result: "3.000000", Type: "number"},
+				"c1": {Value: "2", Result: "2.000000", Type: "number"},
+			},
+		},
+		{
+			name: "Cycle and syntax error",
+			inputs: map[string]models.CellInput{
+				"a1": {Value: "=b2"},
+				"b2": {Value: "=a1"},
+				"y":  {Value: "=1+"},
+				"z":  {Value: "5", Format: &format},
+			},
+			mockBehavior: func() {},
+			expectedError: &BatchError{Errors: map[string]models.ValidationError{
+				"a1": {Code: "#CIRCULAR!", Message: "circular reference: a1 -> b2 -> a1", Cycle: []string{"a1", "b2", "a1"}},
+				"b2": {Code: "#CIRCULAR!", Message: "circular reference: b2 -> a1 -> b2", Cycle: []string{"b2", "a1", "b2"}},
+				"y":  {Code: "#NAME?", Message: "unexpected end of formula", Position: 3},
+				"z":  {Code: "#VALUE!", Message: "invalid format \"0.0.0\""},
+			}},
+		},
+		{
+			name: "Formula error",
+			inputs: map[string]models.CellInput{
+				"x": {Value: "=1/0"},
+				"k": {Value: "3"},
+			},
+			mockBehavior: stored,
+			expectedError: &BatchError{Errors: map[string]models.ValidationError{
+				"x": {Code: "#DIV/0!", Message: "division by zero", Position: 2},
+			}},
+		},
+		{
+			name:          "Empty",
+			inputs:        map[string]models.CellInput{},
+			mockBehavior:  func() {},
+			expectedError: fmt.Errorf("%w: cells are required", ErrInvalidBatch),
+		},
+		{
+			name:          "Repeated cell",
+			inputs:        map[string]models.CellInput{"a1": {Value: "1"}, "A1": {Value: "2"}},
+			mockBehavior:  func() {},
+			expectedError: fmt.Errorf("%w: cell a1 is repeated", ErrInvalidBatch),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &excelLikeService{storage: storage, functions: NewDefaultFunctionRegistry(), graph: graphOf(graph...)}
+			tt.mockBehavior()
+			got, err := s.addCells(context.TODO(), tx, "sheet1", tt.inputs)
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
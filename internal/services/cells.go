@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+// ErrInvalidFormat is returned for display masks numbers can't be formatted with.
+var ErrInvalidFormat = errors.New("invalid format")
+
+func (s *excelLikeService) GetCell(ctx context.Context, sheetID, cellID string) (*models.Cell, error) {
+	input, err := s.storage.GetInput(ctx, nil, sheetID, cellID)
+	if err != nil || input == nil {
+		return nil, err
+	}
+	cell := cellResponse(*input)
+	return &cell, nil
+}
+
+func (s *excelLikeService) GetSheetCells(ctx context.Context, sheetID string) (map[string]models.Cell, error) {
+	inputs, err := s.storage.GetSheetInputs(ctx, sheetID)
+	if err != nil || len(inputs) == 0 {
+		return nil, err
+	}
+	res := make(map[string]models.Cell, len(inputs))
+	for cellID, input := range inputs {
+		res[cellID] = cellResponse(input)
+	}
+	return res, nil
+}
+
+// AddCellTX is AddCellInputTX of the v2 API, it also changes the format of the cell when one is given.
+// withDependents adds the cells recalculated by the write to the response, in the order they were recalculated.
+func (s *excelLikeService) AddCellTX(ctx context.Context, sheetID, cellID string, inputData *models.CellInput, withDependents bool) (*models.Cell, error) {
+	var cell *models.Cell
+	err := s.inTransaction(ctx, func(tx *sql.Tx) (err error) {
+		cell, err = s.addCell(ctx, tx, sheetID, cellID, inputData, withDependents)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cell, nil
+}
+
+// addCell is AddCellTX within tx.
+func (s *excelLikeService) addCell(ctx context.Context, tx *sql.Tx, sheetID, cellID string, inputData *models.CellInput, withDependents bool) (*models.Cell, error) {
+	if inputData.Format != nil {
+		if err := validateFormat(*inputData.Format); err != nil {
+			return nil, err
+		}
+	}
+
+	calc := newCalculation()
+	if _, err := s.addCellInput(ctx, tx, calc, sheetID, cellID, &models.Data{Value: inputData.Value}); err != nil {
+		return nil, err
+	}
+	if inputData.Format != nil {
+		if err := s.storage.SetCellFormat(ctx, tx, sheetID, cellID, *inputData.Format); err != nil {
+			return nil, err
+		}
+	}
+	stored, err := s.storage.GetInput(ctx, tx, sheetID, cellID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("cell %s!%s not stored", sheetID, cellID)
+	}
+
+	cell := cellResponse(*stored)
+	if withDependents {
+		if cell.Dependents, err = s.recalculatedCells(ctx, tx, calc.recalculated); err != nil {
+			return nil, err
+		}
+	}
+	return &cell, nil
+}
+
+// recalculatedCells reads the recalculated cells back with their formats.
+func (s *excelLikeService) recalculatedCells(ctx context.Context, tx *sql.Tx, cells []db.CellRef) ([]models.RecalculatedCell, error) {
+	res := make([]models.RecalculatedCell, 0, len(cells))
+	for _, ref := range cells {
+		input, err := s.storage.GetInput(ctx, tx, ref.SheetID, ref.CellID)
+		if err != nil {
+			return nil, err
+		}
+		if input == nil {
+			return nil, fmt.Errorf("cell %s not stored", ref)
+		}
+		res = append(res, models.RecalculatedCell{Sheet: ref.SheetID, ID: ref.CellID, Cell: cellResponse(*input)})
+	}
+	return res, nil
+}
+
+// validateFormat accepts masks formatNumber understands, an empty mask removes the format of a cell.
+func validateFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	if _, err := formatNumber(0, format); err != nil {
+		return fmt.Errorf("%w %q", ErrInvalidFormat, format)
+	}
+	return nil
+}
+
+// cellResponse renders a stored cell with a typed result. Numbers are formatted by the mask of the cell,
+// exact numbers of sheets in decimal mode keep their digits.
+func cellResponse(input db.Input) models.Cell {
+	value := inputValue(db.CellRef{SheetID: input.SheetID, CellID: input.CellID}, input)
+	if value.Type == TypeError {
+		// the message names the cell the error came from, not the cell itself
+		value.Err.Cell = input.ErrorCell
+	}
+	cell := models.Cell{Value: input.Value, Result: typedResult(value), Type: string(value.Type), Format: input.Format, Formatted: value.AsText()}
+	if value.Type != TypeNumber {
+		return cell
+	}
+	if value.Dec != nil {
+		// the stored text is rounded to the precision of the sheet
+		cell.Result, cell.Formatted = json.Number(input.ResultText), input.ResultText
+	}
+	if input.Format != "" {
+		if formatted, err := formatNumber(value.Number, input.Format); err == nil {
+			cell.Formatted = formatted
+		}
+	}
+	return cell
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+	"dev-challenge/internal/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_cellResponse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input db.Input
+		want  models.Cell
+	}{
+		{
+			name:  "Number",
+			input: db.Input{SheetID: "sheet1", CellID: "a", Value: "=1+1", Result: 2, ResultType: "number"},
+			want:  models.Cell{Value: "=1+1", Result: float64(2), Type: "number", Formatted: "2"},
+		},
+		{
+			name:  "Formatted number",
+			input: db.Input{SheetID: "sheet1", CellID: "a", Value: "1234.5", Result: 1234.5, ResultType: "number", Format: "$#,##0.00"},
+			want:  models.Cell{Value: "1234.5", Result: 1234.5, Type: "number", Format: "$#,##0.00", Formatted: "$1,234.50"},
+		},
+		{
+			name:  "Decimal number",
+			input: db.Input{SheetID: "sheet1", CellID: "a", Value: "=0.1+0.2", Result: 0.3, ResultType: "number", ResultText: "0.30"},
+			want:  models.Cell{Value: "=0.1+0.2", Result: json.Number("0.30"), Type: "number", Formatted: "0.30"},
+		},
+		{
+			name:  "Infinite number",
+			input: db.Input{SheetID: "sheet1", CellID: "a", Value: "=1e308*10", Result: math.Inf(1), ResultType: "number"},
+			want:  models.Cell{Value: "=1e308*10", Result: "+Inf", Type: "number", Formatted: "+Inf"},
+		},
+		{
+			name:  "Text ignores format",
+			input: db.Input{SheetID: "sheet1", CellID: "a", Value: "Acme", ResultType: "text", ResultText: "Acme", Format: "0.00"},
+			want:  models.Cell{Value: "Acme", Result: "Acme", Type: "text", Format: "0.00", Formatted: "Acme"},
+		},
+		{
+			name:  "Boolean",
+			input: db.Input{SheetID: "sheet1", CellID: "a", Value: "=1<2", Result: 1, ResultType: "boolean", ResultText: "TRUE"},
+			want:  models.Cell{Value: "=1<2", Result: true, Type: "boolean", Formatted: "TRUE"},
+		},
+		{
+			name: "Error",
+			input: db.Input{SheetID: "sheet1", CellID: "d", Value: "=c+1", ResultType: "error", ResultText: "#DIV/0!",
+				ResultError: "division by zero", ErrorCell: "sheet1!c"},
+			want: models.Cell{Value: "=c+1", Result: models.CellError{Code: "#DIV/0!", Message: "sheet1!c: division by zero"},
+				Type: "error", Formatted: "#DIV/0!"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cellResponse(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cellResponse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{format: ""},
+		{format: "0.00"},
+		{format: "0.00E+00"},
+		{format: "0%"},
+		{format: "dd.mm.yyyy", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if err := validateFormat(tt.format); (err != nil) != tt.wantErr {
+				t.Errorf("validateFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExcelLikeService_recalculatedCells(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+	s := &excelLikeService{
+		storage:   storage,
+		functions: NewDefaultFunctionRegistry(),
+		graph: graphOf(
+			db.Input{SheetID: "sheet1", CellID: "tax", Value: "=rate*10", UsedParams: []db.CellRef{{SheetID: "sheet1", CellID: "rate"}}},
+			db.Input{SheetID: "report", CellID: "total", Value: "=sheet1!tax+1", UsedParams: []db.CellRef{{SheetID: "sheet1", CellID: "tax"}}},
+		),
+	}
+
+	storage.EXPECT().GetSheetSettings(gomock.Any(), tx, gomock.Any()).DoAndReturn(defaultSettings).AnyTimes()
+	storage.EXPECT().AddCellInput(gomock.Any(), tx, gomock.Any()).Return(&models.Data{}, false, nil)
+	storage.EXPECT().SaveCellResult(gomock.Any(), tx, gomock.Any()).Return(nil).Times(2)
+	calc := newCalculation()
+	_, err := s.addCellInput(context.TODO(), tx, calc, "sheet1", "rate", &models.Data{Value: "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []db.CellRef{{SheetID: "sheet1", CellID: "tax"}, {SheetID: "report", CellID: "total"}}, calc.recalculated)
+
+	storage.EXPECT().GetInput(gomock.Any(), tx, "sheet1", "tax").Return(&db.Input{SheetID: "sheet1", CellID: "tax", Value: "=rate*10", Result: 20, ResultType: "number"}, nil)
+	storage.EXPECT().GetInput(gomock.Any(), tx, "report", "total").Return(&db.Input{SheetID: "report", CellID: "total", Value: "=sheet1!tax+1", Result: 21, ResultType: "number", Format: "0.0"}, nil)
+	cells, err := s.recalculatedCells(context.TODO(), tx, calc.recalculated)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RecalculatedCell{
+		{Sheet: "sheet1", ID: "tax", Cell: models.Cell{Value: "=rate*10", Result: 20.0, Type: "number", Formatted: "20"}},
+		{Sheet: "report", ID: "total", Cell: models.Cell{Value: "=sheet1!tax+1", Result: 21.0, Type: "number", Format: "0.0", Formatted: "21.0"}},
+	}, cells)
+}
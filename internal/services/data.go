@@ -6,8 +6,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
-	"strings"
+	"sort"
+	"sync"
 
 	"dev-challenge/db"
 	"dev-challenge/internal/models"
@@ -16,17 +16,52 @@ import (
 type ExcelLikeService interface {
 	GetCellInput(ctx context.Context, sheetID, cellID string) (*models.Data, error)
 	AddCellInputTX(ctx context.Context, sheetID, cellID string, inputData *models.Data) (*models.Data, error)
-	AddCellInput(ctx context.Context, tx *sql.Tx, sheetID, cellID string, inputData *models.Data) (*models.Data, error)
 	GetSheetInput(ctx context.Context, sheetID string) (map[string]models.Data, error)
+	GetFunctions(ctx context.Context) []models.Function
+	GetCell(ctx context.Context, sheetID, cellID string) (*models.Cell, error)
+	GetSheetCells(ctx context.Context, sheetID string) (map[string]models.Cell, error)
+	AddCellTX(ctx context.Context, sheetID, cellID string, inputData *models.CellInput, withDependents bool) (*models.Cell, error)
+	AddCellInputsTX(ctx context.Context, sheetID string, inputs map[string]models.Data) (map[string]models.Data, error)
+	AddCellsTX(ctx context.Context, sheetID string, inputs map[string]models.CellInput) (map[string]models.Cell, error)
+	DeleteCellTX(ctx context.Context, sheetID, cellID string, policy DeletePolicy) (*models.Deletion, error)
+	DeleteSheetTX(ctx context.Context, sheetID string, policy DeletePolicy) (*models.Deletion, error)
+	GetSheetSettings(ctx context.Context, sheetID string) (*models.SheetSettings, error)
+	UpdateSheetSettingsTX(ctx context.Context, sheetID string, settings *models.SheetSettings) (*models.SheetSettings, error)
+	LoadDependencyGraph(ctx context.Context) error
+	GetPrecedents(ctx context.Context, sheetID, cellID string, depth int) (*models.CellLinks, error)
+	GetDependents(ctx context.Context, sheetID, cellID string, depth int) (*models.CellLinks, error)
+	GetSheetGraph(ctx context.Context, sheetID string) (*models.SheetGraph, error)
+	ExplainCell(ctx context.Context, sheetID, cellID string) (*models.Explanation, error)
+	ValidateCellTX(ctx context.Context, sheetID, cellID string, inputData *models.CellInput) (*models.Validation, error)
+	EvaluateScenario(ctx context.Context, sheetID string, scenario *models.Scenario) (*models.ScenarioResult, error)
+	SaveScenarioTX(ctx context.Context, sheetID, name string, scenario *models.Scenario) (*models.ScenarioResult, error)
+	GetScenarios(ctx context.Context, sheetID string) ([]models.Scenario, error)
+	GetScenario(ctx context.Context, sheetID, name string) (*models.ScenarioResult, error)
+	DeleteScenarioTX(ctx context.Context, sheetID, name string) (bool, error)
+	CompareScenarios(ctx context.Context, sheetID string, names, cells []string) (*models.ScenarioComparison, error)
+	GoalSeekTX(ctx context.Context, sheetID string, request *models.GoalSeek) (*models.GoalSeekResult, error)
+	SolveTX(ctx context.Context, sheetID string, request *models.Solve) (*models.SolveResult, error)
+	Simulate(ctx context.Context, sheetID string, request *models.Simulation) (*models.SimulationResult, error)
 }
 
 type excelLikeService struct {
-	storage db.Storage
+	storage   db.Storage
+	functions *FunctionRegistry
+	graph     *dependencyGraph
+	// mu serializes writes, they share the dependency graph, graph queries read it under RLock
+	mu sync.RWMutex
 }
 
-func NewExcelLikeService(storage db.Storage) ExcelLikeService {
+// NewExcelLikeService creates the service, formulas may call any function of the registry.
+// A nil registry stands for NewDefaultFunctionRegistry.
+func NewExcelLikeService(storage db.Storage, functions *FunctionRegistry) ExcelLikeService {
+	if functions == nil {
+		functions = NewDefaultFunctionRegistry()
+	}
 	return &excelLikeService{
-		storage: storage,
+		storage:   storage,
+		functions: functions,
+		graph:     newDependencyGraph(),
 	}
 }
 
@@ -35,110 +70,383 @@ func (s *excelLikeService) GetCellInput(ctx context.Context, sheetID, cellID str
 }
 
 func (s *excelLikeService) AddCellInputTX(ctx context.Context, sheetID, cellID string, inputData *models.Data) (*models.Data, error) {
-	// Start a transaction
-	tx, err := s.storage.BeginTransaction(ctx)
+	var resp *models.Data
+	err := s.inTransaction(ctx, func(tx *sql.Tx) (err error) {
+		resp, err = s.addCellInput(ctx, tx, newCalculation(), sheetID, cellID, inputData)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback() // Rollback if there's an error
-		}
-	}()
+	return resp, nil
+}
 
-	resp, err := s.AddCellInput(ctx, tx, sheetID, cellID, inputData)
+// addCellInput writes a cell within tx, the cells recalculated by the write are left in calc.
+// It journals the graph changes, so it must run inside inTransaction.
+func (s *excelLikeService) addCellInput(ctx context.Context, tx *sql.Tx, calc *calculation, sheetID, cellID string, inputData *models.Data) (*models.Data, error) {
+	value, formula, err := parseInput(inputData.Value)
 	if err != nil {
 		return nil, err
 	}
 
-	// Commit the transaction
-	if txErr := tx.Commit(); txErr != nil {
-		return nil, txErr
+	if err := s.loadGraph(ctx, tx); err != nil {
+		return nil, err
 	}
-
-	return resp, nil
+	cell := db.CellRef{SheetID: sheetID, CellID: cellID}
+	if err := s.checkCircularReference(cell, extractDependencies(formula, sheetID)); err != nil {
+		return nil, err
+	}
+	return s.saveCell(ctx, tx, calc, cell, value, formula)
 }
 
-func (s *excelLikeService) AddCellInput(ctx context.Context, tx *sql.Tx, sheetID, cellID string, inputData *models.Data) (*models.Data, error) {
-	if !isValid(inputData.Value) {
-		return nil, errors.New("input value is not correct")
+// parseInput checks a written value and parses it, the normalized value is what gets stored.
+func parseInput(input string) (string, node, error) {
+	if !isValid(input) {
+		return "", nil, newFormulaError(ErrName, "input value is not correct")
 	}
-	value := strings.ToLower(inputData.Value)
+	value := normalizeValue(input)
 
-	var m map[string]string
-	cellsToGet := extractParams(value)
-	if len(cellsToGet) > 0 {
-		if contains(cellsToGet, cellID) {
-			return nil, errors.New("cell can't link to itself")
-		}
-		paramsToValues, err := s.storage.GetCellInputBatch(ctx, tx, sheetID, cellsToGet)
-		if err != nil {
-			return nil, err
+	formula, err := parseFormula(value)
+	if err != nil {
+		formulaErr := &FormulaError{Code: ErrName, Message: err.Error()}
+		var syntaxErr *SyntaxError
+		if errors.As(err, &syntaxErr) {
+			formulaErr.Position = syntaxErr.Position
 		}
-		m = paramsToValues
+		return "", nil, formulaErr
 	}
+	return value, formula, nil
+}
+
+// inTransaction runs fn in a transaction, one write at a time. The dependency graph keeps the changes
+// made by fn only when the transaction is committed.
+func (s *excelLikeService) inTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	var newExpression string
-	if m != nil {
-		newExpression = replaceParamsWithValues(value, m)
+	tx, err := s.storage.BeginTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
 	} else {
-		newExpression = value
+		err = tx.Commit()
 	}
+	if err != nil {
+		s.graph.rollback()
+		return err
+	}
+	s.graph.commit()
+	return nil
+}
+
+// LoadDependencyGraph reads the dependencies of all cells into memory, so the first write doesn't have to.
+func (s *excelLikeService) LoadDependencyGraph(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	result, err := calculate(newExpression)
+	s.graph.reset()
+	return s.loadGraph(ctx, nil)
+}
+
+// loadGraph reads the dependency graph from storage unless it is already in memory.
+func (s *excelLikeService) loadGraph(ctx context.Context, tx *sql.Tx) error {
+	if s.graph.loaded {
+		return nil
+	}
+	inputs, err := s.storage.GetAllInputs(ctx, tx)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	deleted, err := s.storage.GetDeletedCells(ctx, tx)
+	if err != nil {
+		return err
 	}
+	s.graph.load(inputs, deleted)
+	return nil
+}
 
-	input := db.Input{
-		SheetID:    sheetID,
-		CellID:     cellID,
-		Value:      value,
-		Result:     result,
-		UsedParams: cellsToGet,
+// saveCell stores an already validated formula with its result and recalculates the cells depending on it.
+func (s *excelLikeService) saveCell(ctx context.Context, tx *sql.Tx, calc *calculation, cell db.CellRef, value string, formula node) (*models.Data, error) {
+	input, err := s.calculate(ctx, tx, calc, cell, value, formula, false)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, wasUpdated, err := s.storage.AddCellInput(ctx, tx, input)
+	resp, _, err := s.storage.AddCellInput(ctx, tx, input)
 	if err != nil {
 		return nil, err
 	}
+	s.graph.set(cell, value, db.Dependencies{Cells: input.UsedParams, Ranges: input.UsedRanges, Patterns: input.UsedPatterns})
+
+	if err := s.recalculate(ctx, tx, calc, s.graph.directDependents(cell)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
 
-	if wasUpdated {
-		if dependentErr := s.updateDependentCells(ctx, tx, input.CellID); dependentErr != nil {
-			return nil, dependentErr
+// recalculate refreshes the results of cells and of all cells depending on them. Every cell is calculated once,
+// after the cells it uses, formulas failing there store the error as their result.
+func (s *excelLikeService) recalculate(ctx context.Context, tx *sql.Tx, calc *calculation, cells []db.CellRef) error {
+	for _, cell := range s.graph.recalculationOrder(cells) {
+		if err := s.refresh(ctx, tx, calc, cell); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return resp, nil
+// refresh calculates the stored formula of cell again and saves its result.
+func (s *excelLikeService) refresh(ctx context.Context, tx *sql.Tx, calc *calculation, cell db.CellRef) error {
+	value, _ := s.graph.value(cell)
+	// the stored formula was validated when written, so only its result has to be refreshed
+	formula, err := parseFormula(value)
+	if err != nil {
+		return err
+	}
+	input, err := s.calculate(ctx, tx, calc, cell, value, formula, true)
+	if err != nil {
+		return err
+	}
+	if err := s.storage.SaveCellResult(ctx, tx, input); err != nil {
+		return err
+	}
+	calc.recalculated = append(calc.recalculated, cell)
+	return nil
 }
 
-func (s *excelLikeService) GetSheetInput(ctx context.Context, sheetID string) (map[string]models.Data, error) {
-	return s.storage.GetSheetInput(ctx, sheetID)
+// calculation is shared by the cells calculated for one write: settings of their sheets are read once
+// and results calculated so far aren't read back from storage. recalculated lists the cells refreshed
+// by recalculate in their order.
+type calculation struct {
+	settings     map[string]db.SheetSettings
+	results      map[db.CellRef]db.Input
+	recalculated []db.CellRef
 }
 
-func (s *excelLikeService) updateDependentCells(ctx context.Context, tx *sql.Tx, cellID string) error {
-	// 1) select distinct ID
-	// 2) select all inputs by ID
-	// 3) in cyclo for all inputs start AddCellInput
+func newCalculation() *calculation {
+	return &calculation{
+		settings: make(map[string]db.SheetSettings),
+		results:  make(map[db.CellRef]db.Input),
+	}
+}
 
-	needToBeChanged, err := s.storage.GetIDList(ctx, tx, cellID)
+// calculate evaluates a formula of cell. A formula failing with a FormulaError is rejected, unless keepErrors
+// is set: dependent cells can't be rejected, so they store the error as their result instead. Formulas
+// referencing cells that don't exist yet are never rejected, they store #REF! until the cells are created.
+// Results of sheets in decimal mode are rounded to the precision of the sheet.
+func (s *excelLikeService) calculate(ctx context.Context, tx *sql.Tx, calc *calculation, cell db.CellRef, value string, formula node, keepErrors bool) (db.Input, error) {
+	deps := extractDependencies(formula, cell.SheetID)
+	e, settings, err := s.newEvaluator(ctx, tx, calc, cell, deps)
 	if err != nil {
-		return err
+		return db.Input{}, err
 	}
+	return evaluate(calc, e, settings, cell, value, formula, deps, keepErrors)
+}
 
-	allInputs, err := s.storage.GetInputBatchByIDs(ctx, tx, needToBeChanged)
+// evaluate is calculate with the evaluator of the formula prepared, it doesn't touch storage.
+func evaluate(calc *calculation, e *evaluator, settings db.SheetSettings, cell db.CellRef, value string, formula node, deps db.Dependencies, keepErrors bool) (db.Input, error) {
+	result, err := e.eval(formula)
 	if err != nil {
-		return err
+		formulaErr := asFormulaError(err)
+		if !keepErrors && formulaErr.Code != ErrRef {
+			return db.Input{}, formulaErr
+		}
+		result = Value{Type: TypeError, Err: formulaErr}
+	}
+	if result.Dec != nil {
+		result = DecimalValue(roundDecimal(result.Dec, settings.Precision, RoundingMode(settings.Rounding)))
+	}
+
+	input := db.Input{
+		SheetID:      cell.SheetID,
+		CellID:       cell.CellID,
+		Value:        value,
+		Result:       result.Number,
+		ResultType:   string(result.Type),
+		ResultText:   resultText(result, settings.Precision),
+		UsedParams:   deps.Cells,
+		UsedRanges:   deps.Ranges,
+		UsedPatterns: deps.Patterns,
 	}
+	if result.Type == TypeError {
+		input.ResultError, input.ErrorCell = result.Err.Message, result.Err.Cell
+	}
+	calc.results[cell] = input
+	return input, nil
+}
+
+func (s *excelLikeService) GetSheetInput(ctx context.Context, sheetID string) (map[string]models.Data, error) {
+	return s.storage.GetSheetInput(ctx, sheetID)
+}
 
-	for _, input := range *allInputs {
-		_, err := s.AddCellInput(ctx, tx, input.SheetID, input.CellID, &models.Data{
-			Value:  input.Value,
-			Result: fmt.Sprintf("%f", input.Result),
+func (s *excelLikeService) GetFunctions(_ context.Context) []models.Function {
+	list := s.functions.List()
+	resp := make([]models.Function, 0, len(list))
+	for _, fn := range list {
+		args := make([]models.FunctionArg, 0, len(fn.Args))
+		for _, arg := range fn.Args {
+			args = append(args, models.FunctionArg{
+				Name:     arg.Name,
+				Type:     string(arg.Type),
+				Optional: arg.Optional,
+			})
+		}
+		resp = append(resp, models.Function{
+			Name:        fn.Name,
+			Signature:   fn.Signature(),
+			Description: fn.Description,
+			Args:        args,
+			Variadic:    fn.Variadic,
+			MinArgs:     fn.MinArgs(),
+			MaxArgs:     fn.MaxArgs(),
 		})
+	}
+	return resp
+}
+
+// resultText is the stored text of a result. Numbers are kept in db.Input.Result only, unless they are exact,
+// then their digits are written with precision decimal places.
+func resultText(result Value, precision int) string {
+	switch {
+	case result.Dec != nil:
+		return result.Dec.FloatString(precision)
+	case result.Type == TypeNumber:
+		return ""
+	}
+	return result.AsText()
+}
+
+// newEvaluator prepares the evaluation of a formula of cell using deps: it loads the settings of the sheet
+// and the values of the referenced cells. References to deleted cells fail with #REF! whatever the sheet settings.
+func (s *excelLikeService) newEvaluator(ctx context.Context, tx *sql.Tx, calc *calculation, cell db.CellRef, deps db.Dependencies) (*evaluator, db.SheetSettings, error) {
+	settings, ok := calc.settings[cell.SheetID]
+	if !ok {
+		var err error
+		if settings, err = s.storage.GetSheetSettings(ctx, tx, cell.SheetID); err != nil {
+			return nil, db.SheetSettings{}, err
+		}
+		calc.settings[cell.SheetID] = settings
+	}
+
+	m, err := s.getCellValues(ctx, tx, withRangeCells(deps.Cells, deps.Ranges), calc.results)
+	if err != nil {
+		return nil, db.SheetSettings{}, err
+	}
+	matched, err := s.getPatternCells(ctx, tx, cell, deps.Patterns, m)
+	if err != nil {
+		return nil, db.SheetSettings{}, err
+	}
+	e := evaluatorOf(cell, settings, s.functions, m, matched)
+	e.deleted = s.graph.deletedRefs(deps)
+	return e, settings, nil
+}
+
+// evaluatorOf returns the evaluator of a formula of cell on a sheet with settings.
+func evaluatorOf(cell db.CellRef, settings db.SheetSettings, functions *FunctionRegistry, values map[db.CellRef]Value, patterns map[db.CellPattern][]db.CellRef) *evaluator {
+	return &evaluator{
+		sheetID:       cell.SheetID,
+		values:        values,
+		patterns:      patterns,
+		functions:     functions,
+		decimal:       NumberMode(settings.NumberMode) == ModeDecimal,
+		missingAsZero: MissingRefsPolicy(settings.MissingRefs) == MissingAsZero,
+	}
+}
+
+// getCellValues loads results of the referenced cells, grouping them by sheet. Results already known
+// are taken from known instead. Missing cells are omitted.
+func (s *excelLikeService) getCellValues(ctx context.Context, tx *sql.Tx, cells []db.CellRef, known map[db.CellRef]db.Input) (map[db.CellRef]Value, error) {
+	var sheets []string
+	bySheet := make(map[string][]string)
+	values := make(map[db.CellRef]Value, len(cells))
+	for _, cell := range cells {
+		if input, ok := known[cell]; ok {
+			values[cell] = inputValue(cell, input)
+			continue
+		}
+		if _, ok := bySheet[cell.SheetID]; !ok {
+			sheets = append(sheets, cell.SheetID)
+		}
+		bySheet[cell.SheetID] = append(bySheet[cell.SheetID], cell.CellID)
+	}
+
+	for _, sheetID := range sheets {
+		results, err := s.storage.GetCellInputBatch(ctx, tx, sheetID, bySheet[sheetID])
 		if err != nil {
-			return err
+			return nil, err
+		}
+		for cellID, result := range results {
+			cell := db.CellRef{SheetID: sheetID, CellID: cellID}
+			values[cell] = inputValue(cell, result)
+		}
+	}
+	return values, nil
+}
+
+// getPatternCells finds the cells matching each pattern except cell itself, which never aggregates its own
+// value, sorted by ID. Results of the matched cells are added to values unless it is nil.
+func (s *excelLikeService) getPatternCells(ctx context.Context, tx *sql.Tx, cell db.CellRef, patterns []db.CellPattern, values map[db.CellRef]Value) (map[db.CellPattern][]db.CellRef, error) {
+	matched := make(map[db.CellPattern][]db.CellRef, len(patterns))
+	for _, pattern := range patterns {
+		results, err := s.storage.GetCellInputByPattern(ctx, tx, pattern)
+		if err != nil {
+			return nil, err
+		}
+		cells := make([]db.CellRef, 0, len(results))
+		for cellID, result := range results {
+			ref := db.CellRef{SheetID: pattern.SheetID, CellID: cellID}
+			if ref == cell {
+				continue
+			}
+			cells = append(cells, ref)
+			if values != nil {
+				values[ref] = inputValue(ref, result)
+			}
+		}
+		sort.Slice(cells, func(i, j int) bool {
+			return cells[i].CellID < cells[j].CellID
+		})
+		matched[pattern] = cells
+	}
+	return matched, nil
+}
+
+// checkCircularReference makes sure the formula of cell using deps doesn't close a loop anywhere in the
+// dependency graph, which may span several sheets.
+func (s *excelLikeService) checkCircularReference(cell db.CellRef, deps db.Dependencies) error {
+	refs := s.graph.precedents(cell, deps)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	// ranges and patterns of stored cells may cover the written cell before it is stored
+	usingCell := make(map[db.CellRef]bool)
+	for _, dependent := range s.graph.directDependents(cell) {
+		usingCell[dependent] = true
+	}
+	graph := make(map[db.CellRef][]db.CellRef)
+	queue := append([]db.CellRef(nil), refs...)
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		if _, ok := graph[from]; ok || from == cell {
+			continue
+		}
+		graph[from] = s.graph.directPrecedents(from)
+		if usingCell[from] {
+			graph[from] = appendCell(graph[from], cell)
+		}
+		queue = append(queue, graph[from]...)
+	}
+
+	if path := findCycle(graph, cell, refs); path != nil {
+		formatted := make([]string, len(path))
+		for i, ref := range path {
+			formatted[i] = formatRef(ref, cell.SheetID)
 		}
+		return &CircularReferenceError{Path: formatted}
 	}
 	return nil
 }
@@ -72,7 +72,7 @@ func TestExcelLikeService_GetCellInput(t *testing.T) {
 	}
 }
 
-func TestExcelLikeService_AddCellInput(t *testing.T) {
+func TestExcelLikeService_addCellInput(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -80,14 +80,18 @@ func TestExcelLikeService_AddCellInput(t *testing.T) {
 	tx := &sql.Tx{}
 
 	s := &excelLikeService{
-		storage: storage,
+		storage:   storage,
+		functions: NewDefaultFunctionRegistry(),
 	}
+	storage.EXPECT().GetSheetSettings(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(defaultSettings).AnyTimes()
 
 	tests := []struct {
 		name          string
 		sheetID       string
 		cellID        string
 		inputData     *models.Data
+		graph         []db.Input
+		unloaded      bool
 		mockBehavior  func()
 		expectedData  *models.Data
 		expectedError string
@@ -97,12 +101,32 @@ func TestExcelLikeService_AddCellInput(t *testing.T) {
 			sheetID: "sheet1",
 			cellID:  "cellA1",
 			inputData: &models.Data{
-				Value: "InvalidValue",
+				Value: "",
 			},
 			mockBehavior:  func() {},
 			expectedData:  nil,
 			expectedError: "input value is not correct",
 		},
+		{
+			name:    "Text value keeps its case",
+			sheetID: "sheet1",
+			cellID:  "status",
+			inputData: &models.Data{
+				Value: "Paid",
+			},
+			mockBehavior: func() {
+				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), db.Input{
+					SheetID:    "sheet1",
+					CellID:     "status",
+					Value:      "Paid",
+					ResultType: "text",
+					ResultText: "Paid",
+					UsedParams: []db.CellRef{},
+				}).Return(&models.Data{Value: "Paid", Result: "Paid", Type: "text"}, false, nil)
+			},
+			expectedData:  &models.Data{Value: "Paid", Result: "Paid", Type: "text"},
+			expectedError: "",
+		},
 		{
 			name:    "Self-referencing cell",
 			sheetID: "sheet1",
@@ -112,7 +136,36 @@ func TestExcelLikeService_AddCellInput(t *testing.T) {
 			},
 			mockBehavior:  func() {},
 			expectedData:  nil,
-			expectedError: "cell can't link to itself",
+			expectedError: "circular reference: cella1 -> cella1",
+		},
+		{
+			name:    "Transitive circular reference",
+			sheetID: "sheet1",
+			cellID:  "b",
+			inputData: &models.Data{
+				Value: "=SUM(a, 1)",
+			},
+			graph: []db.Input{
+				{SheetID: "sheet1", CellID: "a", Value: "=c", UsedParams: sheet1Cells("c")},
+				{SheetID: "sheet1", CellID: "c", Value: "=d+b", UsedParams: sheet1Cells("d", "b")},
+			},
+			mockBehavior:  func() {},
+			expectedData:  nil,
+			expectedError: "circular reference: b -> a -> c -> b",
+		},
+		{
+			name:    "Storage error when loading the graph",
+			sheetID: "sheet1",
+			cellID:  "b",
+			inputData: &models.Data{
+				Value: "=a+1",
+			},
+			unloaded: true,
+			mockBehavior: func() {
+				storage.EXPECT().GetAllInputs(gomock.Any(), gomock.Any()).Return(nil, errors.New("some DB error"))
+			},
+			expectedData:  nil,
+			expectedError: "some DB error",
 		},
 		{
 			name:    "Storage error when fetching cells",
@@ -147,71 +200,289 @@ func TestExcelLikeService_AddCellInput(t *testing.T) {
 			},
 			mockBehavior:  func() {},
 			expectedData:  nil,
-			expectedError: "unsupported binary operator: ^",
+			expectedError: "unexpected character '^' at position 2",
 		},
 		{
-			name:    "AddCellInput storage error",
-			sheetID: "sheet2",
-			cellID:  "cellA2",
+			name:    "Forward reference stores #REF!",
+			sheetID: "sheet1",
+			cellID:  "cellA1",
+			inputData: &models.Data{
+				Value: "=cellB1+5",
+			},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), gomock.Any(), "sheet1", []string{"cellb1"}).Return(numberInputs(map[string]float64{}), nil)
+				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), db.Input{
+					SheetID:     "sheet1",
+					CellID:      "cellA1",
+					Value:       "=cellb1+5",
+					ResultType:  "error",
+					ResultText:  "#REF!",
+					ResultError: "referenced cell \"cellb1\" not found",
+					UsedParams:  []db.CellRef{{SheetID: "sheet1", CellID: "cellb1"}},
+				}).Return(&models.Data{Value: "=cellb1+5", Result: "#REF!", Type: "error", Error: "referenced cell \"cellb1\" not found"}, false, nil)
+			},
+			expectedData:  &models.Data{Value: "=cellb1+5", Result: "#REF!", Type: "error", Error: "referenced cell \"cellb1\" not found"},
+			expectedError: "",
+		},
+		{
+			name:    "New cell recalculates forward references",
+			sheetID: "sheet1",
+			cellID:  "x",
+			inputData: &models.Data{
+				Value: "2",
+			},
+			graph: []db.Input{
+				{SheetID: "sheet1", CellID: "y", Value: "=x*2", UsedParams: []db.CellRef{{SheetID: "sheet1", CellID: "x"}}},
+			},
+			mockBehavior: func() {
+				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.Data{Value: "2", Result: "2.000000"}, false, nil)
+				storage.EXPECT().SaveCellResult(gomock.Any(), gomock.Any(), db.Input{
+					SheetID:    "sheet1",
+					CellID:     "y",
+					Value:      "=x*2",
+					Result:     4,
+					ResultType: "number",
+					UsedParams: []db.CellRef{{SheetID: "sheet1", CellID: "x"}},
+				}).Return(nil)
+			},
+			expectedData:  &models.Data{Value: "2", Result: "2.000000"},
+			expectedError: "",
+		},
+		{
+			name:    "Quoted reference",
+			sheetID: "sheet3",
+			cellID:  "cellA3",
+			inputData: &models.Data{
+				Value: "='a+b'*2",
+			},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), gomock.Any(), "sheet3", []string{"a+b"}).Return(numberInputs(map[string]float64{"a+b": 1.5}), nil)
+				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), db.Input{
+					SheetID:    "sheet3",
+					CellID:     "cellA3",
+					Value:      "='a+b'*2",
+					Result:     3,
+					ResultType: "number",
+					UsedParams: []db.CellRef{{SheetID: "sheet3", CellID: "a+b"}},
+				}).Return(&models.Data{Value: "='a+b'*2", Result: "3.000000"}, false, nil)
+			},
+			expectedData:  &models.Data{Value: "='a+b'*2", Result: "3.000000"},
+			expectedError: "",
+		},
+		{
+			name:    "Cross-sheet reference",
+			sheetID: "budget",
+			cellID:  "tax",
+			inputData: &models.Data{
+				Value: "=assumptions!tax_rate*revenue",
+			},
+			mockBehavior: func() {
+				refs := []db.CellRef{{SheetID: "assumptions", CellID: "tax_rate"}, {SheetID: "budget", CellID: "revenue"}}
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), gomock.Any(), "assumptions", []string{"tax_rate"}).Return(numberInputs(map[string]float64{"tax_rate": 0.2}), nil)
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), gomock.Any(), "budget", []string{"revenue"}).Return(numberInputs(map[string]float64{"revenue": 50}), nil)
+				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), db.Input{
+					SheetID:    "budget",
+					CellID:     "tax",
+					Value:      "=assumptions!tax_rate*revenue",
+					Result:     10,
+					ResultType: "number",
+					UsedParams: refs,
+				}).Return(&models.Data{Value: "=assumptions!tax_rate*revenue", Result: "10.000000"}, false, nil)
+			},
+			expectedData:  &models.Data{Value: "=assumptions!tax_rate*revenue", Result: "10.000000"},
+			expectedError: "",
+		},
+		{
+			name:    "Cross-sheet circular reference",
+			sheetID: "sheet1",
+			cellID:  "a",
+			inputData: &models.Data{
+				Value: "=other!b",
+			},
+			graph: []db.Input{
+				{SheetID: "other", CellID: "b", Value: "=sheet1!a", UsedParams: sheet1Cells("a")},
+			},
+			mockBehavior:  func() {},
+			expectedData:  nil,
+			expectedError: "circular reference: a -> other!b -> a",
+		},
+		{
+			name:    "New grid cell recalculates range consumers",
+			sheetID: "sheet1",
+			cellID:  "a2",
 			inputData: &models.Data{
 				Value: "5",
 			},
+			graph: []db.Input{
+				{SheetID: "sheet1", CellID: "total", Value: "=sum(a1:a3)", UsedRanges: []db.CellRange{{SheetID: "sheet1", FromCol: 1, FromRow: 1, ToCol: 1, ToRow: 3}}},
+			},
 			mockBehavior: func() {
-				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, false, errors.New("Insert error"))
+				column := db.CellRange{SheetID: "sheet1", FromCol: 1, FromRow: 1, ToCol: 1, ToRow: 3}
+				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.Data{Value: "5", Result: "5.000000"}, false, nil)
+				// the written cell is known already, only the rest of the range is read
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), gomock.Any(), "sheet1", []string{"a1", "a3"}).Return(numberInputs(map[string]float64{}), nil)
+				storage.EXPECT().SaveCellResult(gomock.Any(), gomock.Any(), db.Input{
+					SheetID:    "sheet1",
+					CellID:     "total",
+					Value:      "=sum(a1:a3)",
+					Result:     5,
+					ResultType: "number",
+					UsedParams: []db.CellRef{},
+					UsedRanges: []db.CellRange{column},
+				}).Return(nil)
+			},
+			expectedData:  &models.Data{Value: "5", Result: "5.000000"},
+			expectedError: "",
+		},
+		{
+			name:    "Pattern aggregation",
+			sheetID: "sheet1",
+			cellID:  "total_sales",
+			inputData: &models.Data{
+				Value: "=SUM(sales_*)",
+			},
+			mockBehavior: func() {
+				sales := db.CellPattern{SheetID: "sheet1", Pattern: "sales_*"}
+				storage.EXPECT().GetCellInputByPattern(gomock.Any(), gomock.Any(), sales).Return(numberInputs(map[string]float64{"sales_jan": 1, "sales_feb": 2}), nil)
+				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), db.Input{
+					SheetID:      "sheet1",
+					CellID:       "total_sales",
+					Value:        "=sum(sales_*)",
+					Result:       3,
+					ResultType:   "number",
+					UsedParams:   []db.CellRef{},
+					UsedPatterns: []db.CellPattern{sales},
+				}).Return(&models.Data{Value: "=sum(sales_*)", Result: "3.000000"}, false, nil)
+			},
+			expectedData:  &models.Data{Value: "=sum(sales_*)", Result: "3.000000"},
+			expectedError: "",
+		},
+		{
+			name:    "Pattern circular reference through a cell not stored yet",
+			sheetID: "sheet1",
+			cellID:  "sales_avg",
+			inputData: &models.Data{
+				Value: "=AVERAGE(sales_*)",
+			},
+			graph: []db.Input{
+				{SheetID: "sheet1", CellID: "sales_jan", Value: "1"},
+				{SheetID: "sheet1", CellID: "sales_total", Value: "=sum(sales_*)", UsedPatterns: []db.CellPattern{{SheetID: "sheet1", Pattern: "sales_*"}}},
 			},
+			mockBehavior:  func() {},
 			expectedData:  nil,
-			expectedError: "Insert error",
+			expectedError: "circular reference: sales_avg -> sales_total -> sales_avg",
 		},
 		{
-			name:    "Update dependent cells error (failed to get ids)",
+			name:    "New cell recalculates pattern consumers",
 			sheetID: "sheet1",
-			cellID:  "cellA1",
+			cellID:  "sales_mar",
+			inputData: &models.Data{
+				Value: "3",
+			},
+			graph: []db.Input{
+				{SheetID: "sheet1", CellID: "sales_total", Value: "=sum(sales_*)", UsedPatterns: []db.CellPattern{{SheetID: "sheet1", Pattern: "sales_*"}}},
+			},
+			mockBehavior: func() {
+				sales := db.CellPattern{SheetID: "sheet1", Pattern: "sales_*"}
+				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.Data{Value: "3", Result: "3.000000"}, false, nil)
+				storage.EXPECT().GetCellInputByPattern(gomock.Any(), gomock.Any(), sales).Return(numberInputs(map[string]float64{"sales_jan": 1, "sales_mar": 3, "sales_total": 1}), nil)
+				storage.EXPECT().SaveCellResult(gomock.Any(), gomock.Any(), db.Input{
+					SheetID:      "sheet1",
+					CellID:       "sales_total",
+					Value:        "=sum(sales_*)",
+					Result:       4,
+					ResultType:   "number",
+					UsedParams:   []db.CellRef{},
+					UsedPatterns: []db.CellPattern{sales},
+				}).Return(nil)
+			},
+			expectedData:  &models.Data{Value: "3", Result: "3.000000"},
+			expectedError: "",
+		},
+		{
+			name:    "Dependent cell stores error",
+			sheetID: "sheet1",
+			cellID:  "b",
+			inputData: &models.Data{
+				Value: "0",
+			},
+			graph: []db.Input{
+				{SheetID: "sheet1", CellID: "c", Value: "=a/b", UsedParams: []db.CellRef{{SheetID: "sheet1", CellID: "a"}, {SheetID: "sheet1", CellID: "b"}}},
+			},
+			mockBehavior: func() {
+				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.Data{Value: "0", Result: "0.000000"}, true, nil)
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), gomock.Any(), "sheet1", []string{"a"}).Return(numberInputs(map[string]float64{"a": 1}), nil)
+				storage.EXPECT().SaveCellResult(gomock.Any(), gomock.Any(), db.Input{
+					SheetID:     "sheet1",
+					CellID:      "c",
+					Value:       "=a/b",
+					ResultType:  "error",
+					ResultText:  "#DIV/0!",
+					ResultError: "division by zero",
+					UsedParams:  []db.CellRef{{SheetID: "sheet1", CellID: "a"}, {SheetID: "sheet1", CellID: "b"}},
+				}).Return(nil)
+			},
+			expectedData:  &models.Data{Value: "0", Result: "0.000000"},
+			expectedError: "",
+		},
+		{
+			name:    "Reference to cell in error state",
+			sheetID: "sheet1",
+			cellID:  "d",
+			inputData: &models.Data{
+				Value: "=c+1",
+			},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), gomock.Any(), "sheet1", []string{"c"}).Return(map[string]db.Input{
+					"c": {CellID: "c", ResultType: "error", ResultText: "#DIV/0!", ResultError: "division by zero"},
+				}, nil)
+			},
+			expectedData:  nil,
+			expectedError: "sheet1!c: division by zero",
+		},
+		{
+			name:    "AddCellInput storage error",
+			sheetID: "sheet2",
+			cellID:  "cellA2",
 			inputData: &models.Data{
 				Value: "5",
 			},
 			mockBehavior: func() {
-				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.Data{}, true, nil) // Indicate wasUpdated=true
-				storage.EXPECT().GetIDList(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("failed to get dependent inputs"))
+				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, false, errors.New("Insert error"))
 			},
 			expectedData:  nil,
-			expectedError: "failed to get dependent inputs",
+			expectedError: "Insert error",
 		},
 		{
-			name:    "Update dependent cells error (failed to input batch)",
+			name:    "Update dependent cells error (failed to get precedents)",
 			sheetID: "sheet1",
-			cellID:  "cellA1",
+			cellID:  "a",
 			inputData: &models.Data{
 				Value: "5",
 			},
+			graph: []db.Input{
+				{SheetID: "sheet1", CellID: "b", Value: "=a+c", UsedParams: []db.CellRef{{SheetID: "sheet1", CellID: "a"}, {SheetID: "sheet1", CellID: "c"}}},
+			},
 			mockBehavior: func() {
 				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.Data{}, true, nil)
-				storage.EXPECT().GetIDList(gomock.Any(), gomock.Any(), gomock.Any()).Return([]int{1, 2}, nil)
-				storage.EXPECT().GetInputBatchByIDs(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("failed to get inputs batch"))
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), gomock.Any(), "sheet1", []string{"c"}).Return(nil, errors.New("failed to get precedents"))
 			},
 			expectedData:  nil,
-			expectedError: "failed to get inputs batch",
+			expectedError: "failed to get precedents",
 		},
 		{
 			name:    "Update dependent cells error (failed to update dependent)",
 			sheetID: "sheet1",
-			cellID:  "cellA1",
+			cellID:  "a",
 			inputData: &models.Data{
 				Value: "5",
 			},
+			graph: []db.Input{
+				{SheetID: "sheet1", CellID: "b", Value: "=a*2", UsedParams: []db.CellRef{{SheetID: "sheet1", CellID: "a"}}},
+			},
 			mockBehavior: func() {
-				input := []db.Input{
-					{
-						SheetID:    "1",
-						CellID:     "2",
-						Value:      "3",
-						Result:     3,
-						UsedParams: nil,
-					},
-				}
 				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.Data{}, true, nil)
-				storage.EXPECT().GetIDList(gomock.Any(), gomock.Any(), gomock.Any()).Return([]int{1, 2}, nil)
-				storage.EXPECT().GetInputBatchByIDs(gomock.Any(), gomock.Any(), gomock.Any()).Return(&input, nil)
-				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.Data{}, false, errors.New("failed to update"))
+				storage.EXPECT().SaveCellResult(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("failed to update"))
 			},
 			expectedData:  nil,
 			expectedError: "failed to update",
@@ -219,38 +490,50 @@ func TestExcelLikeService_AddCellInput(t *testing.T) {
 		{
 			name:    "Success scenario",
 			sheetID: "sheet4",
-			cellID:  "cellA4",
+			cellID:  "a",
 			inputData: &models.Data{
 				Value: "=5",
 			},
+			graph: []db.Input{
+				{SheetID: "sheet4", CellID: "b", Value: "=a+1", UsedParams: []db.CellRef{{SheetID: "sheet4", CellID: "a"}}},
+				{SheetID: "sheet4", CellID: "c", Value: "=a*b", UsedParams: []db.CellRef{{SheetID: "sheet4", CellID: "a"}, {SheetID: "sheet4", CellID: "b"}}},
+			},
 			mockBehavior: func() {
-				input := []db.Input{
-					{
-						SheetID:    "1",
-						CellID:     "2",
-						Value:      "3",
-						Result:     3,
-						UsedParams: nil,
-					},
-				}
-				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.Data{
-					Value:  "=cellB1",
-					Result: "10",
-				}, true, nil)
-				storage.EXPECT().GetIDList(gomock.Any(), gomock.Any(), gomock.Any()).Return([]int{1, 2}, nil)
-				storage.EXPECT().GetInputBatchByIDs(gomock.Any(), gomock.Any(), gomock.Any()).Return(&input, nil)
-				storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.Data{}, false, nil)
-			},
-			expectedData:  &models.Data{Value: "=cellB1", Result: "10"},
+				// c uses both a and b, it is calculated once after b
+				gomock.InOrder(
+					storage.EXPECT().AddCellInput(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.Data{Value: "=5", Result: "5.000000"}, true, nil),
+					storage.EXPECT().SaveCellResult(gomock.Any(), gomock.Any(), db.Input{
+						SheetID:    "sheet4",
+						CellID:     "b",
+						Value:      "=a+1",
+						Result:     6,
+						ResultType: "number",
+						UsedParams: []db.CellRef{{SheetID: "sheet4", CellID: "a"}},
+					}).Return(nil),
+					storage.EXPECT().SaveCellResult(gomock.Any(), gomock.Any(), db.Input{
+						SheetID:    "sheet4",
+						CellID:     "c",
+						Value:      "=a*b",
+						Result:     30,
+						ResultType: "number",
+						UsedParams: []db.CellRef{{SheetID: "sheet4", CellID: "a"}, {SheetID: "sheet4", CellID: "b"}},
+					}).Return(nil),
+				)
+			},
+			expectedData:  &models.Data{Value: "=5", Result: "5.000000"},
 			expectedError: "",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			s.graph = graphOf(tt.graph...)
+			if tt.unloaded {
+				s.graph = newDependencyGraph()
+			}
 			tt.mockBehavior()
 
-			data, err := s.AddCellInput(context.TODO(), tx, tt.sheetID, tt.cellID, tt.inputData)
+			data, err := s.addCellInput(context.TODO(), tx, newCalculation(), tt.sheetID, tt.cellID, tt.inputData)
 
 			if err != nil {
 				assert.Contains(t, err.Error(), tt.expectedError)
@@ -260,60 +543,23 @@ func TestExcelLikeService_AddCellInput(t *testing.T) {
 	}
 }
 
-func TestExcelLikeService_GetSheetInput(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	storage := mock_db.NewMockStorage(ctrl)
-
-	s := &excelLikeService{
-		storage: storage,
-	}
-
-	out := map[string]models.Data{"cell1": {
-		Value:  "SampleValue",
-		Result: "SomeResult",
-	},
-	}
-
-	tests := []struct {
-		name         string
-		sheetID      string
-		cellID       string
-		mockBehavior func()
-		expectedData map[string]models.Data
-		expectedErr  error
-	}{
-		{
-			name:    "Success",
-			sheetID: "sheet1",
-			cellID:  "cellA1",
-			mockBehavior: func() {
-				storage.EXPECT().GetSheetInput(gomock.Any(), "sheet1").Return(out, nil)
-			},
-			expectedData: out,
-			expectedErr:  nil,
-		},
-		{
-			name:    "Storage Error",
-			sheetID: "sheet1",
-			cellID:  "cellA2",
-			mockBehavior: func() {
-				storage.EXPECT().GetSheetInput(gomock.Any(), "sheet1").Return(nil, errors.New("some storage error"))
-			},
-			expectedData: nil,
-			expectedErr:  errors.New("some storage error"),
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.mockBehavior()
+// defaultSettings mocks Storage.GetSheetSettings for sheets in float mode.
+func defaultSettings(_ context.Context, _ *sql.Tx, sheetID string) (db.SheetSettings, error) {
+	return db.DefaultSheetSettings(sheetID), nil
+}
 
-			data, err := s.GetSheetInput(context.TODO(), tt.sheetID)
+// graphOf builds a loaded dependency graph of the given cells.
+func graphOf(inputs ...db.Input) *dependencyGraph {
+	g := newDependencyGraph()
+	g.load(inputs, nil)
+	return g
+}
 
-			assert.Equal(t, tt.expectedData, data)
-			assert.Equal(t, tt.expectedErr, err)
-		})
+// numberInputs mocks stored cells with numeric results keyed by cell ID.
+func numberInputs(results map[string]float64) map[string]db.Input {
+	res := make(map[string]db.Input, len(results))
+	for cellID, result := range results {
+		res[cellID] = db.Input{CellID: cellID, Result: result, ResultType: "number"}
 	}
+	return res
 }
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// NumberMode tells how numbers of a sheet are calculated.
+type NumberMode string
+
+const (
+	ModeFloat   NumberMode = "float"
+	ModeDecimal NumberMode = "decimal"
+)
+
+// RoundingMode tells how results of sheets in decimal mode are rounded to their precision.
+type RoundingMode string
+
+const (
+	RoundHalfUp   RoundingMode = "half_up"
+	RoundHalfEven RoundingMode = "half_even"
+)
+
+// maxDecimalPrecision limits the number of decimal places kept for results of sheets in decimal mode.
+const maxDecimalPrecision = 30
+
+// DecimalValue wraps an exact decimal number, Number keeps its float64 approximation.
+func DecimalValue(d *big.Rat) Value {
+	number, _ := d.Float64()
+	return Value{Type: TypeNumber, Number: number, Dec: d}
+}
+
+// AsDecimal converts v to an exact decimal, numbers calculated with float64 are taken as the shortest
+// decimal they are written as, so 0.1 becomes exactly 1/10.
+func (v Value) AsDecimal() (*big.Rat, error) {
+	if v.Dec != nil {
+		return v.Dec, nil
+	}
+	number, err := v.AsNumber()
+	if err != nil {
+		return nil, err
+	}
+	if v.Type == TypeText {
+		if d := ratFromText(strings.TrimSpace(v.Text)); d != nil {
+			return d, nil
+		}
+	}
+	d := ratFromFloat(number)
+	if d == nil {
+		return nil, newFormulaError(ErrNum, "%v is not a finite number", number)
+	}
+	return d, nil
+}
+
+// maxExactExponent limits the exponents of number literals read exactly, 1e999999999 would take
+// gigabytes as a rational number.
+const maxExactExponent = 400
+
+// ratFromText reads a number literal like 0.1 or 12345678901234567890.5 exactly, nil when it isn't one
+// or its exponent is out of range.
+func ratFromText(text string) *big.Rat {
+	if _, ok := parseNumber(text); !ok {
+		return nil
+	}
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(text[i+1:])
+		if err != nil || exp > maxExactExponent || exp < -maxExactExponent {
+			return nil
+		}
+	}
+	d, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return nil
+	}
+	return d
+}
+
+// ratFromFloat converts f to the shortest decimal it is written as, nil for infinities and NaN.
+func ratFromFloat(f float64) *big.Rat {
+	d, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	if !ok {
+		return nil
+	}
+	return d
+}
+
+func applyDecimal(op string, x, y *big.Rat) (*big.Rat, error) {
+	switch op {
+	case "+":
+		return new(big.Rat).Add(x, y), nil
+	case "-":
+		return new(big.Rat).Sub(x, y), nil
+	case "*":
+		return new(big.Rat).Mul(x, y), nil
+	case "/":
+		if y.Sign() == 0 {
+			return nil, newFormulaError(ErrDivZero, "division by zero")
+		}
+		return new(big.Rat).Quo(x, y), nil
+	default:
+		return nil, newFormulaError(ErrName, "unsupported binary operator: %v", op)
+	}
+}
+
+// roundDecimal rounds d to digits decimal places, negative digits round to tens, hundreds, etc.
+// Ties go away from zero for RoundHalfUp and to the even neighbour for RoundHalfEven.
+func roundDecimal(d *big.Rat, digits int, mode RoundingMode) *big.Rat {
+	scale := pow10(digits)
+	scaled := new(big.Rat).Mul(d, scale)
+	q, r := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	twice := new(big.Int).Lsh(new(big.Int).Abs(r), 1)
+	if c := twice.Cmp(scaled.Denom()); c > 0 || c == 0 && (mode != RoundHalfEven || q.Bit(0) == 1) {
+		q.Add(q, big.NewInt(int64(scaled.Sign())))
+	}
+	return new(big.Rat).Quo(new(big.Rat).SetInt(q), scale)
+}
+
+// floorDecimal returns the largest integer not greater than d.
+func floorDecimal(d *big.Rat) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Div(d.Num(), d.Denom()))
+}
+
+func pow10(exp int) *big.Rat {
+	if exp < 0 {
+		return new(big.Rat).Inv(pow10(-exp))
+	}
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+}
+
+// formatDecimal writes d without trailing zeros, decimals that don't terminate like 1/3 are cut
+// after maxDecimalPrecision places.
+func formatDecimal(d *big.Rat) string {
+	str := d.FloatString(maxDecimalPrecision)
+	if strings.Contains(str, ".") {
+		str = strings.TrimRight(strings.TrimRight(str, "0"), ".")
+	}
+	if str == "-0" {
+		return "0"
+	}
+	return str
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+)
+
+func Test_roundDecimal(t *testing.T) {
+	tests := []struct {
+		number string
+		digits int
+		mode   RoundingMode
+		want   string
+	}{
+		{number: "2.675", digits: 2, mode: RoundHalfUp, want: "2.68"},
+		{number: "2.665", digits: 2, mode: RoundHalfUp, want: "2.67"},
+		{number: "-2.675", digits: 2, mode: RoundHalfUp, want: "-2.68"},
+		{number: "2.675", digits: 2, mode: RoundHalfEven, want: "2.68"},
+		{number: "2.665", digits: 2, mode: RoundHalfEven, want: "2.66"},
+		{number: "-2.665", digits: 2, mode: RoundHalfEven, want: "-2.66"},
+		{number: "2.6651", digits: 2, mode: RoundHalfEven, want: "2.67"},
+		{number: "1/3", digits: 4, mode: RoundHalfUp, want: "0.3333"},
+		{number: "1250", digits: -2, mode: RoundHalfUp, want: "1300"},
+		{number: "1250", digits: -2, mode: RoundHalfEven, want: "1200"},
+		{number: "0.5", digits: 0, mode: RoundHalfEven, want: "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number+" "+string(tt.mode), func(t *testing.T) {
+			number, _ := new(big.Rat).SetString(tt.number)
+			if got := formatDecimal(roundDecimal(number, tt.digits, tt.mode)); got != tt.want {
+				t.Errorf("roundDecimal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_evalDecimal(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		values  map[string]any
+		want    string
+		wantErr bool
+	}{
+		{name: "Addition without float noise", expr: "=0.1+0.2", want: "0.3"},
+		{name: "20-digit literal", expr: "=12345678901234567891+1", want: "12345678901234567892"},
+		{name: "Numeric text", expr: `=" 12345678901234567891.25"*2`, want: "24691357802469135782.5"},
+		{name: "Literal with a huge exponent", expr: "=1E300*0", want: "0"},
+		{name: "Exact comparison", expr: "=0.1+0.2=0.3", want: "TRUE"},
+		{name: "Division keeps fractions", expr: "=1/3*3", want: "1"},
+		{name: "Non-terminating division", expr: "=2/3", want: "0.666666666666666666666666666667"},
+		{name: "Negation", expr: "=-(0.7-0.1)", want: "-0.6"},
+		{name: "Exact SUM of a range", expr: "=SUM(a1:a3)", values: map[string]any{"a1": 0.1, "a2": 0.2, "a3": "note"}, want: "0.3"},
+		{name: "Exact AVERAGE", expr: "=AVERAGE(0.1, 0.2, 0.3)", want: "0.2"},
+		{name: "ROUND half away from zero", expr: "=ROUND(1.005, 2)", want: "1.01"},
+		{name: "MOD", expr: "=MOD(-0.3, 0.2)", want: "0.1"},
+		{name: "FLOOR and CEILING", expr: "=FLOOR(1.35, 0.1) & CEILING(1.35, 0.1)", want: "1.31.4"},
+		{name: "Text holding a number", expr: `="0.1"+0.2`, want: "0.3"},
+		{name: "Float function result", expr: "=SQRT(0.25)+0.1", want: "0.6"},
+		{name: "Division by zero", expr: "=1/(0.1-0.1)", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseFormula(tt.expr)
+			if err != nil {
+				t.Fatalf("parseFormula() error = %v", err)
+			}
+			values := sheetValues("sheet1", tt.values)
+			got, err := (&evaluator{sheetID: "sheet1", values: values, functions: NewDefaultFunctionRegistry(), decimal: true}).eval(root)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("eval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.AsText() != tt.want {
+				t.Errorf("eval() got = %v, want %v", got.AsText(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_ratFromText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "12345678901234567891", want: "12345678901234567891/1"},
+		{text: "0.1", want: "1/10"},
+		{text: "-.5", want: "-1/2"},
+		{text: "2e3", want: "2000/1"},
+		{text: "1e999999999"},
+		{text: "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := ratFromText(tt.text)
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("ratFromText() = %v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.String() != tt.want {
+				t.Errorf("ratFromText() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+// ErrInvalidDeletePolicy is returned for policies other than DeleteReject and DeleteRef.
+var ErrInvalidDeletePolicy = errors.New("invalid delete policy")
+
+// DeletePolicy tells what happens to cells using the cells being deleted.
+type DeletePolicy string
+
+const (
+	// DeleteReject refuses to delete cells other cells use.
+	DeleteReject DeletePolicy = "reject"
+	// DeleteRef deletes them anyway, formulas referencing them become #REF! also on sheets counting missing
+	// cells as 0, formulas using them through ranges and patterns are recalculated without them.
+	DeleteRef DeletePolicy = "ref"
+)
+
+// DependentsError rejects a delete, Cells are the cells using the cells being deleted.
+type DependentsError struct {
+	Cells []string
+}
+
+func (e *DependentsError) Error() string {
+	return fmt.Sprintf("cells depend on the deleted cells: %s", strings.Join(e.Cells, ", "))
+}
+
+// DeleteCellTX deletes a cell, the result is nil when there is no such cell.
+func (s *excelLikeService) DeleteCellTX(ctx context.Context, sheetID, cellID string, policy DeletePolicy) (*models.Deletion, error) {
+	policy, err := deletePolicyOf(policy)
+	if err != nil {
+		return nil, err
+	}
+	var res *models.Deletion
+	err = s.inTransaction(ctx, func(tx *sql.Tx) (err error) {
+		res, err = s.deleteCell(ctx, tx, sheetID, cellID, policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteSheetTX deletes every cell of a sheet with its settings and scenarios, the result is nil when
+// the sheet has no cells.
+func (s *excelLikeService) DeleteSheetTX(ctx context.Context, sheetID string, policy DeletePolicy) (*models.Deletion, error) {
+	policy, err := deletePolicyOf(policy)
+	if err != nil {
+		return nil, err
+	}
+	var res *models.Deletion
+	err = s.inTransaction(ctx, func(tx *sql.Tx) (err error) {
+		res, err = s.deleteSheet(ctx, tx, sheetID, policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// deletePolicyOf checks policy, DeleteReject is the default.
+func deletePolicyOf(policy DeletePolicy) (DeletePolicy, error) {
+	switch policy {
+	case "":
+		return DeleteReject, nil
+	case DeleteReject, DeleteRef:
+		return policy, nil
+	}
+	return "", fmt.Errorf("%w: %q is neither %q nor %q", ErrInvalidDeletePolicy, policy, DeleteReject, DeleteRef)
+}
+
+// deleteCell is DeleteCellTX within tx.
+func (s *excelLikeService) deleteCell(ctx context.Context, tx *sql.Tx, sheetID, cellID string, policy DeletePolicy) (*models.Deletion, error) {
+	if err := s.loadGraph(ctx, tx); err != nil {
+		return nil, err
+	}
+	cell := db.CellRef{SheetID: sheetID, CellID: cellID}
+	if _, ok := s.graph.value(cell); !ok {
+		return nil, nil
+	}
+	return s.deleteCells(ctx, tx, sheetID, []db.CellRef{cell}, policy, func() error {
+		_, err := s.storage.DeleteCell(ctx, tx, sheetID, cellID)
+		return err
+	})
+}
+
+// deleteSheet is DeleteSheetTX within tx.
+func (s *excelLikeService) deleteSheet(ctx context.Context, tx *sql.Tx, sheetID string, policy DeletePolicy) (*models.Deletion, error) {
+	if err := s.loadGraph(ctx, tx); err != nil {
+		return nil, err
+	}
+	cells := s.graph.sheetCells(sheetID)
+	if len(cells) == 0 {
+		return nil, nil
+	}
+	return s.deleteCells(ctx, tx, sheetID, cells, policy, func() error {
+		_, err := s.storage.DeleteSheet(ctx, tx, sheetID)
+		return err
+	})
+}
+
+// deleteCells removes cells from storage with remove and from the graph, then recalculates the cells
+// using them. With DeleteReject nothing is removed when there are such cells.
+func (s *excelLikeService) deleteCells(ctx context.Context, tx *sql.Tx, sheetID string, cells []db.CellRef, policy DeletePolicy, remove func() error) (*models.Deletion, error) {
+	dependents := s.deletedDependents(cells)
+	if len(dependents) > 0 && policy == DeleteReject {
+		formatted := make([]string, len(dependents))
+		for i, ref := range dependents {
+			formatted[i] = formatRef(ref, sheetID)
+		}
+		return nil, &DependentsError{Cells: formatted}
+	}
+
+	if err := remove(); err != nil {
+		return nil, err
+	}
+	for _, cell := range cells {
+		s.graph.remove(cell)
+	}
+	// formulas keep referencing the cells, so they are marked until written again
+	var referenced []db.CellRef
+	for _, cell := range cells {
+		if len(s.graph.dependents[cell]) > 0 {
+			referenced = append(referenced, cell)
+		}
+	}
+	if len(referenced) > 0 {
+		if err := s.storage.MarkDeletedCells(ctx, tx, referenced); err != nil {
+			return nil, err
+		}
+		for _, cell := range referenced {
+			s.graph.markDeleted(cell, true)
+		}
+	}
+	calc := newCalculation()
+	if err := s.recalculate(ctx, tx, calc, dependents); err != nil {
+		return nil, err
+	}
+	recalculated, err := s.recalculatedCells(ctx, tx, calc.recalculated)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Deletion{Deleted: len(cells), Dependents: recalculated}, nil
+}
+
+// deletedDependents lists the cells using any of cells directly, except cells themselves, sorted.
+func (s *excelLikeService) deletedDependents(cells []db.CellRef) []db.CellRef {
+	deleted := make(map[db.CellRef]bool, len(cells))
+	for _, cell := range cells {
+		deleted[cell] = true
+	}
+	var res []db.CellRef
+	seen := make(map[db.CellRef]bool)
+	for _, cell := range cells {
+		for _, dependent := range s.graph.directDependents(cell) {
+			if _, ok := s.graph.value(dependent); !ok || deleted[dependent] || seen[dependent] {
+				continue
+			}
+			seen[dependent] = true
+			res = append(res, dependent)
+		}
+	}
+	sortCells(res)
+	return res
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+	"dev-challenge/internal/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deletePolicyOf(t *testing.T) {
+	policy, err := deletePolicyOf("")
+	assert.NoError(t, err)
+	assert.Equal(t, DeleteReject, policy)
+	policy, err = deletePolicyOf(DeleteRef)
+	assert.NoError(t, err)
+	assert.Equal(t, DeleteRef, policy)
+	_, err = deletePolicyOf("cascade")
+	assert.EqualError(t, err, `invalid delete policy: "cascade" is neither "reject" nor "ref"`)
+}
+
+func TestExcelLikeService_deleteCell(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+	storage.EXPECT().GetSheetSettings(gomock.Any(), tx, gomock.Any()).DoAndReturn(func(ctx context.Context, tx *sql.Tx, sheetID string) (db.SheetSettings, error) {
+		settings, err := defaultSettings(ctx, tx, sheetID)
+		if sheetID == "totals" {
+			settings.MissingRefs = string(MissingAsZero)
+		}
+		return settings, err
+	}).AnyTimes()
+	graph := []db.Input{
+		formulaCell("a"),
+		formulaCell("lone"),
+		formulaCell("rate"),
+		{SheetID: "sheet1", CellID: "b", Value: "=a*10", UsedParams: sheet1Cells("a")},
+		{SheetID: "sheet2", CellID: "c", Value: "=sheet1!a+1", UsedParams: sheet1Cells("a")},
+		{SheetID: "totals", CellID: "d", Value: "=sheet1!rate*2", UsedParams: sheet1Cells("rate")},
+	}
+
+	tests := []struct {
+		name          string
+		cellID        string
+		policy        DeletePolicy
+		mockBehavior  func()
+		expected      *models.Deletion
+		expectedError string
+	}{
+		{
+			name:   "Without dependents",
+			cellID: "lone",
+			policy: DeleteReject,
+			mockBehavior: func() {
+				storage.EXPECT().DeleteCell(gomock.Any(), tx, "sheet1", "lone").Return(true, nil)
+			},
+			expected: &models.Deletion{Deleted: 1, Dependents: []models.RecalculatedCell{}},
+		},
+		{
+			name:          "Rejected",
+			cellID:        "a",
+			policy:        DeleteReject,
+			mockBehavior:  func() {},
+			expectedError: "cells depend on the deleted cells: b, sheet2!c",
+		},
+		{
+			name:   "Dependents become #REF!",
+			cellID: "a",
+			policy: DeleteRef,
+			mockBehavior: func() {
+				storage.EXPECT().DeleteCell(gomock.Any(), tx, "sheet1", "a").Return(true, nil)
+				storage.EXPECT().MarkDeletedCells(gomock.Any(), tx, sheet1Cells("a")).Return(nil)
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"a"}).Return(map[string]db.Input{}, nil).Times(2)
+				storage.EXPECT().SaveCellResult(gomock.Any(), tx, gomock.Any()).DoAndReturn(func(_ context.Context, _ *sql.Tx, input db.Input) error {
+					assert.Equal(t, "#REF!", input.ResultText)
+					return nil
+				}).Times(2)
+				for _, cell := range []db.Input{graph[3], graph[4]} {
+					stored := cell
+					stored.ResultType, stored.ResultText, stored.ResultError = "error", "#REF!", "referenced cell \"sheet1!a\" was deleted"
+					storage.EXPECT().GetInput(gomock.Any(), tx, cell.SheetID, cell.CellID).Return(&stored, nil)
+				}
+			},
+			expected: &models.Deletion{Deleted: 1, Dependents: []models.RecalculatedCell{
+				{Sheet: "sheet1", ID: "b", Cell: models.Cell{Value: "=a*10", Type: "error", Formatted: "#REF!",
+					Result: models.CellError{Code: "#REF!", Message: "referenced cell \"sheet1!a\" was deleted"}}},
+				{Sheet: "sheet2", ID: "c", Cell: models.Cell{Value: "=sheet1!a+1", Type: "error", Formatted: "#REF!",
+					Result: models.CellError{Code: "#REF!", Message: "referenced cell \"sheet1!a\" was deleted"}}},
+			}},
+		},
+		{
+			name:   "Dependents counting missing cells as 0 become #REF!",
+			cellID: "rate",
+			policy: DeleteRef,
+			mockBehavior: func() {
+				storage.EXPECT().DeleteCell(gomock.Any(), tx, "sheet1", "rate").Return(true, nil)
+				storage.EXPECT().MarkDeletedCells(gomock.Any(), tx, sheet1Cells("rate")).Return(nil)
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"rate"}).Return(map[string]db.Input{}, nil)
+				storage.EXPECT().SaveCellResult(gomock.Any(), tx, gomock.Any()).DoAndReturn(func(_ context.Context, _ *sql.Tx, input db.Input) error {
+					assert.Equal(t, "#REF!", input.ResultText)
+					assert.Equal(t, "referenced cell \"sheet1!rate\" was deleted", input.ResultError)
+					return nil
+				})
+				stored := graph[5]
+				stored.ResultType, stored.ResultText, stored.ResultError = "error", "#REF!", "referenced cell \"sheet1!rate\" was deleted"
+				storage.EXPECT().GetInput(gomock.Any(), tx, "totals", "d").Return(&stored, nil)
+			},
+			expected: &models.Deletion{Deleted: 1, Dependents: []models.RecalculatedCell{
+				{Sheet: "totals", ID: "d", Cell: models.Cell{Value: "=sheet1!rate*2", Type: "error", Formatted: "#REF!",
+					Result: models.CellError{Code: "#REF!", Message: "referenced cell \"sheet1!rate\" was deleted"}}},
+			}},
+		},
+		{
+			name:         "Missing cell",
+			cellID:       "zz",
+			policy:       DeleteRef,
+			mockBehavior: func() {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &excelLikeService{storage: storage, functions: NewDefaultFunctionRegistry(), graph: graphOf(graph...)}
+			tt.mockBehavior()
+			got, err := s.deleteCell(context.TODO(), tx, "sheet1", tt.cellID, tt.policy)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestExcelLikeService_deleteCell_laterWrites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+	storage.EXPECT().GetSheetSettings(gomock.Any(), tx, "sheet1").DoAndReturn(func(ctx context.Context, tx *sql.Tx, sheetID string) (db.SheetSettings, error) {
+		settings, err := defaultSettings(ctx, tx, sheetID)
+		settings.MissingRefs = string(MissingAsZero)
+		return settings, err
+	}).AnyTimes()
+	s := &excelLikeService{storage: storage, functions: NewDefaultFunctionRegistry(), graph: graphOf(
+		formulaCell("a"),
+		formulaCell("b"),
+		formulaCell("c", "a", "b"),
+	)}
+	var results []string
+	storage.EXPECT().SaveCellResult(gomock.Any(), tx, gomock.Any()).DoAndReturn(func(_ context.Context, _ *sql.Tx, input db.Input) error {
+		assert.Equal(t, "c", input.CellID)
+		results = append(results, input.ResultText)
+		return nil
+	}).Times(2)
+	storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"a", "b"}).Return(numberInputs(map[string]float64{"b": 1}), nil)
+	storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"a"}).Return(map[string]db.Input{}, nil)
+
+	storage.EXPECT().DeleteCell(gomock.Any(), tx, "sheet1", "a").Return(true, nil)
+	storage.EXPECT().MarkDeletedCells(gomock.Any(), tx, sheet1Cells("a")).Return(nil)
+	storage.EXPECT().GetInput(gomock.Any(), tx, "sheet1", "c").Return(&db.Input{SheetID: "sheet1", CellID: "c", Value: "=a+b"}, nil)
+	_, err := s.deleteCell(context.TODO(), tx, "sheet1", "a", DeleteRef)
+	assert.NoError(t, err)
+	s.graph.commit()
+
+	// a write of another cell c uses recalculates it, the deleted one still counts as #REF! and not as 0
+	storage.EXPECT().AddCellInput(gomock.Any(), tx, gomock.Any()).Return(&models.Data{Value: "5", Result: "5"}, false, nil)
+	_, err = s.addCellInput(context.TODO(), tx, newCalculation(), "sheet1", "b", &models.Data{Value: "5"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"#REF!", "#REF!"}, results)
+}
+
+func TestExcelLikeService_deleteSheet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+	graph := []db.Input{
+		formulaCell("a"),
+		{SheetID: "sheet1", CellID: "b", Value: "=a*10", UsedParams: sheet1Cells("a")},
+		{SheetID: "sheet2", CellID: "c", Value: "=sheet1!a+1", UsedParams: sheet1Cells("a")},
+	}
+
+	s := &excelLikeService{storage: storage, functions: NewDefaultFunctionRegistry(), graph: graphOf(graph...)}
+	// cells of the sheet using each other don't count
+	_, err := s.deleteSheet(context.TODO(), tx, "sheet1", DeleteReject)
+	assert.EqualError(t, err, "cells depend on the deleted cells: sheet2!c")
+
+	got, err := s.deleteSheet(context.TODO(), tx, "sheet3", DeleteReject)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	storage.EXPECT().DeleteSheet(gomock.Any(), tx, "sheet2").Return(1, nil)
+	got, err = s.deleteSheet(context.TODO(), tx, "sheet2", DeleteReject)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Deletion{Deleted: 1, Dependents: []models.RecalculatedCell{}}, got)
+	assert.Equal(t, sheet1Cells("a", "b"), s.graph.sheetCells("sheet1"))
+	assert.Empty(t, s.graph.sheetCells("sheet2"))
+}
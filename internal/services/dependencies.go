@@ -0,0 +1,51 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"dev-challenge/db"
+)
+
+// CircularReferenceError is returned when a formula would make a cell depend on itself.
+// Path starts and ends with the written cell, i.e. [a b a], cells of other sheets are written as sheet!cell.
+type CircularReferenceError struct {
+	Path []string
+}
+
+func (e *CircularReferenceError) Error() string {
+	return fmt.Sprintf("circular reference: %s", strings.Join(e.Path, " -> "))
+}
+
+// findCycle looks for a path from any of refs back to cellID. graph maps every cell to the cells
+// its current formula references, refs are the references of the formula about to be written to cellID.
+func findCycle(graph map[db.CellRef][]db.CellRef, cellID db.CellRef, refs []db.CellRef) []db.CellRef {
+	visited := make(map[db.CellRef]bool)
+	var path []db.CellRef
+
+	var visit func(id db.CellRef) bool
+	visit = func(id db.CellRef) bool {
+		path = append(path, id)
+		if id == cellID {
+			return true
+		}
+		if !visited[id] {
+			visited[id] = true
+			for _, next := range graph[id] {
+				if visit(next) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+
+	for _, ref := range refs {
+		path = []db.CellRef{cellID}
+		if visit(ref) {
+			return path
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"dev-challenge/db"
+)
+
+func Test_findCycle(t *testing.T) {
+	type args struct {
+		graph  map[string][]string
+		cellID string
+		refs   []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "Two cells",
+			args: args{
+				graph:  map[string][]string{"a": {"b"}},
+				cellID: "b",
+				refs:   []string{"a"},
+			},
+			want: []string{"b", "a", "b"},
+		},
+		{
+			name: "Long chain",
+			args: args{
+				graph:  map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"d"}},
+				cellID: "d",
+				refs:   []string{"x", "a"},
+			},
+			want: []string{"d", "a", "b", "c", "d"},
+		},
+		{
+			name: "Across sheets",
+			args: args{
+				graph:  map[string][]string{"other!a": {"b"}},
+				cellID: "b",
+				refs:   []string{"other!a"},
+			},
+			want: []string{"b", "other!a", "b"},
+		},
+		{
+			name: "Same cell name on other sheet",
+			args: args{
+				graph:  map[string][]string{"a": {"other!b"}},
+				cellID: "b",
+				refs:   []string{"a"},
+			},
+			want: nil,
+		},
+		{
+			name: "Diamond without cycle",
+			args: args{
+				graph:  map[string][]string{"a": {"b", "c"}, "b": {"d"}, "c": {"d"}},
+				cellID: "e",
+				refs:   []string{"a", "b"},
+			},
+			want: nil,
+		},
+		{
+			name: "Existing formula of the cell is ignored",
+			args: args{
+				graph:  map[string][]string{"a": {"b"}, "b": {"c"}},
+				cellID: "a",
+				refs:   []string{"c"},
+			},
+			want: nil,
+		},
+		{
+			name: "No refs",
+			args: args{
+				graph:  map[string][]string{"a": {"b"}},
+				cellID: "b",
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := make(map[db.CellRef][]db.CellRef)
+			for from, to := range tt.args.graph {
+				for _, ref := range to {
+					graph[parseTestRef("sheet1", from)] = append(graph[parseTestRef("sheet1", from)], parseTestRef("sheet1", ref))
+				}
+			}
+			refs := make([]db.CellRef, 0, len(tt.args.refs))
+			for _, ref := range tt.args.refs {
+				refs = append(refs, parseTestRef("sheet1", ref))
+			}
+
+			var got []string
+			for _, cell := range findCycle(graph, parseTestRef("sheet1", tt.args.cellID), refs) {
+				got = append(got, formatRef(cell, "sheet1"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findCycle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
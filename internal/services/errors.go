@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode is a spreadsheet error shown as the result of a cell that can't be calculated.
+type ErrorCode string
+
+const (
+	ErrDivZero  ErrorCode = "#DIV/0!"
+	ErrRef      ErrorCode = "#REF!"
+	ErrName     ErrorCode = "#NAME?"
+	ErrValue    ErrorCode = "#VALUE!"
+	ErrCircular ErrorCode = "#CIRCULAR!"
+	ErrNum      ErrorCode = "#NUM!"
+)
+
+// FormulaError is a typed error raised while parsing or evaluating a formula. Cell is set when the error
+// comes from another cell, like "sheet1!b", and is empty when the formula itself failed.
+// Position is where a syntax error is in the cell value, 0 when unknown.
+type FormulaError struct {
+	Code     ErrorCode
+	Message  string
+	Cell     string
+	Position int
+}
+
+func (e *FormulaError) Error() string {
+	if e.Cell == "" {
+		return e.Message
+	}
+	return e.Cell + ": " + e.Message
+}
+
+// SyntaxError is a formula that can't be parsed, Position is where the problem is in the cell value.
+type SyntaxError struct {
+	Position int
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Message
+}
+
+func syntaxErrorf(pos int, format string, args ...any) *SyntaxError {
+	return &SyntaxError{Position: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func newFormulaError(code ErrorCode, format string, args ...any) *FormulaError {
+	return &FormulaError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// asFormulaError types any evaluation error. Wrapped FormulaErrors keep their code, errors coming from
+// other cells are returned as is, while errors of custom functions become #VALUE!.
+func asFormulaError(err error) *FormulaError {
+	var formulaErr *FormulaError
+	if errors.As(err, &formulaErr) {
+		if formulaErr.Cell != "" || formulaErr.Error() == err.Error() {
+			return formulaErr
+		}
+		return &FormulaError{Code: formulaErr.Code, Message: err.Error()}
+	}
+	var cycleErr *CircularReferenceError
+	if errors.As(err, &cycleErr) {
+		return &FormulaError{Code: ErrCircular, Message: err.Error()}
+	}
+	return &FormulaError{Code: ErrValue, Message: err.Error()}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"dev-challenge/db"
+)
+
+func Test_errorCodes(t *testing.T) {
+	failed := Value{Type: TypeError, Err: &FormulaError{Code: ErrNum, Message: "square root of negative number", Cell: "sheet1!x"}}
+	tests := []struct {
+		name     string
+		expr     string
+		values   map[string]any
+		wantCode ErrorCode
+		wantErr  string
+	}{
+		{name: "Division by zero", expr: "=1/(a-1)", values: map[string]any{"a": 1}, wantCode: ErrDivZero, wantErr: "division by zero"},
+		{name: "Missing reference", expr: "=a+1", wantCode: ErrRef, wantErr: `referenced cell "a" not found`},
+		{name: "Unknown function", expr: "=FOO(1)", wantCode: ErrName, wantErr: `unknown function "foo" at position 1`},
+		{name: "Text in arithmetic", expr: "=a*2", values: map[string]any{"a": "n/a"}, wantCode: ErrValue, wantErr: `text "n/a" is not a number`},
+		{name: "Wrapped argument error", expr: `=ABS("x")`, wantCode: ErrValue, wantErr: `argument 1 of ABS: text "x" is not a number`},
+		{name: "Square root of negative", expr: "=SQRT(-1)", wantCode: ErrNum, wantErr: "square root of negative number"},
+		{name: "Referenced error", expr: "=x+1", wantCode: ErrNum, wantErr: "sheet1!x: square root of negative number"},
+		{name: "Error inside a range", expr: "=SUM(a1:a3)", values: map[string]any{"a1": 1}, wantCode: ErrNum, wantErr: "sheet1!x: square root of negative number"},
+		{name: "Error in the branch taken", expr: "=IF(TRUE, x, 0)", wantCode: ErrNum, wantErr: "sheet1!x: square root of negative number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseFormula(normalizeValue(tt.expr))
+			if err != nil {
+				t.Fatalf("parseFormula() error = %v", err)
+			}
+			values := sheetValues("sheet1", tt.values)
+			values[db.CellRef{SheetID: "sheet1", CellID: "x"}] = failed
+			values[db.CellRef{SheetID: "sheet1", CellID: "a2"}] = failed
+			_, err = (&evaluator{sheetID: "sheet1", values: values, functions: NewDefaultFunctionRegistry()}).eval(root)
+			if err == nil {
+				t.Fatal("eval() error = nil")
+			}
+			got := asFormulaError(err)
+			if got.Code != tt.wantCode || got.Error() != tt.wantErr {
+				t.Errorf("asFormulaError() = %v %q, want %v %q", got.Code, got.Error(), tt.wantCode, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_errorValueSkippedBranch(t *testing.T) {
+	root, err := parseFormula("=if(false, x, 2)")
+	if err != nil {
+		t.Fatalf("parseFormula() error = %v", err)
+	}
+	values := map[db.CellRef]Value{{SheetID: "sheet1", CellID: "x"}: {Type: TypeError, Err: &FormulaError{Code: ErrRef}}}
+	got, err := (&evaluator{sheetID: "sheet1", values: values, functions: NewDefaultFunctionRegistry()}).eval(root)
+	if err != nil || got != NumberValue(2) {
+		t.Errorf("eval() = %v, %v, want 2", got, err)
+	}
+}
+
+func Test_asFormulaError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode ErrorCode
+		wantCell string
+		wantMsg  string
+	}{
+		{name: "Plain error", err: errors.New("custom failure"), wantCode: ErrValue, wantMsg: "custom failure"},
+		{name: "Wrapped formula error", err: fmt.Errorf("argument 2 of F: %w", newFormulaError(ErrNum, "out of range")), wantCode: ErrNum, wantMsg: "argument 2 of F: out of range"},
+		{name: "Error of another cell", err: fmt.Errorf("wrapped: %w", &FormulaError{Code: ErrRef, Message: "gone", Cell: "s!a"}), wantCode: ErrRef, wantCell: "s!a", wantMsg: "gone"},
+		{name: "Circular reference", err: &CircularReferenceError{Path: []string{"a", "a"}}, wantCode: ErrCircular, wantMsg: "circular reference: a -> a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := asFormulaError(tt.err)
+			if got.Code != tt.wantCode || got.Cell != tt.wantCell || got.Message != tt.wantMsg {
+				t.Errorf("asFormulaError() = %+v, want %v %q %q", got, tt.wantCode, tt.wantCell, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+// ExplainCell evaluates the stored formula of a cell again tracing every node of it, it returns nil
+// when the cell doesn't exist. Referenced cells are taken with their stored results, nothing is written.
+func (s *excelLikeService) ExplainCell(ctx context.Context, sheetID, cellID string) (*models.Explanation, error) {
+	tx, err := s.storage.BeginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var res *models.Explanation
+	var explainErr error
+	if err := s.readGraph(ctx, func() {
+		res, explainErr = s.explainCell(ctx, tx, sheetID, cellID)
+	}); err != nil {
+		return nil, err
+	}
+	return res, explainErr
+}
+
+func (s *excelLikeService) explainCell(ctx context.Context, tx *sql.Tx, sheetID, cellID string) (*models.Explanation, error) {
+	input, err := s.storage.GetInput(ctx, tx, sheetID, cellID)
+	if err != nil || input == nil {
+		return nil, err
+	}
+	formula, err := parseFormula(input.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	cell := db.CellRef{SheetID: sheetID, CellID: cellID}
+	deps := extractDependencies(formula, sheetID)
+	e, settings, err := s.newEvaluator(ctx, tx, newCalculation(), cell, deps)
+	if err != nil {
+		return nil, err
+	}
+	e.trace = make(map[node]tracedValue)
+	result, err := e.eval(formula)
+
+	res := &models.Explanation{
+		Sheet:      sheetID,
+		ID:         cellID,
+		Value:      input.Value,
+		Formula:    e.explainNode(formula),
+		References: e.explainReferences(deps),
+	}
+	switch {
+	case err != nil:
+		formulaErr := asFormulaError(err)
+		origin := e.errorOrigin(formula)
+		res.Result, res.Type = typedResult(Value{Type: TypeError, Err: formulaErr}), string(TypeError)
+		res.Error = &models.ExplainError{
+			Code:       string(formulaErr.Code),
+			Message:    formulaErr.Message,
+			Cell:       formulaErr.Cell,
+			Expression: formatNode(origin, sheetID),
+			Position:   origin.Pos(),
+		}
+	case result.Dec != nil:
+		rounded := roundDecimal(result.Dec, settings.Precision, RoundingMode(settings.Rounding))
+		res.Result, res.Type = json.Number(rounded.FloatString(settings.Precision)), string(TypeNumber)
+	default:
+		res.Result, res.Type = typedResult(result), string(result.Type)
+	}
+	return res, nil
+}
+
+// explainNode renders the formula tree of n with the traced value of every node.
+func (e *evaluator) explainNode(n node) models.ExplainNode {
+	res := models.ExplainNode{Kind: nodeKind(n), Expression: formatNode(n, e.sheetID), Position: n.Pos()}
+	if traced, ok := e.trace[n]; ok {
+		res.Evaluated = true
+		value := traced.value
+		if traced.err != nil {
+			value = Value{Type: TypeError, Err: asFormulaError(traced.err)}
+		}
+		if value.Type != "" {
+			res.Result, res.Type = typedResult(value), string(value.Type)
+		}
+	}
+	for _, child := range nodeChildren(n) {
+		res.Children = append(res.Children, e.explainNode(child))
+	}
+	return res
+}
+
+// explainReferences lists the referenced cells, the existing cells of ranges and the cells matching patterns
+// with their values.
+func (e *evaluator) explainReferences(deps db.Dependencies) []models.ExplainedCell {
+	groups := make([][]db.CellRef, 0, len(deps.Ranges)+len(deps.Patterns))
+	for _, cell := range withRangeCells(nil, deps.Ranges) {
+		if _, ok := e.values[cell]; ok {
+			groups = append(groups, []db.CellRef{cell})
+		}
+	}
+	for _, pattern := range deps.Patterns {
+		groups = append(groups, e.patterns[pattern])
+	}
+
+	res := []models.ExplainedCell{}
+	for _, cell := range appendCells(deps.Cells, groups...) {
+		explained := models.ExplainedCell{Sheet: cell.SheetID, ID: cell.CellID}
+		if value, ok := e.values[cell]; ok {
+			explained.Result, explained.Type = typedResult(value), string(value.Type)
+		} else {
+			explained.Missing = true
+		}
+		res = append(res, explained)
+	}
+	return res
+}
+
+// errorOrigin follows the failed nodes of the traced tree n down to the innermost one, where the error was raised.
+func (e *evaluator) errorOrigin(n node) node {
+	for _, child := range nodeChildren(n) {
+		if traced, ok := e.trace[child]; ok && traced.err != nil {
+			return e.errorOrigin(child)
+		}
+	}
+	return n
+}
+
+func nodeKind(n node) string {
+	switch n.(type) {
+	case *numberNode:
+		return "number"
+	case *boolNode:
+		return "boolean"
+	case *textNode:
+		return "text"
+	case *refNode:
+		return "reference"
+	case *rangeNode:
+		return "range"
+	case *patternNode:
+		return "pattern"
+	case *unaryNode, *binaryNode:
+		return "operator"
+	case *callNode:
+		return "function"
+	}
+	return "unknown"
+}
+
+// formatNode renders the formula tree n the way it would be written on sheetID, parentheses are added
+// only where the precedence of operators requires them.
+func formatNode(n node, sheetID string) string {
+	switch n := n.(type) {
+	case *numberNode:
+		if n.text != "" {
+			return n.text
+		}
+		return strconv.FormatFloat(n.value, 'f', -1, 64)
+	case *boolNode:
+		return BoolValue(n.value).AsText()
+	case *textNode:
+		return `"` + strings.ReplaceAll(n.value, `"`, `""`) + `"`
+	case *refNode:
+		cell := resolveRef(n, sheetID)
+		if cell.SheetID == sheetID {
+			return quoteRef(cell.CellID)
+		}
+		return quoteRef(cell.SheetID) + "!" + quoteRef(cell.CellID)
+	case *rangeNode:
+		return formatRange(resolveRange(n, sheetID), sheetID)
+	case *patternNode:
+		return formatPattern(resolvePattern(n, sheetID), sheetID)
+	case *unaryNode:
+		operand := formatNode(n.operand, sheetID)
+		if _, ok := n.operand.(*binaryNode); ok {
+			operand = "(" + operand + ")"
+		}
+		return n.op + operand
+	case *binaryNode:
+		left, right := formatNode(n.left, sheetID), formatNode(n.right, sheetID)
+		if l, ok := n.left.(*binaryNode); ok && precedence(l.op) < precedence(n.op) {
+			left = "(" + left + ")"
+		}
+		if r, ok := n.right.(*binaryNode); ok && precedence(r.op) <= precedence(n.op) {
+			right = "(" + right + ")"
+		}
+		return left + n.op + right
+	case *callNode:
+		args := make([]string, len(n.args))
+		for i, arg := range n.args {
+			args[i] = formatNode(arg, sheetID)
+		}
+		return strings.ToUpper(n.name) + "(" + strings.Join(args, ", ") + ")"
+	}
+	return ""
+}
+
+// precedence of binary operators, higher binds tighter.
+func precedence(op string) int {
+	switch op {
+	case "&":
+		return 2
+	case "+", "-":
+		return 3
+	case "*", "/":
+		return 4
+	}
+	return 1
+}
+
+// quoteRef quotes a sheet or cell ID that isn't a plain word, like 'a+b'.
+func quoteRef(id string) string {
+	for _, r := range id {
+		if !isWordRune(r) {
+			return "'" + strings.ReplaceAll(id, "'", "''") + "'"
+		}
+	}
+	return id
+}
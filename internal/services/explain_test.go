@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+	"dev-challenge/internal/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_formatNode(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "=a*(3+b)-sqrt(a)", expected: "a*(3+b)-SQRT(a)"},
+		{value: "=a-(b-c)", expected: "a-(b-c)"},
+		{value: "=(a-b)-c", expected: "a-b-c"},
+		{value: "=-(a+1)*2", expected: "-(a+1)*2"},
+		{value: "=(a&b)=\"x\"\"y\"", expected: "a&b=\"x\"\"y\""},
+		{value: "='a+b'*sheet2!x", expected: "'a+b'*sheet2!x"},
+		{value: "=sheet1!a+true", expected: "a+TRUE"},
+		{value: "=sum(a1:b2, sheet2!sales_*)", expected: "SUM(a1:b2, sheet2!sales_*)"},
+		{value: "12.5", expected: "12.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			formula, err := parseFormula(tt.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, formatNode(formula, "sheet1"))
+		})
+	}
+}
+
+func TestExcelLikeService_explainCell(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+	s := &excelLikeService{storage: storage, functions: NewDefaultFunctionRegistry(), graph: graphOf()}
+	storage.EXPECT().GetSheetSettings(gomock.Any(), tx, gomock.Any()).DoAndReturn(defaultSettings).AnyTimes()
+
+	tests := []struct {
+		name         string
+		cellID       string
+		mockBehavior func()
+		expected     *models.Explanation
+	}{
+		{
+			name:   "Error raised by a referenced cell",
+			cellID: "c",
+			mockBehavior: func() {
+				storage.EXPECT().GetInput(gomock.Any(), tx, "sheet1", "c").Return(&db.Input{SheetID: "sheet1", CellID: "c", Value: "=a+b*2"}, nil)
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"a", "b"}).Return(map[string]db.Input{
+					"a": {CellID: "a", Value: "1", Result: 1, ResultType: "number"},
+					"b": {CellID: "b", Value: "=1/0", ResultType: "error", ResultText: "#DIV/0!", ResultError: "division by zero"},
+				}, nil)
+			},
+			expected: &models.Explanation{
+				Sheet: "sheet1", ID: "c", Value: "=a+b*2", Type: "error",
+				Result: models.CellError{Code: "#DIV/0!", Message: "sheet1!b: division by zero"},
+				Formula: models.ExplainNode{
+					Kind: "operator", Expression: "a+b*2", Position: 2, Evaluated: true, Type: "error",
+					Result: models.CellError{Code: "#DIV/0!", Message: "sheet1!b: division by zero"},
+					Children: []models.ExplainNode{
+						{Kind: "reference", Expression: "a", Position: 1, Evaluated: true, Result: float64(1), Type: "number"},
+						{
+							Kind: "operator", Expression: "b*2", Position: 4, Evaluated: true, Type: "error",
+							Result: models.CellError{Code: "#DIV/0!", Message: "sheet1!b: division by zero"},
+							Children: []models.ExplainNode{
+								{Kind: "reference", Expression: "b", Position: 3, Evaluated: true, Type: "error",
+									Result: models.CellError{Code: "#DIV/0!", Message: "sheet1!b: division by zero"}},
+								{Kind: "number", Expression: "2", Position: 5},
+							},
+						},
+					},
+				},
+				References: []models.ExplainedCell{
+					{Sheet: "sheet1", ID: "a", Result: float64(1), Type: "number"},
+					{Sheet: "sheet1", ID: "b", Result: models.CellError{Code: "#DIV/0!", Message: "sheet1!b: division by zero"}, Type: "error"},
+				},
+				Error: &models.ExplainError{Code: "#DIV/0!", Message: "division by zero", Cell: "sheet1!b", Expression: "b", Position: 3},
+			},
+		},
+		{
+			name:   "Branch not taken and range",
+			cellID: "d",
+			mockBehavior: func() {
+				storage.EXPECT().GetInput(gomock.Any(), tx, "sheet1", "d").Return(&db.Input{SheetID: "sheet1", CellID: "d", Value: "=if(sum(a1:a2)>1, \"big\", zz)"}, nil)
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"zz", "a1", "a2"}).Return(map[string]db.Input{
+					"a2": {CellID: "a2", Value: "5", Result: 5, ResultType: "number"},
+				}, nil)
+			},
+			expected: &models.Explanation{
+				Sheet: "sheet1", ID: "d", Value: "=if(sum(a1:a2)>1, \"big\", zz)", Result: "big", Type: "text",
+				Formula: models.ExplainNode{
+					Kind: "function", Expression: "IF(SUM(a1:a2)>1, \"big\", zz)", Position: 1, Evaluated: true, Result: "big", Type: "text",
+					Children: []models.ExplainNode{
+						{
+							Kind: "operator", Expression: "SUM(a1:a2)>1", Position: 14, Evaluated: true, Result: true, Type: "boolean",
+							Children: []models.ExplainNode{
+								{
+									Kind: "function", Expression: "SUM(a1:a2)", Position: 4, Evaluated: true, Result: float64(5), Type: "number",
+									Children: []models.ExplainNode{{Kind: "range", Expression: "a1:a2", Position: 8, Evaluated: true}},
+								},
+								{Kind: "number", Expression: "1", Position: 15, Evaluated: true, Result: float64(1), Type: "number"},
+							},
+						},
+						{Kind: "text", Expression: "\"big\"", Position: 18, Evaluated: true, Result: "big", Type: "text"},
+						{Kind: "reference", Expression: "zz", Position: 25},
+					},
+				},
+				References: []models.ExplainedCell{
+					{Sheet: "sheet1", ID: "zz", Missing: true},
+					{Sheet: "sheet1", ID: "a2", Result: float64(5), Type: "number"},
+				},
+			},
+		},
+		{
+			name:   "Cell not found",
+			cellID: "missing",
+			mockBehavior: func() {
+				storage.EXPECT().GetInput(gomock.Any(), tx, "sheet1", "missing").Return(nil, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior()
+			got, err := s.explainCell(context.TODO(), tx, "sheet1", tt.cellID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// formatNumber renders number by a spreadsheet like mask: "0" is a mandatory digit, "#" an optional one,
+// "," groups thousands, "." separates decimals and "%" multiplies the number by 100. Characters around
+// the digits are copied as is, i.e. "$#,##0.00" or "0.0 kg". Numbers are rounded half away from zero.
+// An exponent like "E+00" right after the digits writes the number in scientific notation, i.e. "0.00E+00".
+func formatNumber(number float64, mask string) (string, error) {
+	start := strings.IndexAny(mask, "0#")
+	if start < 0 {
+		return "", fmt.Errorf("invalid format %q", mask)
+	}
+	if start > 0 && mask[start-1] == '.' {
+		start--
+	}
+	end := start
+	for end < len(mask) && strings.ContainsRune("0#,.", rune(mask[end])) {
+		end++
+	}
+	prefix, digits, suffix := mask[:start], mask[start:end], mask[end:]
+	intMask, decMask, _ := strings.Cut(digits, ".")
+	if strings.Contains(decMask, ".") {
+		return "", fmt.Errorf("invalid format %q", mask)
+	}
+	if sign, expDigits, rest, ok := cutExponent(suffix); ok {
+		return formatScientific(number, prefix+digits, sign, expDigits, rest)
+	}
+	decMask = strings.ReplaceAll(decMask, ",", "")
+	if strings.Contains(prefix+suffix, "%") {
+		number *= 100
+	}
+
+	pow := math.Pow(10, float64(len(decMask)))
+	rounded := math.Round(math.Abs(number)*pow) / pow
+	intPart, decPart, _ := strings.Cut(strconv.FormatFloat(rounded, 'f', len(decMask), 64), ".")
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if minInt := strings.Count(intMask, "0"); len(intPart) < minInt {
+		intPart = strings.Repeat("0", minInt-len(intPart)) + intPart
+	}
+	if strings.Contains(intMask, ",") {
+		intPart = groupThousands(intPart)
+	}
+	decPart = strings.TrimRight(decPart, "0")
+	if minDec := strings.Count(decMask, "0"); len(decPart) < minDec {
+		decPart += strings.Repeat("0", minDec-len(decPart))
+	}
+
+	res := prefix + intPart
+	if decPart != "" {
+		res += "." + decPart
+	}
+	res += suffix
+	if number < 0 && rounded != 0 {
+		res = "-" + res
+	}
+	return res, nil
+}
+
+// cutExponent splits an exponent like "E+00" off the part of a mask following the digits. sign is '+'
+// when positive exponents are written with a plus and expDigits the minimum number of exponent digits.
+func cutExponent(suffix string) (sign byte, expDigits int, rest string, ok bool) {
+	if len(suffix) < 3 || (suffix[0] != 'E' && suffix[0] != 'e') || (suffix[1] != '+' && suffix[1] != '-') {
+		return 0, 0, "", false
+	}
+	rest = strings.TrimLeft(suffix[2:], "0")
+	expDigits = len(suffix) - 2 - len(rest)
+	return suffix[1], expDigits, rest, expDigits > 0
+}
+
+// formatScientific writes number as a mantissa formatted by mantissaMask followed by its exponent.
+func formatScientific(number float64, mantissaMask string, sign byte, expDigits int, suffix string) (string, error) {
+	_, decMask, _ := strings.Cut(mantissaMask, ".")
+	decimals := strings.Count(decMask, "0") + strings.Count(decMask, "#")
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(number, 'e', decimals, 64), "e")
+	m, err := strconv.ParseFloat(mantissa, 64)
+	if err != nil {
+		return "", fmt.Errorf("can't format %v in scientific notation", number)
+	}
+	res, err := formatNumber(m, mantissaMask)
+	if err != nil {
+		return "", err
+	}
+
+	e, _ := strconv.Atoi(exp)
+	expSign := ""
+	switch {
+	case e < 0:
+		expSign, e = "-", -e
+	case sign == '+':
+		expSign = "+"
+	}
+	expText := strconv.Itoa(e)
+	if len(expText) < expDigits {
+		expText = strings.Repeat("0", expDigits-len(expText)) + expText
+	}
+	return res + "E" + expSign + expText + suffix, nil
+}
+
+// groupThousands separates groups of three digits with commas.
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	head := len(digits) % 3
+	if head == 0 {
+		head = 3
+	}
+	groups := []string{digits[:head]}
+	for i := head; i < len(digits); i += 3 {
+		groups = append(groups, digits[i:i+3])
+	}
+	return strings.Join(groups, ",")
+}
@@ -0,0 +1,42 @@
+package services
+
+import "testing"
+
+func Test_formatNumber(t *testing.T) {
+	tests := []struct {
+		number  float64
+		mask    string
+		want    string
+		wantErr bool
+	}{
+		{number: 3.14159, mask: "0.00", want: "3.14"},
+		{number: 2.5, mask: "0", want: "3"},
+		{number: -2.5, mask: "0", want: "-3"},
+		{number: 1234567.891, mask: "#,##0", want: "1,234,568"},
+		{number: 1234.5, mask: "$#,##0.00", want: "$1,234.50"},
+		{number: -1234.5, mask: "$#,##0.00", want: "-$1,234.50"},
+		{number: 0.256, mask: "0.0%", want: "25.6%"},
+		{number: 7, mask: "000", want: "007"},
+		{number: 1.5, mask: "0.##", want: "1.5"},
+		{number: 0.5, mask: "#.00", want: ".50"},
+		{number: 12.345, mask: "0.0 kg", want: "12.3 kg"},
+		{number: -0.001, mask: "0.00", want: "0.00"},
+		{number: 12345.678, mask: "0.00E+00", want: "1.23E+04"},
+		{number: 0.00012, mask: "0.0E+0", want: "1.2E-4"},
+		{number: -99999, mask: "0.00E-00", want: "-1.00E05"},
+		{number: 0, mask: "0.0E+00", want: "0.0E+00"},
+		{number: 1, mask: "text", wantErr: true},
+		{number: 1, mask: "0.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mask, func(t *testing.T) {
+			got, err := formatNumber(tt.number, tt.mask)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("formatNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("formatNumber() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
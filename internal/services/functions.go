@@ -0,0 +1,355 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ArgType is the kind of value a function argument accepts.
+type ArgType string
+
+const (
+	ArgNumber  ArgType = "number"
+	ArgText    ArgType = "text"
+	ArgBoolean ArgType = "boolean"
+	ArgAny     ArgType = "any"
+)
+
+// Arg declares a single function argument.
+type Arg struct {
+	Name     string
+	Type     ArgType
+	Optional bool
+}
+
+// Function is a formula function available to cells. When Variadic is set the last argument
+// may be repeated any number of times and accepts ranges, which expand into the values of their
+// non-empty cells, so Call may get fewer arguments than MinArgs.
+//
+// Numeric functions implement Call, which receives already evaluated arguments converted to numbers.
+// Functions working with text implement CallValues instead, its arguments are converted to the declared
+// ArgType, ArgAny leaves them as is. Functions that must not evaluate every argument, like IF, implement
+// CallLazy, which gets arguments evaluated and converted only when called. Exactly one of them has to be set.
+// Numeric functions may also implement CallDecimal, which sheets in decimal mode call with exact numbers,
+// otherwise they get the result of Call.
+type Function struct {
+	Name        string
+	Description string
+	Args        []Arg
+	Variadic    bool
+	Call        func(args []float64) (float64, error)
+	CallValues  func(args []Value) (Value, error)
+	CallLazy    func(args []LazyArg) (Value, error)
+	CallDecimal func(args []*big.Rat) (*big.Rat, error)
+}
+
+// LazyArg evaluates an argument of a CallLazy function.
+type LazyArg func() (Value, error)
+
+// MinArgs returns the number of required arguments.
+func (f Function) MinArgs() int {
+	n := 0
+	for _, arg := range f.Args {
+		if !arg.Optional {
+			n++
+		}
+	}
+	return n
+}
+
+// MaxArgs returns the maximum number of arguments, -1 for variadic functions.
+func (f Function) MaxArgs() int {
+	if f.Variadic {
+		return -1
+	}
+	return len(f.Args)
+}
+
+// Signature renders the function like "ROUND(number, [digits])".
+func (f Function) Signature() string {
+	args := make([]string, 0, len(f.Args)+1)
+	for _, arg := range f.Args {
+		if arg.Optional {
+			args = append(args, "["+arg.Name+"]")
+		} else {
+			args = append(args, arg.Name)
+		}
+	}
+	if f.Variadic {
+		args = append(args, "...")
+	}
+	return fmt.Sprintf("%s(%s)", strings.ToUpper(f.Name), strings.Join(args, ", "))
+}
+
+// FunctionRegistry holds the functions formulas may call. It is safe for concurrent use.
+type FunctionRegistry struct {
+	mu        sync.RWMutex
+	functions map[string]Function
+}
+
+// NewFunctionRegistry returns an empty registry.
+func NewFunctionRegistry() *FunctionRegistry {
+	return &FunctionRegistry{functions: make(map[string]Function)}
+}
+
+// NewDefaultFunctionRegistry returns a registry with all built-in functions registered.
+func NewDefaultFunctionRegistry() *FunctionRegistry {
+	r := NewFunctionRegistry()
+	for _, group := range [][]Function{builtinFunctions, textFunctions, logicalFunctions} {
+		for _, fn := range group {
+			if err := r.Register(fn); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return r
+}
+
+// Register adds fn to the registry. Names are case-insensitive and must be unique.
+func (r *FunctionRegistry) Register(fn Function) error {
+	name := strings.ToLower(fn.Name)
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) || strings.IndexFunc(name, func(r rune) bool { return !isWordRune(r) }) >= 0 {
+		return fmt.Errorf("invalid function name %q", fn.Name)
+	}
+	switch implementations := countTrue(fn.Call != nil, fn.CallValues != nil, fn.CallLazy != nil); {
+	case implementations == 0:
+		return fmt.Errorf("function %s has no implementation", strings.ToUpper(name))
+	case implementations > 1:
+		return fmt.Errorf("function %s has more than one of Call, CallValues and CallLazy", strings.ToUpper(name))
+	}
+	if fn.CallDecimal != nil && fn.Call == nil {
+		return fmt.Errorf("function %s has CallDecimal without Call", strings.ToUpper(name))
+	}
+	for i, arg := range fn.Args {
+		if arg.Optional && fn.Variadic && i == len(fn.Args)-1 {
+			return fmt.Errorf("variadic argument of %s can't be optional", strings.ToUpper(name))
+		}
+		if !arg.Optional && i > 0 && fn.Args[i-1].Optional {
+			return fmt.Errorf("required argument of %s follows an optional one", strings.ToUpper(name))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.functions[name]; ok {
+		return fmt.Errorf("function %s is already registered", strings.ToUpper(name))
+	}
+	fn.Name = strings.ToUpper(name)
+	r.functions[name] = fn
+	return nil
+}
+
+// Lookup finds a function by its case-insensitive name.
+func (r *FunctionRegistry) Lookup(name string) (Function, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.functions[strings.ToLower(name)]
+	return fn, ok
+}
+
+// List returns all registered functions sorted by name.
+func (r *FunctionRegistry) List() []Function {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Function, 0, len(r.functions))
+	for _, fn := range r.functions {
+		list = append(list, fn)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+var builtinFunctions = []Function{
+	{
+		Name: "SUM", Description: "Returns the sum of the arguments.",
+		Args: []Arg{{Name: "number", Type: ArgNumber}}, Variadic: true, Call: fnSum, CallDecimal: decSum,
+	},
+	{
+		Name: "AVERAGE", Description: "Returns the arithmetic mean of the arguments.",
+		Args: []Arg{{Name: "number", Type: ArgNumber}}, Variadic: true, Call: fnAverage, CallDecimal: decAverage,
+	},
+	{
+		Name: "MIN", Description: "Returns the smallest of the arguments.",
+		Args: []Arg{{Name: "number", Type: ArgNumber}}, Variadic: true, Call: fnMin, CallDecimal: decMin,
+	},
+	{
+		Name: "MAX", Description: "Returns the largest of the arguments.",
+		Args: []Arg{{Name: "number", Type: ArgNumber}}, Variadic: true, Call: fnMax, CallDecimal: decMax,
+	},
+	{
+		Name: "COUNT", Description: "Returns the number of the arguments.",
+		Args: []Arg{{Name: "value", Type: ArgNumber}}, Variadic: true, Call: fnCount, CallDecimal: decCount,
+	},
+	{
+		Name: "ABS", Description: "Returns the absolute value of a number.",
+		Args: []Arg{{Name: "number", Type: ArgNumber}}, Call: fnAbs, CallDecimal: decAbs,
+	},
+	{
+		Name: "ROUND", Description: "Rounds a number half away from zero to the given number of digits, negative digits round to tens, hundreds, etc.",
+		Args: []Arg{{Name: "number", Type: ArgNumber}, {Name: "digits", Type: ArgNumber, Optional: true}}, Call: fnRound, CallDecimal: decRound,
+	},
+	{
+		Name: "FLOOR", Description: "Rounds a number down to the nearest multiple of significance, which defaults to 1.",
+		Args: []Arg{{Name: "number", Type: ArgNumber}, {Name: "significance", Type: ArgNumber, Optional: true}}, Call: fnFloor, CallDecimal: decFloor,
+	},
+	{
+		Name: "CEILING", Description: "Rounds a number up to the nearest multiple of significance, which defaults to 1.",
+		Args: []Arg{{Name: "number", Type: ArgNumber}, {Name: "significance", Type: ArgNumber, Optional: true}}, Call: fnCeiling, CallDecimal: decCeiling,
+	},
+	{
+		Name: "SQRT", Description: "Returns the square root of a non-negative number.",
+		Args: []Arg{{Name: "number", Type: ArgNumber}}, Call: fnSqrt,
+	},
+	{
+		Name: "POWER", Description: "Returns a number raised to a power.",
+		Args: []Arg{{Name: "number", Type: ArgNumber}, {Name: "power", Type: ArgNumber}}, Call: fnPower,
+	},
+	{
+		Name: "MOD", Description: "Returns the remainder of a division with the sign of the divisor.",
+		Args: []Arg{{Name: "number", Type: ArgNumber}, {Name: "divisor", Type: ArgNumber}}, Call: fnMod, CallDecimal: decMod,
+	},
+}
+
+func fnSum(args []float64) (float64, error) {
+	var sum float64
+	for _, arg := range args {
+		sum += arg
+	}
+	return sum, nil
+}
+
+func fnAverage(args []float64) (float64, error) {
+	if len(args) == 0 {
+		return 0, newFormulaError(ErrDivZero, "division by zero")
+	}
+	sum, _ := fnSum(args)
+	return sum / float64(len(args)), nil
+}
+
+func fnMin(args []float64) (float64, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	res := args[0]
+	for _, arg := range args[1:] {
+		res = math.Min(res, arg)
+	}
+	return res, nil
+}
+
+func fnMax(args []float64) (float64, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	res := args[0]
+	for _, arg := range args[1:] {
+		res = math.Max(res, arg)
+	}
+	return res, nil
+}
+
+func fnCount(args []float64) (float64, error) {
+	return float64(len(args)), nil
+}
+
+func fnAbs(args []float64) (float64, error) {
+	return math.Abs(args[0]), nil
+}
+
+func fnRound(args []float64) (float64, error) {
+	// 10^digits has to stay within float64, numbers have no digits to round beyond its range anyway
+	pow := math.Pow(10, clampFloat(optionalArg(args, 1, 0), -308, 308))
+	scaled := args[0] * pow
+	if math.Abs(scaled) >= 1<<52 {
+		// already whole at that place, multiplying back would only lose precision or overflow
+		return args[0], nil
+	}
+	return math.Round(scaled) / pow, nil
+}
+
+func fnFloor(args []float64) (float64, error) {
+	significance, err := significanceArg(args)
+	if err != nil {
+		return 0, err
+	}
+	return math.Floor(args[0]/significance) * significance, nil
+}
+
+func fnCeiling(args []float64) (float64, error) {
+	significance, err := significanceArg(args)
+	if err != nil {
+		return 0, err
+	}
+	return math.Ceil(args[0]/significance) * significance, nil
+}
+
+func fnSqrt(args []float64) (float64, error) {
+	if args[0] < 0 {
+		return 0, newFormulaError(ErrNum, "square root of negative number")
+	}
+	return math.Sqrt(args[0]), nil
+}
+
+func fnPower(args []float64) (float64, error) {
+	res := math.Pow(args[0], args[1])
+	if math.IsNaN(res) {
+		return 0, newFormulaError(ErrNum, "power result is not a number")
+	}
+	return res, nil
+}
+
+func fnMod(args []float64) (float64, error) {
+	if args[1] == 0 {
+		return 0, newFormulaError(ErrDivZero, "division by zero")
+	}
+	return args[0] - args[1]*math.Floor(args[0]/args[1]), nil
+}
+
+// argType returns the declared type of the i-th argument, repeated arguments share the type of the last one.
+func (f Function) argType(i int) ArgType {
+	if len(f.Args) == 0 {
+		return ArgAny
+	}
+	if i >= len(f.Args) {
+		i = len(f.Args) - 1
+	}
+	if f.Call != nil {
+		return ArgNumber
+	}
+	return f.Args[i].Type
+}
+
+func countTrue(flags ...bool) int {
+	n := 0
+	for _, flag := range flags {
+		if flag {
+			n++
+		}
+	}
+	return n
+}
+
+func optionalArg(args []float64, i int, def float64) float64 {
+	if len(args) > i {
+		return args[i]
+	}
+	return def
+}
+
+func significanceArg(args []float64) (float64, error) {
+	significance := optionalArg(args, 1, 1)
+	if significance == 0 {
+		return 0, newFormulaError(ErrDivZero, "division by zero")
+	}
+	if args[0] > 0 && significance < 0 {
+		return 0, newFormulaError(ErrNum, "significance must have the same sign as the number")
+	}
+	return significance, nil
+}
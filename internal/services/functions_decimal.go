@@ -0,0 +1,111 @@
+package services
+
+import (
+	"math/big"
+)
+
+// Exact implementations of the built-in numeric functions used by sheets in decimal mode.
+
+func decSum(args []*big.Rat) (*big.Rat, error) {
+	sum := new(big.Rat)
+	for _, arg := range args {
+		sum.Add(sum, arg)
+	}
+	return sum, nil
+}
+
+func decAverage(args []*big.Rat) (*big.Rat, error) {
+	if len(args) == 0 {
+		return nil, newFormulaError(ErrDivZero, "division by zero")
+	}
+	sum, _ := decSum(args)
+	return sum.Quo(sum, new(big.Rat).SetInt64(int64(len(args)))), nil
+}
+
+func decMin(args []*big.Rat) (*big.Rat, error) {
+	res := new(big.Rat)
+	for i, arg := range args {
+		if i == 0 || arg.Cmp(res) < 0 {
+			res.Set(arg)
+		}
+	}
+	return res, nil
+}
+
+func decMax(args []*big.Rat) (*big.Rat, error) {
+	res := new(big.Rat)
+	for i, arg := range args {
+		if i == 0 || arg.Cmp(res) > 0 {
+			res.Set(arg)
+		}
+	}
+	return res, nil
+}
+
+func decCount(args []*big.Rat) (*big.Rat, error) {
+	return new(big.Rat).SetInt64(int64(len(args))), nil
+}
+
+func decAbs(args []*big.Rat) (*big.Rat, error) {
+	return new(big.Rat).Abs(args[0]), nil
+}
+
+func decRound(args []*big.Rat) (*big.Rat, error) {
+	digits := 0
+	if len(args) > 1 {
+		f, _ := args[1].Float64()
+		// decimal numbers come from float64 literals, so rounding beyond its range changes nothing
+		digits = int(clampFloat(f, -400, 400))
+	}
+	return roundDecimal(args[0], digits, RoundHalfUp), nil
+}
+
+func decFloor(args []*big.Rat) (*big.Rat, error) {
+	significance, err := decSignificanceArg(args)
+	if err != nil {
+		return nil, err
+	}
+	quotient := floorDecimal(new(big.Rat).Quo(args[0], significance))
+	return quotient.Mul(quotient, significance), nil
+}
+
+func decCeiling(args []*big.Rat) (*big.Rat, error) {
+	significance, err := decSignificanceArg(args)
+	if err != nil {
+		return nil, err
+	}
+	quotient := floorDecimal(new(big.Rat).Quo(new(big.Rat).Neg(args[0]), significance))
+	return quotient.Mul(quotient.Neg(quotient), significance), nil
+}
+
+func decMod(args []*big.Rat) (*big.Rat, error) {
+	if args[1].Sign() == 0 {
+		return nil, newFormulaError(ErrDivZero, "division by zero")
+	}
+	quotient := floorDecimal(new(big.Rat).Quo(args[0], args[1]))
+	return quotient.Sub(args[0], quotient.Mul(quotient, args[1])), nil
+}
+
+func decSignificanceArg(args []*big.Rat) (*big.Rat, error) {
+	significance := big.NewRat(1, 1)
+	if len(args) > 1 {
+		significance = args[1]
+	}
+	if significance.Sign() == 0 {
+		return nil, newFormulaError(ErrDivZero, "division by zero")
+	}
+	if args[0].Sign() > 0 && significance.Sign() < 0 {
+		return nil, newFormulaError(ErrNum, "significance must have the same sign as the number")
+	}
+	return significance, nil
+}
+
+func clampFloat(f, min, max float64) float64 {
+	switch {
+	case f < min:
+		return min
+	case f > max:
+		return max
+	}
+	return f
+}
@@ -0,0 +1,112 @@
+package services
+
+import "fmt"
+
+var logicalFunctions = []Function{
+	{
+		Name: "AND", Description: "Returns TRUE when all arguments are true.",
+		Args: []Arg{{Name: "logical", Type: ArgBoolean}}, Variadic: true, CallValues: fnAnd,
+	},
+	{
+		Name: "OR", Description: "Returns TRUE when any argument is true.",
+		Args: []Arg{{Name: "logical", Type: ArgBoolean}}, Variadic: true, CallValues: fnOr,
+	},
+	{
+		Name: "NOT", Description: "Reverses a logical value.",
+		Args: []Arg{{Name: "logical", Type: ArgBoolean}}, CallValues: fnNot,
+	},
+	{
+		Name: "IF", Description: "Returns value_if_true when the condition is true and value_if_false, FALSE by default, otherwise. Only the returned value is evaluated.",
+		Args: []Arg{{Name: "condition", Type: ArgBoolean}, {Name: "value_if_true", Type: ArgAny}, {Name: "value_if_false", Type: ArgAny, Optional: true}}, CallLazy: fnIf,
+	},
+	{
+		Name: "IFS", Description: "Takes pairs of conditions and values and returns the value of the first true condition. Conditions after it and other values are not evaluated.",
+		Args: []Arg{{Name: "condition", Type: ArgBoolean}, {Name: "value", Type: ArgAny}}, Variadic: true, CallLazy: fnIfs,
+	},
+	{
+		Name: "SWITCH", Description: "Compares an expression with values and returns the result paired with the first equal one, or the trailing default. Only the returned result is evaluated.",
+		Args: []Arg{{Name: "expression", Type: ArgAny}, {Name: "value", Type: ArgAny}, {Name: "result", Type: ArgAny}}, Variadic: true, CallLazy: fnSwitch,
+	},
+}
+
+func fnAnd(args []Value) (Value, error) {
+	if len(args) == 0 {
+		return Value{}, newFormulaError(ErrValue, "no logical values")
+	}
+	for _, arg := range args {
+		if arg.Number == 0 {
+			return BoolValue(false), nil
+		}
+	}
+	return BoolValue(true), nil
+}
+
+func fnOr(args []Value) (Value, error) {
+	if len(args) == 0 {
+		return Value{}, newFormulaError(ErrValue, "no logical values")
+	}
+	for _, arg := range args {
+		if arg.Number != 0 {
+			return BoolValue(true), nil
+		}
+	}
+	return BoolValue(false), nil
+}
+
+func fnNot(args []Value) (Value, error) {
+	return BoolValue(args[0].Number == 0), nil
+}
+
+func fnIf(args []LazyArg) (Value, error) {
+	condition, err := args[0]()
+	if err != nil {
+		return Value{}, err
+	}
+	switch {
+	case condition.Number != 0:
+		return args[1]()
+	case len(args) > 2:
+		return args[2]()
+	}
+	return BoolValue(false), nil
+}
+
+func fnIfs(args []LazyArg) (Value, error) {
+	if len(args)%2 != 0 {
+		return Value{}, newFormulaError(ErrValue, "IFS expects pairs of conditions and values")
+	}
+	for i := 0; i < len(args); i += 2 {
+		condition, err := args[i]()
+		if err != nil {
+			return Value{}, err
+		}
+		ok, err := condition.AsBool()
+		if err != nil {
+			return Value{}, fmt.Errorf("argument %d of IFS: %w", i+1, err)
+		}
+		if ok {
+			return args[i+1]()
+		}
+	}
+	return Value{}, newFormulaError(ErrValue, "no condition of IFS is met")
+}
+
+func fnSwitch(args []LazyArg) (Value, error) {
+	expr, err := args[0]()
+	if err != nil {
+		return Value{}, err
+	}
+	for i := 1; i+1 < len(args); i += 2 {
+		value, err := args[i]()
+		if err != nil {
+			return Value{}, err
+		}
+		if compareValues(expr, value) == 0 {
+			return args[i+1]()
+		}
+	}
+	if len(args)%2 == 0 {
+		return args[len(args)-1]()
+	}
+	return Value{}, newFormulaError(ErrValue, "no value of SWITCH matches")
+}
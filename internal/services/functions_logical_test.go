@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+)
+
+func Test_logicalFunctions(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		values  map[string]any
+		want    Value
+		wantErr string
+	}{
+		{name: "Number comparison", expr: "=qty > 100", values: map[string]any{"qty": 150}, want: BoolValue(true)},
+		{name: "Not equal", expr: "=1 <> 1", want: BoolValue(false)},
+		{name: "Less or equal", expr: "=2 <= 2", want: BoolValue(true)},
+		{name: "Text comparison ignores case", expr: `=name = "ACME"`, values: map[string]any{"name": "acme"}, want: BoolValue(true)},
+		{name: "Text order", expr: `="apple" < "Banana"`, want: BoolValue(true)},
+		{name: "Numbers come before texts", expr: `=1000 < "1"`, want: BoolValue(true)},
+		{name: "Texts come before booleans", expr: `="z" < false`, want: BoolValue(true)},
+		{name: "Arithmetic before comparison", expr: "=1+1 = 2", want: BoolValue(true)},
+		{name: "AND", expr: "=AND(a > 1, b, TRUE)", values: map[string]any{"a": 2, "b": 1}, want: BoolValue(true)},
+		{name: "AND with a false argument", expr: "=AND(TRUE, 0)", want: BoolValue(false)},
+		{name: "OR", expr: "=OR(FALSE, a = 2)", values: map[string]any{"a": 2}, want: BoolValue(true)},
+		{name: "OR of range skips text", expr: "=OR(a1:a3)", values: map[string]any{"a1": false, "a2": "yes", "a3": 0}, want: BoolValue(false)},
+		{name: "NOT", expr: "=NOT(1 > 2)", want: BoolValue(true)},
+		{name: "NOT of boolean text", expr: `=NOT("true")`, want: BoolValue(false)},
+		{name: "IF true branch", expr: "=IF(qty > 100, price * 0.9, price)", values: map[string]any{"qty": 150, "price": 10}, want: NumberValue(9)},
+		{name: "IF false branch", expr: `=IF(qty > 100, "discount", "full")`, values: map[string]any{"qty": 5}, want: TextValue("full")},
+		{name: "IF without else", expr: "=IF(0, 1)", want: BoolValue(false)},
+		{name: "IF skips division by zero", expr: "=IF(b = 0, 0, a / b)", values: map[string]any{"a": 1, "b": 0}, want: NumberValue(0)},
+		{name: "IF skips missing cell", expr: "=IF(TRUE, 1, missing)", want: NumberValue(1)},
+		{name: "IFS first true condition", expr: `=IFS(x < 0, "neg", x = 0, "zero", TRUE, "pos")`, values: map[string]any{"x": 0}, want: TextValue("zero")},
+		{name: "IFS skips later conditions", expr: "=IFS(TRUE, 1, 1/0 > 1, 2)", want: NumberValue(1)},
+		{name: "SWITCH match", expr: `=SWITCH(code, "a", 1, "b", 2)`, values: map[string]any{"code": "B"}, want: NumberValue(2)},
+		{name: "SWITCH default", expr: `=SWITCH(3, 1, "one", 2, "two", "many")`, want: TextValue("many")},
+		{name: "SWITCH skips other results", expr: "=SWITCH(1, 1, 10, 2, 1/0)", want: NumberValue(10)},
+		{name: "IF takes division by zero when chosen", expr: "=IF(TRUE, 1/0, 1)", wantErr: "division by zero"},
+		{name: "IF condition is not a boolean", expr: `=IF("maybe", 1, 2)`, wantErr: `argument 1 of IF: text "maybe" is not a boolean`},
+		{name: "IFS without true condition", expr: "=IFS(FALSE, 1)", wantErr: "no condition of IFS is met"},
+		{name: "IFS without value", expr: "=IFS(FALSE, 1, TRUE)", wantErr: "IFS expects pairs of conditions and values"},
+		{name: "IFS condition is not a boolean", expr: `=IFS(FALSE, 1, "x", 2)`, wantErr: `argument 3 of IFS: text "x" is not a boolean`},
+		{name: "SWITCH without match", expr: "=SWITCH(3, 1, 10)", wantErr: "no value of SWITCH matches"},
+		{name: "AND of text only range", expr: "=AND(a1:a2)", values: map[string]any{"a1": "x"}, wantErr: "no logical values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseFormula(normalizeValue(tt.expr))
+			if err != nil {
+				t.Fatalf("parseFormula() error = %v", err)
+			}
+			got, err := (&evaluator{sheetID: "sheet1", values: sheetValues("sheet1", tt.values), functions: NewDefaultFunctionRegistry()}).eval(root)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("eval() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("eval() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("eval() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func Test_builtinFunctions(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		values  map[string]any
+		want    float64
+		wantErr string
+	}{
+		{name: "SUM of params and expressions", expr: "=SUM(a, b*2, 1)", values: map[string]any{"a": 1, "b": 2}, want: 6},
+		{name: "AVERAGE", expr: "=average(1, 2, 3, 4)", want: 2.5},
+		{name: "MIN", expr: "=MIN(3, -1, 2)", want: -1},
+		{name: "MAX", expr: "=MAX(3, -1, 2)", want: 3},
+		{name: "COUNT", expr: "=COUNT(a, b, 7)", values: map[string]any{"a": 1, "b": 2}, want: 3},
+		{name: "ABS", expr: "=ABS(-2.5)", want: 2.5},
+		{name: "ROUND half away from zero", expr: "=ROUND(-2.5)", want: -3},
+		{name: "ROUND with digits", expr: "=ROUND(3.14159, 2)", want: 3.14},
+		{name: "ROUND with negative digits", expr: "=ROUND(1250, -2)", want: 1300},
+		{name: "ROUND beyond float precision", expr: "=ROUND(3.14159, 400)", want: 3.14159},
+		{name: "ROUND of a large number", expr: "=ROUND(1e300, 10)", want: 1e300},
+		{name: "ROUND to a place beyond the number", expr: "=ROUND(1250, -400)", want: 0},
+		{name: "FLOOR", expr: "=FLOOR(2.7)", want: 2},
+		{name: "FLOOR with significance", expr: "=FLOOR(7, 5)", want: 5},
+		{name: "CEILING", expr: "=CEILING(2.1)", want: 3},
+		{name: "CEILING with significance", expr: "=CEILING(0.234, 0.1)", want: 0.30000000000000004},
+		{name: "SQRT", expr: "=SQRT(16)", want: 4},
+		{name: "POWER", expr: "=POWER(2, 10)", want: 1024},
+		{name: "MOD takes the divisor sign", expr: "=MOD(-3, 2)", want: 1},
+		{name: "Nested calls", expr: "=MAX(SUM(1, 2), ABS(-5)) + 1", want: 6},
+		{name: "Unknown function", expr: "=FOO(1)", wantErr: `unknown function "foo" at position 1`},
+		{name: "Too few arguments", expr: "=POWER(2)", wantErr: "wrong number of arguments for POWER: 1"},
+		{name: "Too many arguments", expr: "=ABS(1, 2)", wantErr: "wrong number of arguments for ABS: 2"},
+		{name: "No arguments", expr: "=SUM()", wantErr: "wrong number of arguments for SUM: 0"},
+		{name: "SQRT of negative", expr: "=SQRT(-1)", wantErr: "square root of negative number"},
+		{name: "MOD by zero", expr: "=MOD(1, 0)", wantErr: "division by zero"},
+		{name: "FLOOR by zero", expr: "=FLOOR(1, 0)", wantErr: "division by zero"},
+		{name: "CEILING sign mismatch", expr: "=CEILING(1, -1)", wantErr: "significance must have the same sign as the number"},
+		{name: "POWER not a number", expr: "=POWER(-8, 0.5)", wantErr: "power result is not a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseFormula(tt.expr)
+			if err != nil {
+				t.Fatalf("parseFormula() error = %v", err)
+			}
+			got, err := (&evaluator{sheetID: "sheet1", values: sheetValues("sheet1", tt.values), functions: NewDefaultFunctionRegistry()}).eval(root)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("eval() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("eval() error = %v", err)
+			}
+			if got.Type != TypeNumber || math.Abs(got.Number-tt.want) > 1e-12 {
+				t.Errorf("eval() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionRegistry_Register(t *testing.T) {
+	call := func(args []float64) (float64, error) { return args[0], nil }
+
+	tests := []struct {
+		name    string
+		fn      Function
+		wantErr string
+	}{
+		{
+			name: "Custom function",
+			fn:   Function{Name: "discount", Args: []Arg{{Name: "price", Type: ArgNumber}}, Call: call},
+		},
+		{
+			name:    "Duplicate name in other case",
+			fn:      Function{Name: "Sum", Call: call},
+			wantErr: "function SUM is already registered",
+		},
+		{
+			name:    "Empty name",
+			fn:      Function{Call: call},
+			wantErr: `invalid function name ""`,
+		},
+		{
+			name:    "Name starting with digit",
+			fn:      Function{Name: "1st", Call: call},
+			wantErr: `invalid function name "1st"`,
+		},
+		{
+			name:    "Name with operator",
+			fn:      Function{Name: "a+b", Call: call},
+			wantErr: `invalid function name "a+b"`,
+		},
+		{
+			name:    "No implementation",
+			fn:      Function{Name: "noop"},
+			wantErr: "function NOOP has no implementation",
+		},
+		{
+			name: "Several implementations",
+			fn: Function{Name: "twice", Call: call, CallLazy: func(args []LazyArg) (Value, error) {
+				return Value{}, nil
+			}},
+			wantErr: "function TWICE has more than one of Call, CallValues and CallLazy",
+		},
+		{
+			name: "Decimal implementation without Call",
+			fn: Function{Name: "exact", CallValues: func(args []Value) (Value, error) {
+				return Value{}, nil
+			}, CallDecimal: decAbs},
+			wantErr: "function EXACT has CallDecimal without Call",
+		},
+		{
+			name: "Required after optional",
+			fn: Function{Name: "bad", Args: []Arg{
+				{Name: "a", Type: ArgNumber, Optional: true},
+				{Name: "b", Type: ArgNumber},
+			}, Call: call},
+			wantErr: "required argument of BAD follows an optional one",
+		},
+		{
+			name:    "Optional variadic",
+			fn:      Function{Name: "bad", Args: []Arg{{Name: "a", Type: ArgNumber, Optional: true}}, Variadic: true, Call: call},
+			wantErr: "variadic argument of BAD can't be optional",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewDefaultFunctionRegistry()
+			err := r.Register(tt.fn)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Register() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+			if _, ok := r.Lookup(strings.ToUpper(tt.fn.Name)); !ok {
+				t.Errorf("Lookup() didn't find %s", tt.fn.Name)
+			}
+		})
+	}
+}
+
+func TestFunctionRegistry_customFunction(t *testing.T) {
+	r := NewFunctionRegistry()
+	err := r.Register(Function{
+		Name: "DISCOUNT",
+		Args: []Arg{{Name: "price", Type: ArgNumber}, {Name: "rate", Type: ArgNumber, Optional: true}},
+		Call: func(args []float64) (float64, error) {
+			return args[0] * (1 - optionalArg(args, 1, 0.1)), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	root, err := parseFormula("=discount(price)+DISCOUNT(100, 0.5)")
+	if err != nil {
+		t.Fatalf("parseFormula() error = %v", err)
+	}
+	got, err := (&evaluator{sheetID: "sheet1", values: sheetValues("sheet1", map[string]any{"price": 200}), functions: r}).eval(root)
+	if err != nil {
+		t.Fatalf("eval() error = %v", err)
+	}
+	if got != NumberValue(230) {
+		t.Errorf("eval() got = %v, want %v", got, 230)
+	}
+}
+
+func TestFunction_Signature(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   string
+		want string
+	}{
+		{name: "Optional argument", fn: "round", want: "ROUND(number, [digits])"},
+		{name: "Variadic", fn: "sum", want: "SUM(number, ...)"},
+		{name: "Fixed", fn: "power", want: "POWER(number, power)"},
+	}
+	r := NewDefaultFunctionRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, ok := r.Lookup(tt.fn)
+			if !ok {
+				t.Fatalf("Lookup() didn't find %s", tt.fn)
+			}
+			if got := fn.Signature(); got != tt.want {
+				t.Errorf("Signature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
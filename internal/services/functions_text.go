@@ -0,0 +1,148 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+var textFunctions = []Function{
+	{
+		Name: "LEN", Description: "Returns the number of characters in a text.",
+		Args: []Arg{{Name: "text", Type: ArgText}}, CallValues: fnLen,
+	},
+	{
+		Name: "UPPER", Description: "Converts a text to upper case.",
+		Args: []Arg{{Name: "text", Type: ArgText}}, CallValues: fnUpper,
+	},
+	{
+		Name: "LOWER", Description: "Converts a text to lower case.",
+		Args: []Arg{{Name: "text", Type: ArgText}}, CallValues: fnLower,
+	},
+	{
+		Name: "TRIM", Description: "Removes leading and trailing spaces and collapses repeated spaces between words.",
+		Args: []Arg{{Name: "text", Type: ArgText}}, CallValues: fnTrim,
+	},
+	{
+		Name: "LEFT", Description: "Returns the first characters of a text, one by default.",
+		Args: []Arg{{Name: "text", Type: ArgText}, {Name: "count", Type: ArgNumber, Optional: true}}, CallValues: fnLeft,
+	},
+	{
+		Name: "RIGHT", Description: "Returns the last characters of a text, one by default.",
+		Args: []Arg{{Name: "text", Type: ArgText}, {Name: "count", Type: ArgNumber, Optional: true}}, CallValues: fnRight,
+	},
+	{
+		Name: "MID", Description: "Returns count characters of a text starting at the 1-based position start.",
+		Args: []Arg{{Name: "text", Type: ArgText}, {Name: "start", Type: ArgNumber}, {Name: "count", Type: ArgNumber}}, CallValues: fnMid,
+	},
+	{
+		Name: "CONCAT", Description: "Joins the arguments into a single text.",
+		Args: []Arg{{Name: "text", Type: ArgText}}, Variadic: true, CallValues: fnConcat,
+	},
+	{
+		Name: "TEXT", Description: "Formats a number by a mask like \"0.00\", \"#,##0\" or \"0%\".",
+		Args: []Arg{{Name: "value", Type: ArgAny}, {Name: "format", Type: ArgText}}, CallValues: fnText,
+	},
+}
+
+func fnLen(args []Value) (Value, error) {
+	return NumberValue(float64(utf8.RuneCountInString(args[0].Text))), nil
+}
+
+func fnUpper(args []Value) (Value, error) {
+	return TextValue(strings.ToUpper(args[0].Text)), nil
+}
+
+func fnLower(args []Value) (Value, error) {
+	return TextValue(strings.ToLower(args[0].Text)), nil
+}
+
+func fnTrim(args []Value) (Value, error) {
+	return TextValue(strings.Join(strings.Fields(args[0].Text), " ")), nil
+}
+
+func fnLeft(args []Value) (Value, error) {
+	count, err := countArg(args, 1)
+	if err != nil {
+		return Value{}, err
+	}
+	runes := []rune(args[0].Text)
+	if count > len(runes) {
+		count = len(runes)
+	}
+	return TextValue(string(runes[:count])), nil
+}
+
+func fnRight(args []Value) (Value, error) {
+	count, err := countArg(args, 1)
+	if err != nil {
+		return Value{}, err
+	}
+	runes := []rune(args[0].Text)
+	if count > len(runes) {
+		count = len(runes)
+	}
+	return TextValue(string(runes[len(runes)-count:])), nil
+}
+
+func fnMid(args []Value) (Value, error) {
+	if !(args[1].Number >= 1) {
+		return Value{}, newFormulaError(ErrValue, "start must be at least 1")
+	}
+	count, err := countArg(args, 2)
+	if err != nil {
+		return Value{}, err
+	}
+	runes := []rune(args[0].Text)
+	start := clampCount(args[1].Number) - 1
+	if start > len(runes) {
+		start = len(runes)
+	}
+	if start+count > len(runes) {
+		count = len(runes) - start
+	}
+	return TextValue(string(runes[start : start+count])), nil
+}
+
+func fnConcat(args []Value) (Value, error) {
+	var sb strings.Builder
+	for _, arg := range args {
+		sb.WriteString(arg.Text)
+	}
+	return TextValue(sb.String()), nil
+}
+
+func fnText(args []Value) (Value, error) {
+	number, err := args[0].AsNumber()
+	if err != nil {
+		// texts which aren't numbers are kept as is
+		return TextValue(args[0].Text), nil
+	}
+	text, err := formatNumber(number, args[1].Text)
+	if err != nil {
+		return Value{}, err
+	}
+	return TextValue(text), nil
+}
+
+// countArg returns the optional character count at position i, one by default.
+func countArg(args []Value, i int) (int, error) {
+	if len(args) <= i {
+		return 1, nil
+	}
+	if math.IsNaN(args[i].Number) {
+		return 0, newFormulaError(ErrValue, "count must be a number")
+	}
+	if args[i].Number < 0 {
+		return 0, newFormulaError(ErrValue, "count must not be negative")
+	}
+	return clampCount(args[i].Number), nil
+}
+
+// clampCount converts a non-negative count or position to int, huge numbers would wrap around otherwise.
+func clampCount(n float64) int {
+	if n > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(n)
+}
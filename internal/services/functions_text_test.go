@@ -0,0 +1,96 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_textFunctions(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		values  map[string]any
+		want    Value
+		wantErr string
+	}{
+		{name: "LEN counts characters", expr: `=LEN("héllo")`, want: NumberValue(5)},
+		{name: "LEN of number", expr: "=LEN(1.5)", want: NumberValue(3)},
+		{name: "UPPER", expr: "=UPPER(name)", values: map[string]any{"name": "Acme"}, want: TextValue("ACME")},
+		{name: "LOWER", expr: `=LOWER("Acme")`, want: TextValue("acme")},
+		{name: "TRIM", expr: `=TRIM("  a   b ")`, want: TextValue("a b")},
+		{name: "LEFT default count", expr: `=LEFT("abc")`, want: TextValue("a")},
+		{name: "LEFT longer than text", expr: `=LEFT("abc", 5)`, want: TextValue("abc")},
+		{name: "RIGHT", expr: `=RIGHT("invoice-42", 2)`, want: TextValue("42")},
+		{name: "MID", expr: `=MID("spreadsheet", 7, 5)`, want: TextValue("sheet")},
+		{name: "MID past the end", expr: `=MID("abc", 5, 2)`, want: TextValue("")},
+		{name: "MID huge count", expr: `=MID("abc", 2, 1E300)`, want: TextValue("bc")},
+		{name: "MID huge start", expr: `=MID("abc", 1E300, 2)`, want: TextValue("")},
+		{name: "LEFT huge count", expr: `=LEFT("abc", 1E300)`, want: TextValue("abc")},
+		{name: "RIGHT huge count", expr: `=RIGHT("abc", 1E300)`, want: TextValue("abc")},
+		{name: "CONCAT of values and ranges", expr: `=CONCAT("#", a1:b1, 7)`, values: map[string]any{"a1": "x", "b1": 2}, want: TextValue("#x27")},
+		{name: "TEXT with mask", expr: `=TEXT(1234.5, "#,##0.00")`, want: TextValue("1,234.50")},
+		{name: "TEXT of numeric text", expr: `=TEXT("0.25", "0%")`, want: TextValue("25%")},
+		{name: "TEXT of text", expr: `=TEXT("n/a", "0.0")`, want: TextValue("n/a")},
+		{name: "Text function result in arithmetic", expr: `=LEN(a) * 2`, values: map[string]any{"a": "abc"}, want: NumberValue(6)},
+		{name: "LEFT negative count", expr: `=LEFT("abc", -1)`, wantErr: "count must not be negative"},
+		{name: "MID start before text", expr: `=MID("abc", 0, 1)`, wantErr: "start must be at least 1"},
+		{name: "TEXT invalid mask", expr: `=TEXT(1, "abc")`, wantErr: `invalid format "abc"`},
+		{name: "Text count argument", expr: `=LEFT("abc", "x")`, wantErr: `argument 2 of LEFT: text "x" is not a number`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseFormula(tt.expr)
+			if err != nil {
+				t.Fatalf("parseFormula() error = %v", err)
+			}
+			got, err := (&evaluator{sheetID: "sheet1", values: sheetValues("sheet1", tt.values), functions: NewDefaultFunctionRegistry()}).eval(root)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("eval() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("eval() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("eval() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_textCounts(t *testing.T) {
+	nan := NumberValue(math.NaN())
+	tests := []struct {
+		name    string
+		fn      func([]Value) (Value, error)
+		args    []Value
+		want    Value
+		wantErr string
+	}{
+		{name: "MID huge count", fn: fnMid, args: []Value{TextValue("abc"), NumberValue(2), NumberValue(1e300)}, want: TextValue("bc")},
+		{name: "MID infinite count", fn: fnMid, args: []Value{TextValue("abc"), NumberValue(1), NumberValue(math.Inf(1))}, want: TextValue("abc")},
+		{name: "MID NaN start", fn: fnMid, args: []Value{TextValue("abc"), nan, NumberValue(1)}, wantErr: "start must be at least 1"},
+		{name: "MID NaN count", fn: fnMid, args: []Value{TextValue("abc"), NumberValue(1), nan}, wantErr: "count must be a number"},
+		{name: "LEFT NaN count", fn: fnLeft, args: []Value{TextValue("abc"), nan}, wantErr: "count must be a number"},
+		{name: "RIGHT huge count", fn: fnRight, args: []Value{TextValue("abc"), NumberValue(1e300)}, want: TextValue("abc")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("got error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
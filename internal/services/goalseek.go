@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+var (
+	// ErrInvalidGoalSeek is returned for goal seeks that can't be run, like a changing cell holding a formula.
+	ErrInvalidGoalSeek = errors.New("invalid goal seek")
+	// ErrGoalNotReached is returned when no value of the changing cell gives the target value.
+	ErrGoalNotReached = errors.New("goal not reached")
+)
+
+const (
+	defaultGoalSeekTolerance  = 1e-7
+	defaultGoalSeekIterations = 100
+	maxGoalSeekIterations     = 1000
+)
+
+// goalSeekParams tune seekGoal. Without bounds the search starts at start.
+type goalSeekParams struct {
+	start         float64
+	min, max      float64
+	bounded       bool
+	tolerance     float64
+	maxIterations int
+}
+
+// GoalSeekTX finds the value of the changing cell making the target cell result in the requested value.
+// The tried values are calculated in memory from the sheet as it is, the found value is written only when
+// the request asks to commit it.
+func (s *excelLikeService) GoalSeekTX(ctx context.Context, sheetID string, request *models.GoalSeek) (*models.GoalSeekResult, error) {
+	params, err := goalSeekParamsOf(request)
+	if err != nil {
+		return nil, err
+	}
+	target := db.CellRef{SheetID: sheetID, CellID: strings.ToLower(request.Target)}
+	changing := db.CellRef{SheetID: sheetID, CellID: strings.ToLower(request.Changing)}
+
+	w, err := s.prepareWhatIf(ctx, func(tx *sql.Tx) (*whatIf, error) {
+		return s.goalSeekModelOf(ctx, tx, target, changing, &params)
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := goalSeek(ctx, w, target, changing, request.Value, params)
+	if err != nil {
+		return nil, err
+	}
+	if request.Commit {
+		err = s.inTransaction(ctx, func(tx *sql.Tx) error {
+			_, err := s.addCellInput(ctx, tx, newCalculation(), sheetID, changing.CellID, &models.Data{Value: numberInput(res.Input)})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	res.Committed = request.Commit
+	return res, nil
+}
+
+func goalSeekParamsOf(request *models.GoalSeek) (goalSeekParams, error) {
+	params := goalSeekParams{tolerance: request.Tolerance, maxIterations: request.MaxIterations}
+	switch {
+	case request.Target == "" || request.Changing == "":
+		return params, fmt.Errorf("%w: target and changing cells are required", ErrInvalidGoalSeek)
+	case strings.EqualFold(request.Target, request.Changing):
+		return params, fmt.Errorf("%w: target and changing cells must differ", ErrInvalidGoalSeek)
+	case (request.Min == nil) != (request.Max == nil):
+		return params, fmt.Errorf("%w: min and max go together", ErrInvalidGoalSeek)
+	case request.Min != nil && *request.Min >= *request.Max:
+		return params, fmt.Errorf("%w: min must be less than max", ErrInvalidGoalSeek)
+	case request.Tolerance < 0:
+		return params, fmt.Errorf("%w: tolerance must not be negative", ErrInvalidGoalSeek)
+	case request.MaxIterations < 0 || request.MaxIterations > maxGoalSeekIterations:
+		return params, fmt.Errorf("%w: max_iterations must be between 1 and %d, 0 or none means %d", ErrInvalidGoalSeek,
+			maxGoalSeekIterations, defaultGoalSeekIterations)
+	}
+	if request.Min != nil {
+		params.min, params.max, params.bounded = *request.Min, *request.Max, true
+	}
+	if params.tolerance == 0 {
+		params.tolerance = defaultGoalSeekTolerance
+	}
+	if params.maxIterations == 0 {
+		params.maxIterations = defaultGoalSeekIterations
+	}
+	return params, nil
+}
+
+// goalSeekModelOf checks the target and changing cells and prepares the evaluation of the target for values of
+// the changing cell, reading stored cells within tx. It sets the start of params to the value of the changing
+// cell. The dependency graph must not change meanwhile.
+func (s *excelLikeService) goalSeekModelOf(ctx context.Context, tx *sql.Tx, target, changing db.CellRef, params *goalSeekParams) (*whatIf, error) {
+	if _, ok := s.graph.value(target); !ok {
+		return nil, fmt.Errorf("%w: target cell %s not found", ErrInvalidGoalSeek, target.CellID)
+	}
+	value, ok := s.graph.value(changing)
+	if !ok {
+		return nil, fmt.Errorf("%w: changing cell %s not found", ErrInvalidGoalSeek, changing.CellID)
+	}
+	if strings.HasPrefix(value, "=") {
+		return nil, fmt.Errorf("%w: changing cell %s holds a formula", ErrInvalidGoalSeek, changing.CellID)
+	}
+	if params.start, ok = parseNumber(value); !ok {
+		return nil, fmt.Errorf("%w: changing cell %s doesn't hold a number", ErrInvalidGoalSeek, changing.CellID)
+	}
+	if !s.dependsOn(target, changing) {
+		return nil, fmt.Errorf("%w: target cell %s doesn't depend on %s", ErrInvalidGoalSeek, target.CellID, changing.CellID)
+	}
+	return s.newWhatIf(ctx, tx, map[db.CellRef]string{changing: value}, []db.CellRef{target})
+}
+
+// goalSeek searches with the evaluation prepared in w, every tried value of the changing cell recalculates
+// the cells depending on it in memory and the target is read from them.
+func goalSeek(ctx context.Context, w *whatIf, target, changing db.CellRef, goal float64, params goalSeekParams) (*models.GoalSeekResult, error) {
+	f := func(x float64) (float64, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		results, err := w.evaluate(map[db.CellRef]db.Input{changing: numberResult(changing, x)})
+		if err != nil {
+			return 0, err
+		}
+		input, _ := w.result(results, target)
+		result := inputValue(target, input)
+		if result.Type != TypeNumber {
+			return 0, fmt.Errorf("%w: target is %s when %s is %s", ErrGoalNotReached, result.AsText(), changing.CellID, numberInput(x))
+		}
+		return result.Number - goal, nil
+	}
+	x, fx, iterations, err := seekGoal(f, params)
+	if err != nil {
+		return nil, err
+	}
+	return &models.GoalSeekResult{Target: target.CellID, Changing: changing.CellID, Input: x, Result: goal + fx, Iterations: iterations}, nil
+}
+
+// dependsOn tells whether cell uses precedent, directly or through other cells.
+func (s *excelLikeService) dependsOn(cell, precedent db.CellRef) bool {
+	for _, linked := range s.graph.walk(cell, 0, s.graph.directPrecedents) {
+		if linked.cell == precedent {
+			return true
+		}
+	}
+	return false
+}
+
+// seekGoal finds a root of f, an x with |f(x)| within the tolerance, and returns it with f(x) and the number of
+// times f was called. Bounded searches start from the bracket [min, max], others take secant steps from start
+// until a sign change brackets the root. Bracketed roots are refined by regula falsi with the Illinois
+// modification, falling back to bisection when a step leaves the bracket.
+func seekGoal(f func(x float64) (float64, error), params goalSeekParams) (x, fx float64, iterations int, err error) {
+	eval := func(x float64) (float64, error) {
+		iterations++
+		return f(x)
+	}
+	converged := func(fx float64) bool {
+		return math.Abs(fx) <= params.tolerance
+	}
+
+	var a, b, fa, fb float64
+	if params.bounded {
+		a, b = params.min, params.max
+		if fa, err = eval(a); err != nil || converged(fa) {
+			return a, fa, iterations, err
+		}
+		if fb, err = eval(b); err != nil || converged(fb) {
+			return b, fb, iterations, err
+		}
+		if (fa < 0) == (fb < 0) {
+			return 0, 0, iterations, fmt.Errorf("%w: target doesn't reach the value between %s and %s",
+				ErrGoalNotReached, numberInput(a), numberInput(b))
+		}
+	} else {
+		x0 := params.start
+		f0, err := eval(x0)
+		if err != nil || converged(f0) {
+			return x0, f0, iterations, err
+		}
+		step := math.Abs(x0) / 100
+		if step == 0 {
+			step = 0.01
+		}
+		x1 := x0 + step
+		f1, err := eval(x1)
+		if err != nil || converged(f1) {
+			return x1, f1, iterations, err
+		}
+		for (f0 < 0) == (f1 < 0) {
+			if iterations >= params.maxIterations {
+				return 0, 0, iterations, fmt.Errorf("%w: no solution within %d iterations", ErrGoalNotReached, params.maxIterations)
+			}
+			if f0 == f1 {
+				return 0, 0, iterations, fmt.Errorf("%w: target doesn't change with the changing cell", ErrGoalNotReached)
+			}
+			x2 := x1 - f1*(x1-x0)/(f1-f0)
+			if math.IsInf(x2, 0) || math.IsNaN(x2) {
+				return 0, 0, iterations, fmt.Errorf("%w: the search diverged", ErrGoalNotReached)
+			}
+			f2, err := eval(x2)
+			if err != nil || converged(f2) {
+				return x2, f2, iterations, err
+			}
+			x0, f0, x1, f1 = x1, f1, x2, f2
+		}
+		a, fa, b, fb = x0, f0, x1, f1
+		if a > b {
+			a, fa, b, fb = b, fb, a, fa
+		}
+	}
+
+	// side remembers which end the last step replaced, an end kept twice has its value halved
+	side := 0
+	for iterations < params.maxIterations {
+		x = (a*fb - b*fa) / (fb - fa)
+		if !(x > a && x < b) {
+			x = a + (b-a)/2
+		}
+		if x <= a || x >= b {
+			// the bracket can't get any narrower in float64, the target isn't continuous there
+			return 0, 0, iterations, fmt.Errorf("%w: target jumps over the value at %s", ErrGoalNotReached, numberInput(a))
+		}
+		if fx, err = eval(x); err != nil || converged(fx) {
+			return x, fx, iterations, err
+		}
+		if (fx < 0) == (fb < 0) {
+			b, fb = x, fx
+			if side == -1 {
+				fa /= 2
+			}
+			side = -1
+		} else {
+			a, fa = x, fx
+			if side == 1 {
+				fb /= 2
+			}
+			side = 1
+		}
+	}
+	return 0, 0, iterations, fmt.Errorf("%w: no solution within %d iterations", ErrGoalNotReached, params.maxIterations)
+}
+
+// numberInput writes x the way a user would type it, without an exponent.
+func numberInput(x float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
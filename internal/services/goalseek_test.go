@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+	"dev-challenge/internal/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_seekGoal(t *testing.T) {
+	tests := []struct {
+		name          string
+		f             func(x float64) (float64, error)
+		params        goalSeekParams
+		expected      float64
+		expectedError string
+	}{
+		{
+			name:     "Linear from start",
+			f:        func(x float64) (float64, error) { return 3*x - 12, nil },
+			params:   goalSeekParams{start: 1},
+			expected: 4,
+		},
+		{
+			name:     "Start at zero",
+			f:        func(x float64) (float64, error) { return x*x*x - 8, nil },
+			params:   goalSeekParams{},
+			expected: 2,
+		},
+		{
+			name:     "Bounded picks the root within bounds",
+			f:        func(x float64) (float64, error) { return x*x - 2, nil },
+			params:   goalSeekParams{min: -5, max: 0, bounded: true},
+			expected: -math.Sqrt2,
+		},
+		{
+			name:     "Root at a bound",
+			f:        func(x float64) (float64, error) { return x - 1, nil },
+			params:   goalSeekParams{min: 1, max: 3, bounded: true},
+			expected: 1,
+		},
+		{
+			name:          "Not crossing within bounds",
+			f:             func(x float64) (float64, error) { return x*x + 1, nil },
+			params:        goalSeekParams{min: -1, max: 1, bounded: true},
+			expectedError: "goal not reached: target doesn't reach the value between -1 and 1",
+		},
+		{
+			name:          "Flat target",
+			f:             func(x float64) (float64, error) { return 5, nil },
+			params:        goalSeekParams{start: 1},
+			expectedError: "goal not reached: target doesn't change with the changing cell",
+		},
+		{
+			name: "Discontinuous target",
+			f: func(x float64) (float64, error) {
+				if x < 0.5 {
+					return -1, nil
+				}
+				return 1, nil
+			},
+			params:        goalSeekParams{min: 0, max: 1, bounded: true, maxIterations: 1000},
+			expectedError: "goal not reached: target jumps over the value at 0.49999999999999994",
+		},
+		{
+			name:          "Out of iterations",
+			f:             func(x float64) (float64, error) { return math.Exp(x) - 1e-300, nil },
+			params:        goalSeekParams{start: 1, maxIterations: 5},
+			expectedError: "goal not reached: no solution within 5 iterations",
+		},
+		{
+			name:          "Failing target",
+			f:             func(x float64) (float64, error) { return 0, errors.New("some DB error") },
+			params:        goalSeekParams{start: 1},
+			expectedError: "some DB error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			params.tolerance = 1e-9
+			if params.maxIterations == 0 {
+				params.maxIterations = 100
+			}
+			x, fx, iterations, err := seekGoal(tt.f, params)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, x, 1e-6)
+			assert.LessOrEqual(t, math.Abs(fx), 1e-9)
+			assert.LessOrEqual(t, iterations, params.maxIterations)
+		})
+	}
+}
+
+func TestExcelLikeService_goalSeek(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+	storage.EXPECT().GetSheetSettings(gomock.Any(), tx, gomock.Any()).DoAndReturn(defaultSettings).AnyTimes()
+	graph := []db.Input{
+		{SheetID: "sheet1", CellID: "price", Value: "10"},
+		{SheetID: "sheet1", CellID: "cost", Value: "=1200"},
+		{SheetID: "sheet1", CellID: "label", Value: "price"},
+		{SheetID: "sheet1", CellID: "profit", Value: "=price*100-cost", UsedParams: sheet1Cells("price", "cost")},
+	}
+
+	tests := []struct {
+		name          string
+		target        string
+		changing      string
+		mockBehavior  func()
+		expected      *models.GoalSeekResult
+		expectedError string
+	}{
+		{
+			name:     "Break-even price",
+			target:   "profit",
+			changing: "price",
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"cost", "profit"}).
+					Return(numberInputs(map[string]float64{"cost": 1200, "profit": -200}), nil)
+			},
+			expected: &models.GoalSeekResult{Target: "profit", Changing: "price", Input: 12, Result: 0, Iterations: 3},
+		},
+		{
+			name:          "Missing target",
+			target:        "margin",
+			changing:      "price",
+			expectedError: "invalid goal seek: target cell margin not found",
+		},
+		{
+			name:          "Formula changing cell",
+			target:        "profit",
+			changing:      "cost",
+			expectedError: "invalid goal seek: changing cell cost holds a formula",
+		},
+		{
+			name:          "Text changing cell",
+			target:        "profit",
+			changing:      "label",
+			expectedError: "invalid goal seek: changing cell label doesn't hold a number",
+		},
+		{
+			name:          "Independent cells",
+			target:        "cost",
+			changing:      "price",
+			expectedError: "invalid goal seek: target cell cost doesn't depend on price",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &excelLikeService{storage: storage, functions: NewDefaultFunctionRegistry(), graph: graphOf(graph...)}
+			if tt.mockBehavior != nil {
+				tt.mockBehavior()
+			}
+			target, changing := db.CellRef{SheetID: "sheet1", CellID: tt.target}, db.CellRef{SheetID: "sheet1", CellID: tt.changing}
+			params := goalSeekParams{tolerance: defaultGoalSeekTolerance, maxIterations: defaultGoalSeekIterations}
+			w, err := s.goalSeekModelOf(context.TODO(), tx, target, changing, &params)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			got, err := goalSeek(context.TODO(), w, target, changing, 0, params)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected.Input, got.Input, 1e-9)
+			assert.InDelta(t, tt.expected.Result, got.Result, defaultGoalSeekTolerance)
+			got.Input, got.Result = tt.expected.Input, tt.expected.Result
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func Test_goalSeekParamsOf(t *testing.T) {
+	lo, hi := 0.0, 1.0
+	tests := []struct {
+		name          string
+		request       models.GoalSeek
+		expected      goalSeekParams
+		expectedError string
+	}{
+		{
+			name:     "Defaults",
+			request:  models.GoalSeek{Target: "a", Changing: "b"},
+			expected: goalSeekParams{tolerance: defaultGoalSeekTolerance, maxIterations: defaultGoalSeekIterations},
+		},
+		{
+			name:     "Bounds",
+			request:  models.GoalSeek{Target: "a", Changing: "b", Min: &lo, Max: &hi, Tolerance: 0.5, MaxIterations: 10},
+			expected: goalSeekParams{min: 0, max: 1, bounded: true, tolerance: 0.5, maxIterations: 10},
+		},
+		{
+			name:          "Missing cell",
+			request:       models.GoalSeek{Target: "a"},
+			expectedError: "invalid goal seek: target and changing cells are required",
+		},
+		{
+			name:          "Same cell",
+			request:       models.GoalSeek{Target: "a", Changing: "A"},
+			expectedError: "invalid goal seek: target and changing cells must differ",
+		},
+		{
+			name:          "Only min",
+			request:       models.GoalSeek{Target: "a", Changing: "b", Min: &lo},
+			expectedError: "invalid goal seek: min and max go together",
+		},
+		{
+			name:          "Wrong bounds",
+			request:       models.GoalSeek{Target: "a", Changing: "b", Min: &hi, Max: &lo},
+			expectedError: "invalid goal seek: min must be less than max",
+		},
+		{
+			name:          "Too many iterations",
+			request:       models.GoalSeek{Target: "a", Changing: "b", MaxIterations: 1001},
+			expectedError: "invalid goal seek: max_iterations must be between 1 and 1000, 0 or none means 100",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := goalSeekParamsOf(&tt.request)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"sort"
+
+	"dev-challenge/db"
+)
+
+// dependencyGraph mirrors the stored cells and their dependency tables in memory, so a write finds every cell
+// to recalculate without querying storage level by level. Changes are journaled until the transaction they
+// belong to ends, rollback restores the graph the transaction started with.
+// The graph isn't locked itself, excelLikeService serializes writes.
+type dependencyGraph struct {
+	loaded bool
+	cells  map[db.CellRef]*graphCell
+	// dependents maps a cell to the cells referencing it directly
+	dependents map[db.CellRef]map[db.CellRef]bool
+	// ranges and patterns map a sheet to the cells using ranges or patterns of it
+	ranges   map[string]map[db.CellRef][]db.CellRange
+	patterns map[string]map[db.CellRef][]db.CellPattern
+	// deleted marks cells deleted while formulas referenced them, references to them stay #REF!
+	deleted map[db.CellRef]bool
+	// journal keeps cells changed by the current transaction as they were before it, nil for new cells,
+	// deletedJournal whether they were marked deleted
+	journal        map[db.CellRef]*graphCell
+	deletedJournal map[db.CellRef]bool
+}
+
+// graphCell is a stored cell: its formula or constant and what the formula references.
+type graphCell struct {
+	value string
+	deps  db.Dependencies
+}
+
+func newDependencyGraph() *dependencyGraph {
+	g := &dependencyGraph{}
+	g.reset()
+	return g
+}
+
+// reset empties the graph, it has to be loaded again before use.
+func (g *dependencyGraph) reset() {
+	g.loaded = false
+	g.cells = make(map[db.CellRef]*graphCell)
+	g.dependents = make(map[db.CellRef]map[db.CellRef]bool)
+	g.ranges = make(map[string]map[db.CellRef][]db.CellRange)
+	g.patterns = make(map[string]map[db.CellRef][]db.CellPattern)
+	g.deleted = make(map[db.CellRef]bool)
+	g.journal = make(map[db.CellRef]*graphCell)
+	g.deletedJournal = make(map[db.CellRef]bool)
+}
+
+// load replaces the graph with the stored cells and the cells marked deleted.
+func (g *dependencyGraph) load(inputs []db.Input, deleted []db.CellRef) {
+	g.reset()
+	for _, cell := range deleted {
+		g.deleted[cell] = true
+	}
+	for _, input := range inputs {
+		g.link(db.CellRef{SheetID: input.SheetID, CellID: input.CellID}, &graphCell{
+			value: input.Value,
+			deps:  db.Dependencies{Cells: input.UsedParams, Ranges: input.UsedRanges, Patterns: input.UsedPatterns},
+		})
+	}
+	g.loaded = true
+}
+
+// set stores the formula of cell with its dependencies.
+func (g *dependencyGraph) set(cell db.CellRef, value string, deps db.Dependencies) {
+	old := g.cells[cell]
+	if _, ok := g.journal[cell]; !ok {
+		g.journal[cell] = old
+	}
+	g.unlink(cell, old)
+	g.link(cell, &graphCell{value: value, deps: deps})
+	g.markDeleted(cell, false)
+}
+
+// remove deletes cell from the graph, cells referencing it keep their dependency on it.
+func (g *dependencyGraph) remove(cell db.CellRef) {
+	old := g.cells[cell]
+	if _, ok := g.journal[cell]; !ok {
+		g.journal[cell] = old
+	}
+	g.unlink(cell, old)
+}
+
+// markDeleted sets whether cell is a deleted cell formulas still reference.
+func (g *dependencyGraph) markDeleted(cell db.CellRef, deleted bool) {
+	if _, ok := g.deletedJournal[cell]; !ok {
+		g.deletedJournal[cell] = g.deleted[cell]
+	}
+	if deleted {
+		g.deleted[cell] = true
+	} else {
+		delete(g.deleted, cell)
+	}
+}
+
+// deletedRefs returns the cells deps references directly which are marked deleted, nil when there are none.
+func (g *dependencyGraph) deletedRefs(deps db.Dependencies) map[db.CellRef]bool {
+	var res map[db.CellRef]bool
+	for _, ref := range deps.Cells {
+		if g.deleted[ref] {
+			if res == nil {
+				res = make(map[db.CellRef]bool)
+			}
+			res[ref] = true
+		}
+	}
+	return res
+}
+
+// commit keeps the changes of the finished transaction.
+func (g *dependencyGraph) commit() {
+	g.journal = make(map[db.CellRef]*graphCell)
+	g.deletedJournal = make(map[db.CellRef]bool)
+}
+
+// rollback restores cells changed by the failed transaction.
+func (g *dependencyGraph) rollback() {
+	for cell, old := range g.journal {
+		g.unlink(cell, g.cells[cell])
+		if old != nil {
+			g.link(cell, old)
+		}
+	}
+	for cell, deleted := range g.deletedJournal {
+		if deleted {
+			g.deleted[cell] = true
+		} else {
+			delete(g.deleted, cell)
+		}
+	}
+	g.journal = make(map[db.CellRef]*graphCell)
+	g.deletedJournal = make(map[db.CellRef]bool)
+}
+
+func (g *dependencyGraph) link(cell db.CellRef, c *graphCell) {
+	g.cells[cell] = c
+	for _, ref := range c.deps.Cells {
+		if g.dependents[ref] == nil {
+			g.dependents[ref] = make(map[db.CellRef]bool)
+		}
+		g.dependents[ref][cell] = true
+	}
+	for _, r := range c.deps.Ranges {
+		if g.ranges[r.SheetID] == nil {
+			g.ranges[r.SheetID] = make(map[db.CellRef][]db.CellRange)
+		}
+		g.ranges[r.SheetID][cell] = append(g.ranges[r.SheetID][cell], r)
+	}
+	for _, p := range c.deps.Patterns {
+		if g.patterns[p.SheetID] == nil {
+			g.patterns[p.SheetID] = make(map[db.CellRef][]db.CellPattern)
+		}
+		g.patterns[p.SheetID][cell] = append(g.patterns[p.SheetID][cell], p)
+	}
+}
+
+func (g *dependencyGraph) unlink(cell db.CellRef, c *graphCell) {
+	if c == nil {
+		return
+	}
+	delete(g.cells, cell)
+	for _, ref := range c.deps.Cells {
+		delete(g.dependents[ref], cell)
+		if len(g.dependents[ref]) == 0 {
+			delete(g.dependents, ref)
+		}
+	}
+	for _, r := range c.deps.Ranges {
+		delete(g.ranges[r.SheetID], cell)
+	}
+	for _, p := range c.deps.Patterns {
+		delete(g.patterns[p.SheetID], cell)
+	}
+}
+
+// value returns the formula or constant of a stored cell.
+func (g *dependencyGraph) value(cell db.CellRef) (string, bool) {
+	c, ok := g.cells[cell]
+	if !ok {
+		return "", false
+	}
+	return c.value, true
+}
+
+// sheetCells lists the stored cells of a sheet sorted by ID.
+func (g *dependencyGraph) sheetCells(sheetID string) []db.CellRef {
+	var cells []db.CellRef
+	for cell := range g.cells {
+		if cell.SheetID == sheetID {
+			cells = append(cells, cell)
+		}
+	}
+	sortCells(cells)
+	return cells
+}
+
+// directDependents lists the cells whose formulas use cell directly, through a range or a pattern, sorted.
+// Cells referenced before they exist are included, a pattern never makes a cell depend on itself.
+func (g *dependencyGraph) directDependents(cell db.CellRef) []db.CellRef {
+	var res []db.CellRef
+	seen := make(map[db.CellRef]bool)
+	add := func(dependent db.CellRef) {
+		if !seen[dependent] {
+			seen[dependent] = true
+			res = append(res, dependent)
+		}
+	}
+	for dependent := range g.dependents[cell] {
+		add(dependent)
+	}
+	for dependent, ranges := range g.ranges[cell.SheetID] {
+		for _, r := range ranges {
+			if rangeContains(r, cell) {
+				add(dependent)
+				break
+			}
+		}
+	}
+	for dependent, patterns := range g.patterns[cell.SheetID] {
+		if dependent == cell {
+			continue
+		}
+		for _, p := range patterns {
+			if matchPattern(p.Pattern, cell.CellID) {
+				add(dependent)
+				break
+			}
+		}
+	}
+	sortCells(res)
+	return res
+}
+
+// recalculationOrder returns the stored cells among roots and all their transitive dependents, each of them once
+// and after every cell it uses. Cells still forming a cycle, which writes never let happen, come last.
+func (g *dependencyGraph) recalculationOrder(roots []db.CellRef) []db.CellRef {
+	dependents := make(map[db.CellRef][]db.CellRef)
+	var cells []db.CellRef
+	queue := append([]db.CellRef(nil), roots...)
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		if _, ok := dependents[cell]; ok {
+			continue
+		}
+		dependents[cell] = g.directDependents(cell)
+		cells = append(cells, cell)
+		queue = append(queue, dependents[cell]...)
+	}
+
+	inDegree := make(map[db.CellRef]int, len(cells))
+	for _, cell := range cells {
+		for _, dependent := range dependents[cell] {
+			inDegree[dependent]++
+		}
+	}
+	sortCells(cells)
+	var ready []db.CellRef
+	for _, cell := range cells {
+		if inDegree[cell] == 0 {
+			ready = append(ready, cell)
+		}
+	}
+
+	order := make([]db.CellRef, 0, len(cells))
+	done := make(map[db.CellRef]bool, len(cells))
+	for len(ready) > 0 {
+		cell := ready[0]
+		ready = ready[1:]
+		order = append(order, cell)
+		done[cell] = true
+		for _, dependent := range dependents[cell] {
+			if inDegree[dependent]--; inDegree[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	for _, cell := range cells {
+		if !done[cell] {
+			order = append(order, cell)
+		}
+	}
+
+	res := order[:0]
+	for _, cell := range order {
+		if _, ok := g.cells[cell]; ok {
+			res = append(res, cell)
+		}
+	}
+	return res
+}
+
+// directPrecedents lists the cells the formula of cell uses, sorted. Referenced cells are included even when
+// they don't exist yet, ranges and patterns only bring in the stored cells they cover.
+func (g *dependencyGraph) directPrecedents(cell db.CellRef) []db.CellRef {
+	c, ok := g.cells[cell]
+	if !ok {
+		return nil
+	}
+	return g.precedents(cell, c.deps)
+}
+
+// precedents is directPrecedents of cell with the formula using deps, which doesn't have to be stored yet.
+func (g *dependencyGraph) precedents(cell db.CellRef, deps db.Dependencies) []db.CellRef {
+	var res []db.CellRef
+	seen := make(map[db.CellRef]bool)
+	add := func(precedent db.CellRef) {
+		if !seen[precedent] {
+			seen[precedent] = true
+			res = append(res, precedent)
+		}
+	}
+	for _, ref := range deps.Cells {
+		add(ref)
+	}
+	if len(deps.Ranges) > 0 || len(deps.Patterns) > 0 {
+		for stored := range g.cells {
+			for _, r := range deps.Ranges {
+				if rangeContains(r, stored) {
+					add(stored)
+				}
+			}
+			for _, p := range deps.Patterns {
+				if stored != cell && stored.SheetID == p.SheetID && matchPattern(p.Pattern, stored.CellID) {
+					add(stored)
+				}
+			}
+		}
+	}
+	sortCells(res)
+	return res
+}
+
+// linkedCell is a cell reached by walk at the given depth.
+type linkedCell struct {
+	cell  db.CellRef
+	depth int
+}
+
+// walk follows next from cell breadth first and returns every cell reached, once, at the depth it was first
+// reached. A positive maxDepth stops the walk at that depth, cell itself is never part of the result.
+func (g *dependencyGraph) walk(cell db.CellRef, maxDepth int, next func(db.CellRef) []db.CellRef) []linkedCell {
+	var res []linkedCell
+	seen := map[db.CellRef]bool{cell: true}
+	level := []db.CellRef{cell}
+	for depth := 1; len(level) > 0 && (maxDepth <= 0 || depth <= maxDepth); depth++ {
+		var nextLevel []db.CellRef
+		for _, c := range level {
+			for _, linked := range next(c) {
+				if seen[linked] {
+					continue
+				}
+				seen[linked] = true
+				res = append(res, linkedCell{cell: linked, depth: depth})
+				nextLevel = append(nextLevel, linked)
+			}
+		}
+		level = nextLevel
+	}
+	return res
+}
+
+func sortCells(cells []db.CellRef) {
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].SheetID != cells[j].SheetID {
+			return cells[i].SheetID < cells[j].SheetID
+		}
+		return cells[i].CellID < cells[j].CellID
+	})
+}
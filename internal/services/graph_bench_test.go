@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newBenchService returns a service backed by a fresh SQLite database holding the given cells of "sheet1".
+// The cells are stored directly with results left at 0, the measured writes recalculate them.
+func newBenchService(b *testing.B, cells [][2]string) ExcelLikeService {
+	b.Helper()
+	conn, err := sql.Open("sqlite3", filepath.Join(b.TempDir(), "bench.db")+"?_foreign_keys=on")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { conn.Close() })
+	ctx := context.Background()
+	if err := db.Migrate(ctx, conn); err != nil {
+		b.Fatal(err)
+	}
+
+	storage := db.NewStorage(conn)
+	tx, err := storage.BeginTransaction(ctx)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, cell := range cells {
+		formula, err := parseFormula(cell[1])
+		if err != nil {
+			b.Fatal(err)
+		}
+		input := db.Input{
+			SheetID:      "sheet1",
+			CellID:       cell[0],
+			Value:        cell[1],
+			UsedParams:   extractParams(formula, "sheet1"),
+			UsedRanges:   extractRanges(formula, "sheet1"),
+			UsedPatterns: extractPatterns(formula, "sheet1"),
+		}
+		if _, _, err := storage.AddCellInput(ctx, tx, input); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatal(err)
+	}
+	return NewExcelLikeService(storage, nil)
+}
+
+// benchmarkUpdate measures writes of cellID, which recalculate all of its dependents. The first write
+// isn't measured, it loads the dependency graph.
+func benchmarkUpdate(b *testing.B, s ExcelLikeService, cellID string) {
+	write := func(i int) {
+		if _, err := s.AddCellInputTX(context.Background(), "sheet1", cellID, &models.Data{Value: strconv.Itoa(i + 2)}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	write(-1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		write(i)
+	}
+}
+
+// BenchmarkRecalculateChain updates the head of a chain c0 <- c1 <- ... <- c9999.
+func BenchmarkRecalculateChain(b *testing.B) {
+	cells := [][2]string{{"c0", "1"}}
+	for i := 1; i < 10000; i++ {
+		cells = append(cells, [2]string{fmt.Sprintf("c%d", i), fmt.Sprintf("=c%d+1", i-1)})
+	}
+	benchmarkUpdate(b, newBenchService(b, cells), "c0")
+}
+
+// BenchmarkRecalculateChainTail writes a cell at the end of the chain of BenchmarkRecalculateChain, nothing
+// depends on it, so the write is dominated by checking its 10000 precedents for a loop.
+func BenchmarkRecalculateChainTail(b *testing.B) {
+	cells := [][2]string{{"c0", "1"}}
+	for i := 1; i < 10000; i++ {
+		cells = append(cells, [2]string{fmt.Sprintf("c%d", i), fmt.Sprintf("=c%d+1", i-1)})
+	}
+	s := newBenchService(b, cells)
+	write := func() {
+		if _, err := s.AddCellInputTX(context.Background(), "sheet1", "tail", &models.Data{Value: "=c9999+1"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	write()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		write()
+	}
+}
+
+// BenchmarkRecalculateFanOut updates a cell used by 10000 others.
+func BenchmarkRecalculateFanOut(b *testing.B) {
+	cells := [][2]string{{"src", "1"}}
+	for i := 0; i < 10000; i++ {
+		cells = append(cells, [2]string{fmt.Sprintf("f%d", i), fmt.Sprintf("=src*%d", i)})
+	}
+	benchmarkUpdate(b, newBenchService(b, cells), "src")
+}
+
+// BenchmarkRecalculateDiamond updates a cell used by 1000 cells, which are all summed up by a single one,
+// so every path ends in the same total.
+func BenchmarkRecalculateDiamond(b *testing.B) {
+	cells := [][2]string{{"src", "1"}}
+	for i := 0; i < 1000; i++ {
+		cells = append(cells, [2]string{fmt.Sprintf("m_%d", i), fmt.Sprintf("=src+%d", i)})
+	}
+	cells = append(cells, [2]string{"total", "=SUM(m_*)"})
+	benchmarkUpdate(b, newBenchService(b, cells), "src")
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// formulaCell mocks a stored cell of "sheet1" referencing the given cells of the sheet.
+func formulaCell(cellID string, refs ...string) db.Input {
+	input := db.Input{SheetID: "sheet1", CellID: cellID, Value: "1"}
+	if len(refs) > 0 {
+		input.Value = "=" + strings.Join(refs, "+")
+	}
+	for _, ref := range refs {
+		input.UsedParams = append(input.UsedParams, db.CellRef{SheetID: "sheet1", CellID: ref})
+	}
+	return input
+}
+
+func sheet1Cells(cellIDs ...string) []db.CellRef {
+	cells := make([]db.CellRef, len(cellIDs))
+	for i, cellID := range cellIDs {
+		cells[i] = db.CellRef{SheetID: "sheet1", CellID: cellID}
+	}
+	return cells
+}
+
+func Test_dependencyGraph_recalculationOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		cells    []db.Input
+		roots    []db.CellRef
+		expected []db.CellRef
+	}{
+		{
+			name:     "Chain",
+			cells:    []db.Input{formulaCell("a"), formulaCell("c", "b"), formulaCell("b", "a")},
+			roots:    sheet1Cells("b"),
+			expected: sheet1Cells("b", "c"),
+		},
+		{
+			name:     "Diamond calculates the joining cell once",
+			cells:    []db.Input{formulaCell("b", "a"), formulaCell("c", "a"), formulaCell("d", "c", "b"), formulaCell("e", "d", "a")},
+			roots:    sheet1Cells("c", "b", "e"),
+			expected: sheet1Cells("b", "c", "d", "e"),
+		},
+		{
+			name: "Ranges and patterns",
+			cells: []db.Input{
+				{SheetID: "sheet1", CellID: "a2", Value: "1"},
+				{SheetID: "sheet1", CellID: "total", Value: "=sum(a1:a3)", UsedRanges: []db.CellRange{{SheetID: "sheet1", FromCol: 1, FromRow: 1, ToCol: 1, ToRow: 3}}},
+				{SheetID: "sheet1", CellID: "sales_total", Value: "=sum(sales_*)", UsedPatterns: []db.CellPattern{{SheetID: "sheet1", Pattern: "sales_*"}}},
+				formulaCell("sales_jan", "total"),
+			},
+			roots:    sheet1Cells("a2"),
+			expected: sheet1Cells("a2", "total", "sales_jan", "sales_total"),
+		},
+		{
+			name: "Other sheets",
+			cells: []db.Input{
+				{SheetID: "sheet1", CellID: "rate", Value: "0.2"},
+				{SheetID: "budget", CellID: "tax", Value: "=sheet1!rate*2", UsedParams: sheet1Cells("rate")},
+			},
+			roots:    sheet1Cells("rate"),
+			expected: []db.CellRef{{SheetID: "sheet1", CellID: "rate"}, {SheetID: "budget", CellID: "tax"}},
+		},
+		{
+			name:     "Cells that don't exist are skipped",
+			cells:    []db.Input{formulaCell("b", "a")},
+			roots:    sheet1Cells("a"),
+			expected: sheet1Cells("b"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, graphOf(tt.cells...).recalculationOrder(tt.roots))
+		})
+	}
+}
+
+func Test_dependencyGraph_rollback(t *testing.T) {
+	g := graphOf(formulaCell("a"), formulaCell("b", "a"))
+
+	g.set(db.CellRef{SheetID: "sheet1", CellID: "b"}, "=c", db.Dependencies{Cells: sheet1Cells("c")})
+	g.set(db.CellRef{SheetID: "sheet1", CellID: "d"}, "=a", db.Dependencies{Cells: sheet1Cells("a")})
+	assert.Equal(t, sheet1Cells("d"), g.directDependents(db.CellRef{SheetID: "sheet1", CellID: "a"}))
+	assert.Equal(t, sheet1Cells("b"), g.directDependents(db.CellRef{SheetID: "sheet1", CellID: "c"}))
+
+	g.rollback()
+	assert.Equal(t, sheet1Cells("b"), g.directDependents(db.CellRef{SheetID: "sheet1", CellID: "a"}))
+	assert.Empty(t, g.directDependents(db.CellRef{SheetID: "sheet1", CellID: "c"}))
+	assert.Equal(t, sheet1Cells("a", "b"), g.sheetCells("sheet1"))
+
+	// committed changes are kept by later rollbacks
+	g.set(db.CellRef{SheetID: "sheet1", CellID: "d"}, "=a", db.Dependencies{Cells: sheet1Cells("a")})
+	g.commit()
+	g.rollback()
+	assert.Equal(t, sheet1Cells("b", "d"), g.directDependents(db.CellRef{SheetID: "sheet1", CellID: "a"}))
+
+	// removed cells come back on rollback, cells referencing them keep depending on them meanwhile
+	g.remove(db.CellRef{SheetID: "sheet1", CellID: "a"})
+	g.remove(db.CellRef{SheetID: "sheet1", CellID: "b"})
+	assert.Equal(t, sheet1Cells("d"), g.sheetCells("sheet1"))
+	assert.Equal(t, sheet1Cells("d"), g.directDependents(db.CellRef{SheetID: "sheet1", CellID: "a"}))
+	g.rollback()
+	assert.Equal(t, sheet1Cells("a", "b", "d"), g.sheetCells("sheet1"))
+	assert.Equal(t, sheet1Cells("b", "d"), g.directDependents(db.CellRef{SheetID: "sheet1", CellID: "a"}))
+
+	// so do deleted marks, writing a deleted cell again clears its mark
+	a, b := db.CellRef{SheetID: "sheet1", CellID: "a"}, db.CellRef{SheetID: "sheet1", CellID: "b"}
+	g.markDeleted(b, true)
+	g.commit()
+	g.remove(a)
+	g.markDeleted(a, true)
+	g.set(b, "1", db.Dependencies{})
+	assert.Equal(t, map[db.CellRef]bool{a: true}, g.deletedRefs(db.Dependencies{Cells: sheet1Cells("a", "b")}))
+	g.rollback()
+	assert.Equal(t, map[db.CellRef]bool{b: true}, g.deletedRefs(db.Dependencies{Cells: sheet1Cells("a", "b")}))
+}
+
+func TestExcelLikeService_loadGraph(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	s := &excelLikeService{storage: storage, graph: newDependencyGraph()}
+
+	storage.EXPECT().GetAllInputs(gomock.Any(), nil).Return(nil, errors.New("some DB error"))
+	assert.EqualError(t, s.loadGraph(context.TODO(), nil), "some DB error")
+
+	// the graph is read once
+	storage.EXPECT().GetAllInputs(gomock.Any(), nil).Return([]db.Input{formulaCell("a"), formulaCell("b", "a", "c")}, nil)
+	storage.EXPECT().GetDeletedCells(gomock.Any(), nil).Return(sheet1Cells("c"), nil)
+	assert.NoError(t, s.loadGraph(context.TODO(), nil))
+	assert.NoError(t, s.loadGraph(context.TODO(), nil))
+	assert.Equal(t, sheet1Cells("b"), s.graph.directDependents(db.CellRef{SheetID: "sheet1", CellID: "a"}))
+	assert.Equal(t, map[db.CellRef]bool{{SheetID: "sheet1", CellID: "c"}: true}, s.graph.deletedRefs(db.Dependencies{Cells: sheet1Cells("a", "c")}))
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"dev-challenge/db"
+)
+
+// maxRangeSize limits the number of cells a single range reference may cover.
+const maxRangeSize = 10000
+
+// a1Pattern matches cell IDs addressing the grid, the column is up to three letters like in spreadsheets.
+var a1Pattern = regexp.MustCompile(`^([a-z]{1,3})([1-9][0-9]{0,6})$`)
+
+// parseA1 returns 1-based column and row of a grid cell ID like "b12".
+func parseA1(cellID string) (col, row int, ok bool) {
+	m := a1Pattern.FindStringSubmatch(strings.ToLower(cellID))
+	if m == nil {
+		return 0, 0, false
+	}
+	for _, r := range m[1] {
+		col = col*26 + int(r-'a'+1)
+	}
+	row, _ = strconv.Atoi(m[2])
+	return col, row, true
+}
+
+// formatA1 is the reverse of parseA1.
+func formatA1(col, row int) string {
+	var letters []byte
+	for ; col > 0; col = (col - 1) / 26 {
+		letters = append([]byte{byte('a' + (col-1)%26)}, letters...)
+	}
+	return string(letters) + strconv.Itoa(row)
+}
+
+// newCellRange builds a normalized range of sheetID between two grid cells given in any order.
+func newCellRange(sheetID, from, to string) (db.CellRange, bool) {
+	fromCol, fromRow, ok := parseA1(from)
+	if !ok {
+		return db.CellRange{}, false
+	}
+	toCol, toRow, ok := parseA1(to)
+	if !ok {
+		return db.CellRange{}, false
+	}
+	if fromCol > toCol {
+		fromCol, toCol = toCol, fromCol
+	}
+	if fromRow > toRow {
+		fromRow, toRow = toRow, fromRow
+	}
+	return db.CellRange{SheetID: sheetID, FromCol: fromCol, FromRow: fromRow, ToCol: toCol, ToRow: toRow}, true
+}
+
+func rangeSize(r db.CellRange) int {
+	return (r.ToCol - r.FromCol + 1) * (r.ToRow - r.FromRow + 1)
+}
+
+// rangeCells lists the cells of the range row by row.
+func rangeCells(r db.CellRange) []db.CellRef {
+	cells := make([]db.CellRef, 0, rangeSize(r))
+	for row := r.FromRow; row <= r.ToRow; row++ {
+		for col := r.FromCol; col <= r.ToCol; col++ {
+			cells = append(cells, db.CellRef{SheetID: r.SheetID, CellID: formatA1(col, row)})
+		}
+	}
+	return cells
+}
+
+func rangeContains(r db.CellRange, cell db.CellRef) bool {
+	col, row, ok := parseA1(cell.CellID)
+	return ok && cell.SheetID == r.SheetID && r.FromCol <= col && col <= r.ToCol && r.FromRow <= row && row <= r.ToRow
+}
+
+// formatRange renders r the way it is referenced from a formula on sheetID.
+func formatRange(r db.CellRange, sheetID string) string {
+	res := formatA1(r.FromCol, r.FromRow) + ":" + formatA1(r.ToCol, r.ToRow)
+	if r.SheetID != sheetID {
+		return r.SheetID + "!" + res
+	}
+	return res
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+
+	"dev-challenge/db"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseA1(t *testing.T) {
+	tests := []struct {
+		cellID  string
+		wantCol int
+		wantRow int
+		wantOk  bool
+	}{
+		{cellID: "a1", wantCol: 1, wantRow: 1, wantOk: true},
+		{cellID: "b12", wantCol: 2, wantRow: 12, wantOk: true},
+		{cellID: "z3", wantCol: 26, wantRow: 3, wantOk: true},
+		{cellID: "aa3", wantCol: 27, wantRow: 3, wantOk: true},
+		{cellID: "zzz1", wantCol: 18278, wantRow: 1, wantOk: true},
+		{cellID: "a0", wantOk: false},
+		{cellID: "abcd1", wantOk: false},
+		{cellID: "total", wantOk: false},
+		{cellID: "1a", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cellID, func(t *testing.T) {
+			col, row, ok := parseA1(tt.cellID)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantCol, col)
+			assert.Equal(t, tt.wantRow, row)
+			if ok {
+				assert.Equal(t, tt.cellID, formatA1(col, row))
+			}
+		})
+	}
+}
+
+func Test_newCellRange(t *testing.T) {
+	r, ok := newCellRange("sheet1", "c10", "a2")
+	assert.True(t, ok)
+	assert.Equal(t, db.CellRange{SheetID: "sheet1", FromCol: 1, FromRow: 2, ToCol: 3, ToRow: 10}, r)
+	assert.Equal(t, 27, rangeSize(r))
+	assert.Equal(t, "a2:c10", formatRange(r, "sheet1"))
+	assert.Equal(t, "sheet1!a2:c10", formatRange(r, "sheet2"))
+
+	assert.True(t, rangeContains(r, db.CellRef{SheetID: "sheet1", CellID: "b5"}))
+	assert.False(t, rangeContains(r, db.CellRef{SheetID: "sheet1", CellID: "d5"}))
+	assert.False(t, rangeContains(r, db.CellRef{SheetID: "sheet2", CellID: "b5"}))
+	assert.False(t, rangeContains(r, db.CellRef{SheetID: "sheet1", CellID: "total"}))
+
+	small, _ := newCellRange("sheet1", "a1", "b2")
+	assert.Equal(t, []db.CellRef{
+		{SheetID: "sheet1", CellID: "a1"},
+		{SheetID: "sheet1", CellID: "b1"},
+		{SheetID: "sheet1", CellID: "a2"},
+		{SheetID: "sheet1", CellID: "b2"},
+	}, rangeCells(small))
+
+	_, ok = newCellRange("sheet1", "a1", "total")
+	assert.False(t, ok)
+}
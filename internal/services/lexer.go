@@ -0,0 +1,196 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokQuotedRef
+	tokPattern
+	tokString
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+	tokBang
+	tokColon
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// numberPattern matches the literals accepted by the formula language, including scientific notation like 2e3.
+var numberPattern = regexp.MustCompile(`^(\d+\.?\d*|\.\d+)(e[+-]?\d+)?$`)
+
+// tokenize splits a formula body into tokens. Positions are byte offsets into the original input,
+// offset is added to every position so callers can tokenize a substring of the cell value.
+func tokenize(expr string, offset int) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		r, size := utf8.DecodeRuneInString(expr[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '\'':
+			text, next, err := scanQuoted(expr, i)
+			if err != nil {
+				return nil, syntaxErrorf(offset+i, "%v at position %d", err, offset+i)
+			}
+			tokens = append(tokens, token{kind: tokQuotedRef, text: text, pos: offset + i})
+			i = next
+		case r == '"':
+			text, next, err := scanString(expr, i)
+			if err != nil {
+				return nil, syntaxErrorf(offset+i, "%v at position %d", err, offset+i)
+			}
+			tokens = append(tokens, token{kind: tokString, text: text, pos: offset + i})
+			i = next
+		case isWordRune(r) || r == '?' || (r == '*' && expectsOperand(tokens)):
+			word, next := scanWord(expr, i)
+			kind := tokIdent
+			if strings.ContainsAny(word, "*?") {
+				kind = tokPattern
+			} else if numberPattern.MatchString(strings.ToLower(word)) {
+				kind = tokNumber
+			}
+			tokens = append(tokens, token{kind: kind, text: word, pos: offset + i})
+			i = next
+		case r == '+' || r == '-' || r == '*' || r == '/' || r == '&' || r == '=':
+			tokens = append(tokens, token{kind: tokOperator, text: string(r), pos: offset + i})
+			i += size
+		case r == '<' || r == '>':
+			op := string(r)
+			if next := expr[i+size:]; strings.HasPrefix(next, "=") || r == '<' && strings.HasPrefix(next, ">") {
+				op += next[:1]
+			}
+			tokens = append(tokens, token{kind: tokOperator, text: op, pos: offset + i})
+			i += len(op)
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: offset + i})
+			i += size
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: offset + i})
+			i += size
+		case r == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: offset + i})
+			i += size
+		case r == '!':
+			tokens = append(tokens, token{kind: tokBang, text: "!", pos: offset + i})
+			i += size
+		case r == ':':
+			tokens = append(tokens, token{kind: tokColon, text: ":", pos: offset + i})
+			i += size
+		default:
+			return nil, syntaxErrorf(offset+i, "unexpected character %q at position %d", r, offset+i)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: offset + len(expr)}), nil
+}
+
+// isWordRune reports whether r may appear in an unquoted cell reference or number.
+// Any other URL compatible character, i.e. operators, requires the reference to be quoted.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.~$@", r)
+}
+
+// expectsOperand reports whether the next token has to start an operand rather than follow one.
+func expectsOperand(tokens []token) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	switch tokens[len(tokens)-1].kind {
+	case tokNumber, tokIdent, tokQuotedRef, tokPattern, tokString, tokRParen:
+		return false
+	}
+	return true
+}
+
+// scanWord reads an unquoted word starting at start. An exponent sign is consumed as part of
+// the word when it follows a numeric mantissa, so "2e+3" stays a single number.
+// Wildcards make the word a pattern: "?" is allowed anywhere, while "*" is only taken when it starts
+// the word or ends a function argument, so "a*b" and "2*" are still a multiplication.
+func scanWord(expr string, start int) (string, int) {
+	i := start
+	for i < len(expr) {
+		r, size := utf8.DecodeRuneInString(expr[i:])
+		if isWordRune(r) || r == '?' {
+			i += size
+			continue
+		}
+		if r == '*' && (expr[start] == '*' || endsArgument(expr[i+1:]) && !numberPattern.MatchString(strings.ToLower(expr[start:i]))) {
+			i += size
+			continue
+		}
+		if (r == '+' || r == '-') && i+1 < len(expr) && unicode.IsDigit(rune(expr[i+1])) && isExponentPrefix(expr[start:i]) {
+			i += size
+			continue
+		}
+		break
+	}
+	return expr[start:i], i
+}
+
+// endsArgument reports whether rest, ignoring leading spaces, closes a function argument.
+func endsArgument(rest string) bool {
+	rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+	return strings.HasPrefix(rest, ")") || strings.HasPrefix(rest, ",")
+}
+
+// isExponentPrefix reports whether word is a mantissa followed by an exponent marker, i.e. "2e" or "1.5E".
+func isExponentPrefix(word string) bool {
+	word = strings.ToLower(word)
+	return strings.HasSuffix(word, "e") && numberPattern.MatchString(word+"0")
+}
+
+// scanQuoted reads a quoted reference like 'a+b' starting at the opening quote.
+// A doubled quote inside the reference stands for a literal quote.
+func scanQuoted(expr string, start int) (string, int, error) {
+	var sb strings.Builder
+	for i := start + 1; i < len(expr); i++ {
+		if expr[i] != '\'' {
+			sb.WriteByte(expr[i])
+			continue
+		}
+		if i+1 < len(expr) && expr[i+1] == '\'' {
+			sb.WriteByte('\'')
+			i++
+			continue
+		}
+		if sb.Len() == 0 {
+			return "", 0, fmt.Errorf("empty quoted reference")
+		}
+		return sb.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("unterminated quoted reference")
+}
+
+// scanString reads a string literal like "total: " starting at the opening quote.
+// A doubled quote inside the literal stands for a literal quote, the literal may be empty.
+func scanString(expr string, start int) (string, int, error) {
+	var sb strings.Builder
+	for i := start + 1; i < len(expr); i++ {
+		if expr[i] != '"' {
+			sb.WriteByte(expr[i])
+			continue
+		}
+		if i+1 < len(expr) && expr[i+1] == '"' {
+			sb.WriteByte('"')
+			i++
+			continue
+		}
+		return sb.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
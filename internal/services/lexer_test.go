@@ -0,0 +1,165 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_tokenize(t *testing.T) {
+	type args struct {
+		expr string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []token
+		wantErr bool
+	}{
+		{
+			name: "Operators and identifiers",
+			args: args{expr: "a+b1*(2-c)"},
+			want: []token{
+				{kind: tokIdent, text: "a", pos: 0},
+				{kind: tokOperator, text: "+", pos: 1},
+				{kind: tokIdent, text: "b1", pos: 2},
+				{kind: tokOperator, text: "*", pos: 4},
+				{kind: tokLParen, text: "(", pos: 5},
+				{kind: tokNumber, text: "2", pos: 6},
+				{kind: tokOperator, text: "-", pos: 7},
+				{kind: tokIdent, text: "c", pos: 8},
+				{kind: tokRParen, text: ")", pos: 9},
+				{kind: tokEOF, pos: 10},
+			},
+		},
+		{
+			name: "Range",
+			args: args{expr: "sum(a1:b2)"},
+			want: []token{
+				{kind: tokIdent, text: "sum", pos: 0},
+				{kind: tokLParen, text: "(", pos: 3},
+				{kind: tokIdent, text: "a1", pos: 4},
+				{kind: tokColon, text: ":", pos: 6},
+				{kind: tokIdent, text: "b2", pos: 7},
+				{kind: tokRParen, text: ")", pos: 9},
+				{kind: tokEOF, pos: 10},
+			},
+		},
+		{
+			name: "Patterns",
+			args: args{expr: "sum(sales_*, *_jan, q? , a*b)"},
+			want: []token{
+				{kind: tokIdent, text: "sum", pos: 0},
+				{kind: tokLParen, text: "(", pos: 3},
+				{kind: tokPattern, text: "sales_*", pos: 4},
+				{kind: tokComma, text: ",", pos: 11},
+				{kind: tokPattern, text: "*_jan", pos: 13},
+				{kind: tokComma, text: ",", pos: 18},
+				{kind: tokPattern, text: "q?", pos: 20},
+				{kind: tokComma, text: ",", pos: 23},
+				{kind: tokIdent, text: "a", pos: 25},
+				{kind: tokOperator, text: "*", pos: 26},
+				{kind: tokIdent, text: "b", pos: 27},
+				{kind: tokRParen, text: ")", pos: 28},
+				{kind: tokEOF, pos: 29},
+			},
+		},
+		{
+			name: "Multiplication by a trailing star is not a pattern",
+			args: args{expr: "2*)"},
+			want: []token{
+				{kind: tokNumber, text: "2", pos: 0},
+				{kind: tokOperator, text: "*", pos: 1},
+				{kind: tokRParen, text: ")", pos: 2},
+				{kind: tokEOF, pos: 3},
+			},
+		},
+		{
+			name: "Scientific notation",
+			args: args{expr: "2e3 - 1.5e-2"},
+			want: []token{
+				{kind: tokNumber, text: "2e3", pos: 0},
+				{kind: tokOperator, text: "-", pos: 4},
+				{kind: tokNumber, text: "1.5e-2", pos: 6},
+				{kind: tokEOF, pos: 12},
+			},
+		},
+		{
+			name: "Identifier starting with digits",
+			args: args{expr: "1x-2e"},
+			want: []token{
+				{kind: tokIdent, text: "1x", pos: 0},
+				{kind: tokOperator, text: "-", pos: 2},
+				{kind: tokIdent, text: "2e", pos: 3},
+				{kind: tokEOF, pos: 5},
+			},
+		},
+		{
+			name: "Quoted reference with escaped quote",
+			args: args{expr: "'a+b'/'it''s'"},
+			want: []token{
+				{kind: tokQuotedRef, text: "a+b", pos: 0},
+				{kind: tokOperator, text: "/", pos: 5},
+				{kind: tokQuotedRef, text: "it's", pos: 6},
+				{kind: tokEOF, pos: 13},
+			},
+		},
+		{
+			name: "Comparison operators",
+			args: args{expr: "a<>b<=c>=d<e>f=g"},
+			want: []token{
+				{kind: tokIdent, text: "a", pos: 0},
+				{kind: tokOperator, text: "<>", pos: 1},
+				{kind: tokIdent, text: "b", pos: 3},
+				{kind: tokOperator, text: "<=", pos: 4},
+				{kind: tokIdent, text: "c", pos: 6},
+				{kind: tokOperator, text: ">=", pos: 7},
+				{kind: tokIdent, text: "d", pos: 9},
+				{kind: tokOperator, text: "<", pos: 10},
+				{kind: tokIdent, text: "e", pos: 11},
+				{kind: tokOperator, text: ">", pos: 12},
+				{kind: tokIdent, text: "f", pos: 13},
+				{kind: tokOperator, text: "=", pos: 14},
+				{kind: tokIdent, text: "g", pos: 15},
+				{kind: tokEOF, pos: 16},
+			},
+		},
+		{
+			name: "Comparison with negative number",
+			args: args{expr: "a>-1"},
+			want: []token{
+				{kind: tokIdent, text: "a", pos: 0},
+				{kind: tokOperator, text: ">", pos: 1},
+				{kind: tokOperator, text: "-", pos: 2},
+				{kind: tokNumber, text: "1", pos: 3},
+				{kind: tokEOF, pos: 4},
+			},
+		},
+		{
+			name:    "Unterminated quoted reference",
+			args:    args{expr: "'a+b"},
+			wantErr: true,
+		},
+		{
+			name:    "Empty quoted reference",
+			args:    args{expr: "''+1"},
+			wantErr: true,
+		},
+		{
+			name:    "Unexpected character",
+			args:    args{expr: "5^2"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tokenize(tt.args.expr, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("tokenize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+// GetPrecedents lists the cells the formula of a cell uses, up to depth levels away, 0 meaning no limit.
+// It returns nil when the cell doesn't exist.
+func (s *excelLikeService) GetPrecedents(ctx context.Context, sheetID, cellID string, depth int) (*models.CellLinks, error) {
+	return s.cellLinks(ctx, db.CellRef{SheetID: sheetID, CellID: cellID}, depth, s.graph.directPrecedents)
+}
+
+// GetDependents lists the cells whose formulas use a cell, up to depth levels away, 0 meaning no limit.
+// It returns nil when the cell doesn't exist.
+func (s *excelLikeService) GetDependents(ctx context.Context, sheetID, cellID string, depth int) (*models.CellLinks, error) {
+	return s.cellLinks(ctx, db.CellRef{SheetID: sheetID, CellID: cellID}, depth, s.graph.directDependents)
+}
+
+func (s *excelLikeService) cellLinks(ctx context.Context, cell db.CellRef, depth int, next func(db.CellRef) []db.CellRef) (*models.CellLinks, error) {
+	var res *models.CellLinks
+	err := s.readGraph(ctx, func() {
+		if _, ok := s.graph.value(cell); !ok {
+			return
+		}
+		res = &models.CellLinks{Sheet: cell.SheetID, ID: cell.CellID, Cells: []models.LinkedCell{}}
+		for _, linked := range s.graph.walk(cell, depth, next) {
+			_, stored := s.graph.value(linked.cell)
+			res.Cells = append(res.Cells, models.LinkedCell{
+				Sheet:   linked.cell.SheetID,
+				ID:      linked.cell.CellID,
+				Depth:   linked.depth,
+				Missing: !stored,
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetSheetGraph exports the dependencies of the cells of a sheet, including the links to cells of other sheets.
+// It returns nil when the sheet has no cells.
+func (s *excelLikeService) GetSheetGraph(ctx context.Context, sheetID string) (*models.SheetGraph, error) {
+	var res *models.SheetGraph
+	err := s.readGraph(ctx, func() {
+		cells := s.graph.sheetCells(sheetID)
+		if len(cells) == 0 {
+			return
+		}
+		res = &models.SheetGraph{Sheet: sheetID, Nodes: []models.GraphNode{}, Edges: []models.GraphEdge{}}
+		nodes := make(map[db.CellRef]bool)
+		edges := make(map[models.GraphEdge]bool)
+		addEdge := func(from, to db.CellRef) {
+			nodes[from], nodes[to] = true, true
+			edges[models.GraphEdge{From: from.String(), To: to.String()}] = true
+		}
+		for _, cell := range cells {
+			nodes[cell] = true
+			for _, precedent := range s.graph.directPrecedents(cell) {
+				addEdge(precedent, cell)
+			}
+			for _, dependent := range s.graph.directDependents(cell) {
+				addEdge(cell, dependent)
+			}
+		}
+
+		refs := make([]db.CellRef, 0, len(nodes))
+		for cell := range nodes {
+			refs = append(refs, cell)
+		}
+		sortCells(refs)
+		for _, cell := range refs {
+			value, stored := s.graph.value(cell)
+			res.Nodes = append(res.Nodes, models.GraphNode{
+				Ref:     cell.String(),
+				Sheet:   cell.SheetID,
+				ID:      cell.CellID,
+				Value:   value,
+				Missing: !stored,
+			})
+		}
+		for edge := range edges {
+			res.Edges = append(res.Edges, edge)
+		}
+		sort.Slice(res.Edges, func(i, j int) bool {
+			if res.Edges[i].From != res.Edges[j].From {
+				return res.Edges[i].From < res.Edges[j].From
+			}
+			return res.Edges[i].To < res.Edges[j].To
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// readGraph runs fn on the loaded dependency graph while no write is in progress.
+func (s *excelLikeService) readGraph(ctx context.Context, fn func()) error {
+	s.mu.RLock()
+	if s.graph.loaded {
+		defer s.mu.RUnlock()
+		fn()
+		return nil
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadGraph(ctx, nil); err != nil {
+		return err
+	}
+	fn()
+	return nil
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+	"dev-challenge/internal/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// linksGraph is a chain a -> b -> c with d referencing the missing cell m, total summing a1:a3 and
+// sheet2!x referencing c.
+func linksGraph() []db.Input {
+	return []db.Input{
+		formulaCell("a"),
+		formulaCell("b", "a"),
+		formulaCell("c", "b", "a"),
+		formulaCell("d", "c", "m"),
+		formulaCell("a1"),
+		formulaCell("a2"),
+		{SheetID: "sheet1", CellID: "total", Value: "=sum(a1:a3)", UsedRanges: []db.CellRange{{SheetID: "sheet1", FromCol: 1, FromRow: 1, ToCol: 1, ToRow: 3}}},
+		{SheetID: "sheet2", CellID: "x", Value: "=sheet1!c", UsedParams: []db.CellRef{{SheetID: "sheet1", CellID: "c"}}},
+	}
+}
+
+func TestExcelLikeService_GetPrecedents(t *testing.T) {
+	tests := []struct {
+		name     string
+		cellID   string
+		depth    int
+		expected *models.CellLinks
+	}{
+		{
+			name:   "Transitive, each cell at the depth it is first reached",
+			cellID: "d",
+			expected: &models.CellLinks{Sheet: "sheet1", ID: "d", Cells: []models.LinkedCell{
+				{Sheet: "sheet1", ID: "c", Depth: 1},
+				{Sheet: "sheet1", ID: "m", Depth: 1, Missing: true},
+				{Sheet: "sheet1", ID: "a", Depth: 2},
+				{Sheet: "sheet1", ID: "b", Depth: 2},
+			}},
+		},
+		{
+			name:   "Depth limit",
+			cellID: "d",
+			depth:  1,
+			expected: &models.CellLinks{Sheet: "sheet1", ID: "d", Cells: []models.LinkedCell{
+				{Sheet: "sheet1", ID: "c", Depth: 1},
+				{Sheet: "sheet1", ID: "m", Depth: 1, Missing: true},
+			}},
+		},
+		{
+			name:   "Range brings in its stored cells",
+			cellID: "total",
+			expected: &models.CellLinks{Sheet: "sheet1", ID: "total", Cells: []models.LinkedCell{
+				{Sheet: "sheet1", ID: "a1", Depth: 1},
+				{Sheet: "sheet1", ID: "a2", Depth: 1},
+			}},
+		},
+		{
+			name:     "Constant",
+			cellID:   "a",
+			expected: &models.CellLinks{Sheet: "sheet1", ID: "a", Cells: []models.LinkedCell{}},
+		},
+		{
+			name:   "Cell not found",
+			cellID: "m",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &excelLikeService{graph: graphOf(linksGraph()...)}
+			got, err := s.GetPrecedents(context.TODO(), "sheet1", tt.cellID, tt.depth)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestExcelLikeService_GetDependents(t *testing.T) {
+	tests := []struct {
+		name     string
+		cellID   string
+		depth    int
+		expected *models.CellLinks
+	}{
+		{
+			name:   "Transitive across sheets",
+			cellID: "a",
+			expected: &models.CellLinks{Sheet: "sheet1", ID: "a", Cells: []models.LinkedCell{
+				{Sheet: "sheet1", ID: "b", Depth: 1},
+				{Sheet: "sheet1", ID: "c", Depth: 1},
+				{Sheet: "sheet1", ID: "d", Depth: 2},
+				{Sheet: "sheet2", ID: "x", Depth: 2},
+			}},
+		},
+		{
+			name:   "Depth limit",
+			cellID: "b",
+			depth:  1,
+			expected: &models.CellLinks{Sheet: "sheet1", ID: "b", Cells: []models.LinkedCell{
+				{Sheet: "sheet1", ID: "c", Depth: 1},
+			}},
+		},
+		{
+			name:   "Used through a range",
+			cellID: "a2",
+			expected: &models.CellLinks{Sheet: "sheet1", ID: "a2", Cells: []models.LinkedCell{
+				{Sheet: "sheet1", ID: "total", Depth: 1},
+			}},
+		},
+		{
+			name:   "Cell not found",
+			cellID: "missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &excelLikeService{graph: graphOf(linksGraph()...)}
+			got, err := s.GetDependents(context.TODO(), "sheet1", tt.cellID, tt.depth)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestExcelLikeService_GetSheetGraph(t *testing.T) {
+	s := &excelLikeService{graph: graphOf(
+		formulaCell("a"),
+		formulaCell("b", "a", "m"),
+		db.Input{SheetID: "sheet1", CellID: "c", Value: "=sheet2!y", UsedParams: []db.CellRef{{SheetID: "sheet2", CellID: "y"}}},
+		db.Input{SheetID: "sheet2", CellID: "x", Value: "=sheet1!b", UsedParams: []db.CellRef{{SheetID: "sheet1", CellID: "b"}}},
+		db.Input{SheetID: "sheet2", CellID: "y", Value: "2"},
+	)}
+
+	got, err := s.GetSheetGraph(context.TODO(), "sheet1")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.SheetGraph{
+		Sheet: "sheet1",
+		Nodes: []models.GraphNode{
+			{Ref: "sheet1!a", Sheet: "sheet1", ID: "a", Value: "1"},
+			{Ref: "sheet1!b", Sheet: "sheet1", ID: "b", Value: "=a+m"},
+			{Ref: "sheet1!c", Sheet: "sheet1", ID: "c", Value: "=sheet2!y"},
+			{Ref: "sheet1!m", Sheet: "sheet1", ID: "m", Missing: true},
+			{Ref: "sheet2!x", Sheet: "sheet2", ID: "x", Value: "=sheet1!b"},
+			{Ref: "sheet2!y", Sheet: "sheet2", ID: "y", Value: "2"},
+		},
+		Edges: []models.GraphEdge{
+			{From: "sheet1!a", To: "sheet1!b"},
+			{From: "sheet1!b", To: "sheet2!x"},
+			{From: "sheet1!m", To: "sheet1!b"},
+			{From: "sheet2!y", To: "sheet1!c"},
+		},
+	}, got)
+
+	got, err = s.GetSheetGraph(context.TODO(), "empty")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestExcelLikeService_readGraph(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	s := &excelLikeService{storage: storage, graph: newDependencyGraph()}
+
+	storage.EXPECT().GetAllInputs(gomock.Any(), nil).Return(nil, errors.New("some DB error"))
+	_, err := s.GetDependents(context.TODO(), "sheet1", "a", 0)
+	assert.EqualError(t, err, "some DB error")
+
+	// the first query loads the graph, later ones read it
+	storage.EXPECT().GetAllInputs(gomock.Any(), nil).Return([]db.Input{formulaCell("a"), formulaCell("b", "a")}, nil)
+	storage.EXPECT().GetDeletedCells(gomock.Any(), nil).Return(nil, nil)
+	for i := 0; i < 2; i++ {
+		got, err := s.GetDependents(context.TODO(), "sheet1", "a", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.LinkedCell{{Sheet: "sheet1", ID: "b", Depth: 1}}, got.Cells)
+	}
+}
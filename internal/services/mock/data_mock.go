@@ -6,8 +6,8 @@ package mock_services
 
 import (
 	context "context"
-	sql "database/sql"
 	models "dev-challenge/internal/models"
+	services "dev-challenge/internal/services"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -36,34 +36,169 @@ func (m *MockExcelLikeService) EXPECT() *MockExcelLikeServiceMockRecorder {
 	return m.recorder
 }
 
-// AddCellInput mocks base method.
-func (m *MockExcelLikeService) AddCellInput(ctx context.Context, tx *sql.Tx, sheetID, cellID string, inputData *models.Data) (*models.Data, error) {
+// AddCellInputTX mocks base method.
+func (m *MockExcelLikeService) AddCellInputTX(ctx context.Context, sheetID, cellID string, inputData *models.Data) (*models.Data, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddCellInput", ctx, tx, sheetID, cellID, inputData)
+	ret := m.ctrl.Call(m, "AddCellInputTX", ctx, sheetID, cellID, inputData)
 	ret0, _ := ret[0].(*models.Data)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AddCellInput indicates an expected call of AddCellInput.
-func (mr *MockExcelLikeServiceMockRecorder) AddCellInput(ctx, tx, sheetID, cellID, inputData interface{}) *gomock.Call {
+// AddCellInputTX indicates an expected call of AddCellInputTX.
+func (mr *MockExcelLikeServiceMockRecorder) AddCellInputTX(ctx, sheetID, cellID, inputData interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCellInput", reflect.TypeOf((*MockExcelLikeService)(nil).AddCellInput), ctx, tx, sheetID, cellID, inputData)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCellInputTX", reflect.TypeOf((*MockExcelLikeService)(nil).AddCellInputTX), ctx, sheetID, cellID, inputData)
 }
 
-// AddCellInputTX mocks base method.
-func (m *MockExcelLikeService) AddCellInputTX(ctx context.Context, sheetID, cellID string, inputData *models.Data) (*models.Data, error) {
+// AddCellInputsTX mocks base method.
+func (m *MockExcelLikeService) AddCellInputsTX(ctx context.Context, sheetID string, inputs map[string]models.Data) (map[string]models.Data, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddCellInputTX", ctx, sheetID, cellID, inputData)
-	ret0, _ := ret[0].(*models.Data)
+	ret := m.ctrl.Call(m, "AddCellInputsTX", ctx, sheetID, inputs)
+	ret0, _ := ret[0].(map[string]models.Data)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AddCellInputTX indicates an expected call of AddCellInputTX.
-func (mr *MockExcelLikeServiceMockRecorder) AddCellInputTX(ctx, sheetID, cellID, inputData interface{}) *gomock.Call {
+// AddCellInputsTX indicates an expected call of AddCellInputsTX.
+func (mr *MockExcelLikeServiceMockRecorder) AddCellInputsTX(ctx, sheetID, inputs interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCellInputTX", reflect.TypeOf((*MockExcelLikeService)(nil).AddCellInputTX), ctx, sheetID, cellID, inputData)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCellInputsTX", reflect.TypeOf((*MockExcelLikeService)(nil).AddCellInputsTX), ctx, sheetID, inputs)
+}
+
+// AddCellTX mocks base method.
+func (m *MockExcelLikeService) AddCellTX(ctx context.Context, sheetID, cellID string, inputData *models.CellInput, withDependents bool) (*models.Cell, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCellTX", ctx, sheetID, cellID, inputData, withDependents)
+	ret0, _ := ret[0].(*models.Cell)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddCellTX indicates an expected call of AddCellTX.
+func (mr *MockExcelLikeServiceMockRecorder) AddCellTX(ctx, sheetID, cellID, inputData, withDependents interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCellTX", reflect.TypeOf((*MockExcelLikeService)(nil).AddCellTX), ctx, sheetID, cellID, inputData, withDependents)
+}
+
+// AddCellsTX mocks base method.
+func (m *MockExcelLikeService) AddCellsTX(ctx context.Context, sheetID string, inputs map[string]models.CellInput) (map[string]models.Cell, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCellsTX", ctx, sheetID, inputs)
+	ret0, _ := ret[0].(map[string]models.Cell)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddCellsTX indicates an expected call of AddCellsTX.
+func (mr *MockExcelLikeServiceMockRecorder) AddCellsTX(ctx, sheetID, inputs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCellsTX", reflect.TypeOf((*MockExcelLikeService)(nil).AddCellsTX), ctx, sheetID, inputs)
+}
+
+// CompareScenarios mocks base method.
+func (m *MockExcelLikeService) CompareScenarios(ctx context.Context, sheetID string, names, cells []string) (*models.ScenarioComparison, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareScenarios", ctx, sheetID, names, cells)
+	ret0, _ := ret[0].(*models.ScenarioComparison)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareScenarios indicates an expected call of CompareScenarios.
+func (mr *MockExcelLikeServiceMockRecorder) CompareScenarios(ctx, sheetID, names, cells interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareScenarios", reflect.TypeOf((*MockExcelLikeService)(nil).CompareScenarios), ctx, sheetID, names, cells)
+}
+
+// DeleteCellTX mocks base method.
+func (m *MockExcelLikeService) DeleteCellTX(ctx context.Context, sheetID, cellID string, policy services.DeletePolicy) (*models.Deletion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCellTX", ctx, sheetID, cellID, policy)
+	ret0, _ := ret[0].(*models.Deletion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteCellTX indicates an expected call of DeleteCellTX.
+func (mr *MockExcelLikeServiceMockRecorder) DeleteCellTX(ctx, sheetID, cellID, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCellTX", reflect.TypeOf((*MockExcelLikeService)(nil).DeleteCellTX), ctx, sheetID, cellID, policy)
+}
+
+// DeleteScenarioTX mocks base method.
+func (m *MockExcelLikeService) DeleteScenarioTX(ctx context.Context, sheetID, name string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteScenarioTX", ctx, sheetID, name)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteScenarioTX indicates an expected call of DeleteScenarioTX.
+func (mr *MockExcelLikeServiceMockRecorder) DeleteScenarioTX(ctx, sheetID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScenarioTX", reflect.TypeOf((*MockExcelLikeService)(nil).DeleteScenarioTX), ctx, sheetID, name)
+}
+
+// DeleteSheetTX mocks base method.
+func (m *MockExcelLikeService) DeleteSheetTX(ctx context.Context, sheetID string, policy services.DeletePolicy) (*models.Deletion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSheetTX", ctx, sheetID, policy)
+	ret0, _ := ret[0].(*models.Deletion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSheetTX indicates an expected call of DeleteSheetTX.
+func (mr *MockExcelLikeServiceMockRecorder) DeleteSheetTX(ctx, sheetID, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSheetTX", reflect.TypeOf((*MockExcelLikeService)(nil).DeleteSheetTX), ctx, sheetID, policy)
+}
+
+// EvaluateScenario mocks base method.
+func (m *MockExcelLikeService) EvaluateScenario(ctx context.Context, sheetID string, scenario *models.Scenario) (*models.ScenarioResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvaluateScenario", ctx, sheetID, scenario)
+	ret0, _ := ret[0].(*models.ScenarioResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvaluateScenario indicates an expected call of EvaluateScenario.
+func (mr *MockExcelLikeServiceMockRecorder) EvaluateScenario(ctx, sheetID, scenario interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateScenario", reflect.TypeOf((*MockExcelLikeService)(nil).EvaluateScenario), ctx, sheetID, scenario)
+}
+
+// ExplainCell mocks base method.
+func (m *MockExcelLikeService) ExplainCell(ctx context.Context, sheetID, cellID string) (*models.Explanation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExplainCell", ctx, sheetID, cellID)
+	ret0, _ := ret[0].(*models.Explanation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExplainCell indicates an expected call of ExplainCell.
+func (mr *MockExcelLikeServiceMockRecorder) ExplainCell(ctx, sheetID, cellID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExplainCell", reflect.TypeOf((*MockExcelLikeService)(nil).ExplainCell), ctx, sheetID, cellID)
+}
+
+// GetCell mocks base method.
+func (m *MockExcelLikeService) GetCell(ctx context.Context, sheetID, cellID string) (*models.Cell, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCell", ctx, sheetID, cellID)
+	ret0, _ := ret[0].(*models.Cell)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCell indicates an expected call of GetCell.
+func (mr *MockExcelLikeServiceMockRecorder) GetCell(ctx, sheetID, cellID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCell", reflect.TypeOf((*MockExcelLikeService)(nil).GetCell), ctx, sheetID, cellID)
 }
 
 // GetCellInput mocks base method.
@@ -81,6 +216,110 @@ func (mr *MockExcelLikeServiceMockRecorder) GetCellInput(ctx, sheetID, cellID in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCellInput", reflect.TypeOf((*MockExcelLikeService)(nil).GetCellInput), ctx, sheetID, cellID)
 }
 
+// GetDependents mocks base method.
+func (m *MockExcelLikeService) GetDependents(ctx context.Context, sheetID, cellID string, depth int) (*models.CellLinks, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDependents", ctx, sheetID, cellID, depth)
+	ret0, _ := ret[0].(*models.CellLinks)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDependents indicates an expected call of GetDependents.
+func (mr *MockExcelLikeServiceMockRecorder) GetDependents(ctx, sheetID, cellID, depth interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDependents", reflect.TypeOf((*MockExcelLikeService)(nil).GetDependents), ctx, sheetID, cellID, depth)
+}
+
+// GetFunctions mocks base method.
+func (m *MockExcelLikeService) GetFunctions(ctx context.Context) []models.Function {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFunctions", ctx)
+	ret0, _ := ret[0].([]models.Function)
+	return ret0
+}
+
+// GetFunctions indicates an expected call of GetFunctions.
+func (mr *MockExcelLikeServiceMockRecorder) GetFunctions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFunctions", reflect.TypeOf((*MockExcelLikeService)(nil).GetFunctions), ctx)
+}
+
+// GetPrecedents mocks base method.
+func (m *MockExcelLikeService) GetPrecedents(ctx context.Context, sheetID, cellID string, depth int) (*models.CellLinks, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPrecedents", ctx, sheetID, cellID, depth)
+	ret0, _ := ret[0].(*models.CellLinks)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPrecedents indicates an expected call of GetPrecedents.
+func (mr *MockExcelLikeServiceMockRecorder) GetPrecedents(ctx, sheetID, cellID, depth interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrecedents", reflect.TypeOf((*MockExcelLikeService)(nil).GetPrecedents), ctx, sheetID, cellID, depth)
+}
+
+// GetScenario mocks base method.
+func (m *MockExcelLikeService) GetScenario(ctx context.Context, sheetID, name string) (*models.ScenarioResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScenario", ctx, sheetID, name)
+	ret0, _ := ret[0].(*models.ScenarioResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScenario indicates an expected call of GetScenario.
+func (mr *MockExcelLikeServiceMockRecorder) GetScenario(ctx, sheetID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScenario", reflect.TypeOf((*MockExcelLikeService)(nil).GetScenario), ctx, sheetID, name)
+}
+
+// GetScenarios mocks base method.
+func (m *MockExcelLikeService) GetScenarios(ctx context.Context, sheetID string) ([]models.Scenario, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScenarios", ctx, sheetID)
+	ret0, _ := ret[0].([]models.Scenario)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScenarios indicates an expected call of GetScenarios.
+func (mr *MockExcelLikeServiceMockRecorder) GetScenarios(ctx, sheetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScenarios", reflect.TypeOf((*MockExcelLikeService)(nil).GetScenarios), ctx, sheetID)
+}
+
+// GetSheetCells mocks base method.
+func (m *MockExcelLikeService) GetSheetCells(ctx context.Context, sheetID string) (map[string]models.Cell, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSheetCells", ctx, sheetID)
+	ret0, _ := ret[0].(map[string]models.Cell)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSheetCells indicates an expected call of GetSheetCells.
+func (mr *MockExcelLikeServiceMockRecorder) GetSheetCells(ctx, sheetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSheetCells", reflect.TypeOf((*MockExcelLikeService)(nil).GetSheetCells), ctx, sheetID)
+}
+
+// GetSheetGraph mocks base method.
+func (m *MockExcelLikeService) GetSheetGraph(ctx context.Context, sheetID string) (*models.SheetGraph, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSheetGraph", ctx, sheetID)
+	ret0, _ := ret[0].(*models.SheetGraph)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSheetGraph indicates an expected call of GetSheetGraph.
+func (mr *MockExcelLikeServiceMockRecorder) GetSheetGraph(ctx, sheetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSheetGraph", reflect.TypeOf((*MockExcelLikeService)(nil).GetSheetGraph), ctx, sheetID)
+}
+
 // GetSheetInput mocks base method.
 func (m *MockExcelLikeService) GetSheetInput(ctx context.Context, sheetID string) (map[string]models.Data, error) {
 	m.ctrl.T.Helper()
@@ -95,3 +334,122 @@ func (mr *MockExcelLikeServiceMockRecorder) GetSheetInput(ctx, sheetID interface
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSheetInput", reflect.TypeOf((*MockExcelLikeService)(nil).GetSheetInput), ctx, sheetID)
 }
+
+// GetSheetSettings mocks base method.
+func (m *MockExcelLikeService) GetSheetSettings(ctx context.Context, sheetID string) (*models.SheetSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSheetSettings", ctx, sheetID)
+	ret0, _ := ret[0].(*models.SheetSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSheetSettings indicates an expected call of GetSheetSettings.
+func (mr *MockExcelLikeServiceMockRecorder) GetSheetSettings(ctx, sheetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSheetSettings", reflect.TypeOf((*MockExcelLikeService)(nil).GetSheetSettings), ctx, sheetID)
+}
+
+// GoalSeekTX mocks base method.
+func (m *MockExcelLikeService) GoalSeekTX(ctx context.Context, sheetID string, request *models.GoalSeek) (*models.GoalSeekResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GoalSeekTX", ctx, sheetID, request)
+	ret0, _ := ret[0].(*models.GoalSeekResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GoalSeekTX indicates an expected call of GoalSeekTX.
+func (mr *MockExcelLikeServiceMockRecorder) GoalSeekTX(ctx, sheetID, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GoalSeekTX", reflect.TypeOf((*MockExcelLikeService)(nil).GoalSeekTX), ctx, sheetID, request)
+}
+
+// LoadDependencyGraph mocks base method.
+func (m *MockExcelLikeService) LoadDependencyGraph(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadDependencyGraph", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LoadDependencyGraph indicates an expected call of LoadDependencyGraph.
+func (mr *MockExcelLikeServiceMockRecorder) LoadDependencyGraph(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadDependencyGraph", reflect.TypeOf((*MockExcelLikeService)(nil).LoadDependencyGraph), ctx)
+}
+
+// SaveScenarioTX mocks base method.
+func (m *MockExcelLikeService) SaveScenarioTX(ctx context.Context, sheetID, name string, scenario *models.Scenario) (*models.ScenarioResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveScenarioTX", ctx, sheetID, name, scenario)
+	ret0, _ := ret[0].(*models.ScenarioResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveScenarioTX indicates an expected call of SaveScenarioTX.
+func (mr *MockExcelLikeServiceMockRecorder) SaveScenarioTX(ctx, sheetID, name, scenario interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveScenarioTX", reflect.TypeOf((*MockExcelLikeService)(nil).SaveScenarioTX), ctx, sheetID, name, scenario)
+}
+
+// Simulate mocks base method.
+func (m *MockExcelLikeService) Simulate(ctx context.Context, sheetID string, request *models.Simulation) (*models.SimulationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Simulate", ctx, sheetID, request)
+	ret0, _ := ret[0].(*models.SimulationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Simulate indicates an expected call of Simulate.
+func (mr *MockExcelLikeServiceMockRecorder) Simulate(ctx, sheetID, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Simulate", reflect.TypeOf((*MockExcelLikeService)(nil).Simulate), ctx, sheetID, request)
+}
+
+// SolveTX mocks base method.
+func (m *MockExcelLikeService) SolveTX(ctx context.Context, sheetID string, request *models.Solve) (*models.SolveResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SolveTX", ctx, sheetID, request)
+	ret0, _ := ret[0].(*models.SolveResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SolveTX indicates an expected call of SolveTX.
+func (mr *MockExcelLikeServiceMockRecorder) SolveTX(ctx, sheetID, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SolveTX", reflect.TypeOf((*MockExcelLikeService)(nil).SolveTX), ctx, sheetID, request)
+}
+
+// UpdateSheetSettingsTX mocks base method.
+func (m *MockExcelLikeService) UpdateSheetSettingsTX(ctx context.Context, sheetID string, settings *models.SheetSettings) (*models.SheetSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSheetSettingsTX", ctx, sheetID, settings)
+	ret0, _ := ret[0].(*models.SheetSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSheetSettingsTX indicates an expected call of UpdateSheetSettingsTX.
+func (mr *MockExcelLikeServiceMockRecorder) UpdateSheetSettingsTX(ctx, sheetID, settings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSheetSettingsTX", reflect.TypeOf((*MockExcelLikeService)(nil).UpdateSheetSettingsTX), ctx, sheetID, settings)
+}
+
+// ValidateCellTX mocks base method.
+func (m *MockExcelLikeService) ValidateCellTX(ctx context.Context, sheetID, cellID string, inputData *models.CellInput) (*models.Validation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateCellTX", ctx, sheetID, cellID, inputData)
+	ret0, _ := ret[0].(*models.Validation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateCellTX indicates an expected call of ValidateCellTX.
+func (mr *MockExcelLikeServiceMockRecorder) ValidateCellTX(ctx, sheetID, cellID, inputData interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateCellTX", reflect.TypeOf((*MockExcelLikeService)(nil).ValidateCellTX), ctx, sheetID, cellID, inputData)
+}
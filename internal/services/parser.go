@@ -0,0 +1,278 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"dev-challenge/db"
+)
+
+// node is an element of a parsed formula tree.
+type node interface {
+	Pos() int
+}
+
+// numberNode is a number literal, text keeps its digits so sheets in decimal mode read it exactly.
+type numberNode struct {
+	pos   int
+	value float64
+	text  string
+}
+
+// boolNode is a TRUE or FALSE literal.
+type boolNode struct {
+	pos   int
+	value bool
+}
+
+// textNode is a string literal or the value of a text cell.
+type textNode struct {
+	pos   int
+	value string
+}
+
+// refNode is a reference to another cell, sheetID is empty for cells of the formula's own sheet.
+type refNode struct {
+	pos     int
+	sheetID string
+	cellID  string
+}
+
+// rangeNode is a rectangular range of grid cells like a1:b10, cells.SheetID is empty for the formula's own sheet.
+type rangeNode struct {
+	pos   int
+	cells db.CellRange
+}
+
+// patternNode is a set of cells whose IDs match a wildcard pattern like sales_*, cells.SheetID is empty
+// for the formula's own sheet.
+type patternNode struct {
+	pos   int
+	cells db.CellPattern
+}
+
+type unaryNode struct {
+	pos     int
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	pos         int
+	op          string
+	left, right node
+}
+
+// callNode is a function call like SUM(a, b*2). Names are kept in lower case.
+type callNode struct {
+	pos  int
+	name string
+	args []node
+}
+
+func (n *numberNode) Pos() int  { return n.pos }
+func (n *boolNode) Pos() int    { return n.pos }
+func (n *textNode) Pos() int    { return n.pos }
+func (n *refNode) Pos() int     { return n.pos }
+func (n *rangeNode) Pos() int   { return n.pos }
+func (n *patternNode) Pos() int { return n.pos }
+func (n *unaryNode) Pos() int   { return n.pos }
+func (n *binaryNode) Pos() int  { return n.pos }
+func (n *callNode) Pos() int    { return n.pos }
+
+// parseFormula builds a formula tree from a cell value. Values without the "=" prefix are plain numbers
+// or text, a leading apostrophe keeps values like '=x or '12 as text and is not part of it.
+func parseFormula(value string) (node, error) {
+	if !strings.HasPrefix(value, "=") {
+		if number, ok := parseNumber(value); ok {
+			return &numberNode{value: number, text: value}, nil
+		}
+		return &textNode{value: strings.TrimPrefix(value, "'")}, nil
+	}
+
+	tokens, err := tokenize(value[1:], 1)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, unexpectedToken(tok)
+	}
+	return root, nil
+}
+
+type parser struct {
+	tokens []token
+	cur    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.cur]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.cur]
+	if tok.kind != tokEOF {
+		p.cur++
+	}
+	return tok
+}
+
+func (p *parser) parseExpr() (node, error) {
+	return p.parseBinary(p.parseConcat, "=", "<>", "<", "<=", ">", ">=")
+}
+
+func (p *parser) parseConcat() (node, error) {
+	return p.parseBinary(p.parseSum, "&")
+}
+
+func (p *parser) parseSum() (node, error) {
+	return p.parseBinary(p.parseTerm, "+", "-")
+}
+
+func (p *parser) parseTerm() (node, error) {
+	return p.parseBinary(p.parseUnary, "*", "/")
+}
+
+// parseBinary parses a left associative chain of operands joined by any of ops.
+func (p *parser) parseBinary(operand func() (node, error), ops ...string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokOperator || !contains(ops, tok.text) {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: tok.pos, op: tok.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok := p.peek()
+	if tok.kind == tokOperator && (tok.text == "-" || tok.text == "+") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: tok.pos, op: tok.text, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		number, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, syntaxErrorf(tok.pos, "invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return &numberNode{pos: tok.pos, value: number, text: tok.text}, nil
+	case tokString:
+		return &textNode{pos: tok.pos, value: tok.text}, nil
+	case tokIdent:
+		switch next := p.peek().kind; {
+		case next == tokLParen:
+			return p.parseCall(tok)
+		case next != tokBang && next != tokColon && (strings.EqualFold(tok.text, "true") || strings.EqualFold(tok.text, "false")):
+			return &boolNode{pos: tok.pos, value: strings.EqualFold(tok.text, "true")}, nil
+		}
+		return p.parseRef(tok)
+	case tokQuotedRef:
+		return p.parseRef(tok)
+	case tokPattern:
+		return &patternNode{pos: tok.pos, cells: db.CellPattern{Pattern: tok.text}}, nil
+	case tokLParen:
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, syntaxErrorf(tok.pos, "missing closing parenthesis for position %d", tok.pos)
+		}
+		return inner, nil
+	default:
+		return nil, unexpectedToken(tok)
+	}
+}
+
+// parseRef parses a reference like cell, 'a+b', sheet!cell, 'my sheet'!'a+b', a range like sheet!a1:b10
+// or a pattern like sheet!sales_*, first is its already consumed first part.
+func (p *parser) parseRef(first token) (node, error) {
+	ref := &refNode{pos: first.pos, cellID: first.text}
+	if p.peek().kind == tokBang {
+		p.next()
+		cell := p.next()
+		if cell.kind == tokPattern {
+			return &patternNode{pos: first.pos, cells: db.CellPattern{SheetID: first.text, Pattern: cell.text}}, nil
+		}
+		if cell.kind != tokIdent && cell.kind != tokQuotedRef {
+			return nil, unexpectedToken(cell)
+		}
+		ref.sheetID, ref.cellID = first.text, cell.text
+	}
+	if p.peek().kind != tokColon {
+		return ref, nil
+	}
+
+	p.next()
+	last := p.next()
+	if last.kind != tokIdent {
+		return nil, unexpectedToken(last)
+	}
+	cells, ok := newCellRange(ref.sheetID, ref.cellID, last.text)
+	if !ok {
+		return nil, syntaxErrorf(first.pos, "invalid range %s:%s at position %d", ref.cellID, last.text, first.pos)
+	}
+	if rangeSize(cells) > maxRangeSize {
+		return nil, syntaxErrorf(first.pos, "range %s:%s at position %d is larger than %d cells", ref.cellID, last.text, first.pos, maxRangeSize)
+	}
+	return &rangeNode{pos: first.pos, cells: cells}, nil
+}
+
+// parseCall parses the argument list of a function call, name is the already consumed function name.
+func (p *parser) parseCall(name token) (node, error) {
+	open := p.next()
+	call := &callNode{pos: name.pos, name: strings.ToLower(name.text)}
+	if p.peek().kind == tokRParen {
+		p.next()
+		return call, nil
+	}
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+
+		switch tok := p.next(); tok.kind {
+		case tokComma:
+			continue
+		case tokRParen:
+			return call, nil
+		case tokEOF:
+			return nil, syntaxErrorf(open.pos, "missing closing parenthesis for position %d", open.pos)
+		default:
+			return nil, unexpectedToken(tok)
+		}
+	}
+}
+
+func unexpectedToken(tok token) error {
+	if tok.kind == tokEOF {
+		return syntaxErrorf(tok.pos, "unexpected end of formula")
+	}
+	return syntaxErrorf(tok.pos, "unexpected %q at position %d", tok.text, tok.pos)
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"dev-challenge/db"
+)
+
+func Test_parseFormula(t *testing.T) {
+	type args struct {
+		value string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    node
+		wantErr string
+	}{
+		{
+			name: "Plain number",
+			args: args{value: "2.5"},
+			want: &numberNode{value: 2.5, text: "2.5"},
+		},
+		{
+			name: "Operator precedence",
+			args: args{value: "=1+a*2"},
+			want: &binaryNode{pos: 2, op: "+",
+				left: &numberNode{pos: 1, value: 1, text: "1"},
+				right: &binaryNode{pos: 4, op: "*",
+					left:  &refNode{pos: 3, cellID: "a"},
+					right: &numberNode{pos: 5, value: 2, text: "2"},
+				},
+			},
+		},
+		{
+			name: "Parentheses and unary minus",
+			args: args{value: "=-(a-'b-c')"},
+			want: &unaryNode{pos: 1, op: "-",
+				operand: &binaryNode{pos: 4, op: "-",
+					left:  &refNode{pos: 3, cellID: "a"},
+					right: &refNode{pos: 5, cellID: "b-c"},
+				},
+			},
+		},
+		{
+			name: "Left associativity",
+			args: args{value: "=8/4/2"},
+			want: &binaryNode{pos: 4, op: "/",
+				left: &binaryNode{pos: 2, op: "/",
+					left:  &numberNode{pos: 1, value: 8, text: "8"},
+					right: &numberNode{pos: 3, value: 4, text: "4"},
+				},
+				right: &numberNode{pos: 5, value: 2, text: "2"},
+			},
+		},
+		{
+			name: "Function call",
+			args: args{value: "=SUM(a, 2*b)"},
+			want: &callNode{pos: 1, name: "sum", args: []node{
+				&refNode{pos: 5, cellID: "a"},
+				&binaryNode{pos: 9, op: "*",
+					left:  &numberNode{pos: 8, value: 2, text: "2"},
+					right: &refNode{pos: 10, cellID: "b"},
+				},
+			}},
+		},
+		{
+			name: "Function call without arguments",
+			args: args{value: "=sum()"},
+			want: &callNode{pos: 1, name: "sum"},
+		},
+		{
+			name: "Function name used as reference",
+			args: args{value: "=sum+1"},
+			want: &binaryNode{pos: 4, op: "+",
+				left:  &refNode{pos: 1, cellID: "sum"},
+				right: &numberNode{pos: 5, value: 1, text: "1"},
+			},
+		},
+		{
+			name:    "Unclosed function call",
+			args:    args{value: "=sum(1,2"},
+			wantErr: "missing closing parenthesis for position 4",
+		},
+		{
+			name: "Reference to other sheet",
+			args: args{value: "=assumptions!tax_rate*'my sheet'!'a+b'"},
+			want: &binaryNode{pos: 21, op: "*",
+				left:  &refNode{pos: 1, sheetID: "assumptions", cellID: "tax_rate"},
+				right: &refNode{pos: 22, sheetID: "my sheet", cellID: "a+b"},
+			},
+		},
+		{
+			name:    "Reference to other sheet without cell",
+			args:    args{value: "=other!+1"},
+			wantErr: `unexpected "+" at position 7`,
+		},
+		{
+			name: "Range as function argument",
+			args: args{value: "=sum(b10:a1, data!c2:c3)"},
+			want: &callNode{pos: 1, name: "sum", args: []node{
+				&rangeNode{pos: 5, cells: db.CellRange{FromCol: 1, FromRow: 1, ToCol: 2, ToRow: 10}},
+				&rangeNode{pos: 13, cells: db.CellRange{SheetID: "data", FromCol: 3, FromRow: 2, ToCol: 3, ToRow: 3}},
+			}},
+		},
+		{
+			name: "Pattern as function argument",
+			args: args{value: "=sum(sales_*, data!*_jan)"},
+			want: &callNode{pos: 1, name: "sum", args: []node{
+				&patternNode{pos: 5, cells: db.CellPattern{Pattern: "sales_*"}},
+				&patternNode{pos: 14, cells: db.CellPattern{SheetID: "data", Pattern: "*_jan"}},
+			}},
+		},
+		{
+			name:    "Pattern used as sheet",
+			args:    args{value: "=sum(data_*!a1)"},
+			wantErr: `unexpected "!" at position 11`,
+		},
+		{
+			name:    "Range of non-grid cells",
+			args:    args{value: "=sum(a1:total)"},
+			wantErr: "invalid range a1:total at position 5",
+		},
+		{
+			name:    "Range too large",
+			args:    args{value: "=sum(a1:z1000)"},
+			wantErr: "range a1:z1000 at position 5 is larger than 10000 cells",
+		},
+		{
+			name: "Text",
+			args: args{value: "Paid"},
+			want: &textNode{value: "Paid"},
+		},
+		{
+			name: "Apostrophe keeps numbers and formulas as text",
+			args: args{value: "'=1+2"},
+			want: &textNode{value: "=1+2"},
+		},
+		{
+			name: "Concatenation has lower precedence than arithmetic",
+			args: args{value: `="n="&1+2`},
+			want: &binaryNode{pos: 5, op: "&",
+				left: &textNode{pos: 1, value: "n="},
+				right: &binaryNode{pos: 7, op: "+",
+					left:  &numberNode{pos: 6, value: 1, text: "1"},
+					right: &numberNode{pos: 8, value: 2, text: "2"},
+				},
+			},
+		},
+		{
+			name: "Comparison has lower precedence than concatenation",
+			args: args{value: `=a&"x"<>b+1`},
+			want: &binaryNode{pos: 6, op: "<>",
+				left: &binaryNode{pos: 2, op: "&",
+					left:  &refNode{pos: 1, cellID: "a"},
+					right: &textNode{pos: 3, value: "x"},
+				},
+				right: &binaryNode{pos: 9, op: "+",
+					left:  &refNode{pos: 8, cellID: "b"},
+					right: &numberNode{pos: 10, value: 1, text: "1"},
+				},
+			},
+		},
+		{
+			name: "Boolean literals",
+			args: args{value: "=true=FALSE"},
+			want: &binaryNode{pos: 5, op: "=",
+				left:  &boolNode{pos: 1, value: true},
+				right: &boolNode{pos: 6, value: false},
+			},
+		},
+		{
+			name: "Quoted and other sheet cells named like booleans stay references",
+			args: args{value: "='true'+flags!false"},
+			want: &binaryNode{pos: 7, op: "+",
+				left:  &refNode{pos: 1, cellID: "true"},
+				right: &refNode{pos: 8, sheetID: "flags", cellID: "false"},
+			},
+		},
+		{
+			name:    "Unterminated string",
+			args:    args{value: `="abc`},
+			wantErr: "unterminated string at position 1",
+		},
+		{
+			name:    "Missing closing parenthesis",
+			args:    args{value: "=(1+2"},
+			wantErr: "missing closing parenthesis for position 1",
+		},
+		{
+			name:    "Trailing token",
+			args:    args{value: "=1 2"},
+			wantErr: `unexpected "2" at position 3`,
+		},
+		{
+			name:    "Unexpected end",
+			args:    args{value: "=5*"},
+			wantErr: "unexpected end of formula",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFormula(tt.args.value)
+			if err != nil || tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("parseFormula() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFormula() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
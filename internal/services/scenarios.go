@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+var (
+	// ErrInvalidScenario is returned for scenarios whose overrides can't be written, like wrong formulas or cycles.
+	ErrInvalidScenario = errors.New("invalid scenario")
+	// ErrScenarioNotFound is returned when a compared scenario isn't stored.
+	ErrScenarioNotFound = errors.New("scenario not found")
+)
+
+// EvaluateScenario calculates the cells of a scenario with its overrides applied, nothing is stored.
+func (s *excelLikeService) EvaluateScenario(ctx context.Context, sheetID string, scenario *models.Scenario) (*models.ScenarioResult, error) {
+	results, err := s.evaluateScenarios(ctx, sheetID, []models.Scenario{normalizeScenario(scenario)})
+	if err != nil {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// SaveScenarioTX stores a scenario of a sheet under name after making sure it can be calculated.
+func (s *excelLikeService) SaveScenarioTX(ctx context.Context, sheetID, name string, scenario *models.Scenario) (*models.ScenarioResult, error) {
+	normalized := normalizeScenario(scenario)
+	normalized.Name = name
+	var res *models.ScenarioResult
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		if err := s.loadGraph(ctx, tx); err != nil {
+			return err
+		}
+		results, err := s.evaluateScenario(ctx, tx, sheetID, normalized)
+		if err != nil {
+			return err
+		}
+		res = &models.ScenarioResult{Scenario: normalized, Results: results}
+		return s.storage.SaveScenario(ctx, tx, db.Scenario{SheetID: sheetID, Name: name, Overrides: normalized.Overrides, Cells: normalized.Cells})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetScenarios lists the stored scenarios of a sheet.
+func (s *excelLikeService) GetScenarios(ctx context.Context, sheetID string) ([]models.Scenario, error) {
+	stored, err := s.storage.GetScenarios(ctx, sheetID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.Scenario, 0, len(stored))
+	for _, scenario := range stored {
+		res = append(res, scenarioModel(scenario))
+	}
+	return res, nil
+}
+
+// GetScenario calculates a stored scenario, it returns nil when there is no such scenario.
+func (s *excelLikeService) GetScenario(ctx context.Context, sheetID, name string) (*models.ScenarioResult, error) {
+	stored, err := s.storage.GetScenario(ctx, sheetID, name)
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	results, err := s.evaluateScenarios(ctx, sheetID, []models.Scenario{scenarioModel(*stored)})
+	if err != nil {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// DeleteScenarioTX removes a stored scenario, it reports whether there was one.
+func (s *excelLikeService) DeleteScenarioTX(ctx context.Context, sheetID, name string) (bool, error) {
+	var deleted bool
+	err := s.inTransaction(ctx, func(tx *sql.Tx) (err error) {
+		deleted, err = s.storage.DeleteScenario(ctx, tx, sheetID, name)
+		return err
+	})
+	return deleted, err
+}
+
+// CompareScenarios calculates stored scenarios, all of them when names is empty, and puts their results next to
+// the stored results of the sheet. cells selects the compared cells, by default the cells the scenarios report.
+func (s *excelLikeService) CompareScenarios(ctx context.Context, sheetID string, names, cells []string) (*models.ScenarioComparison, error) {
+	var stored []db.Scenario
+	if len(names) == 0 {
+		var err error
+		if stored, err = s.storage.GetScenarios(ctx, sheetID); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		scenario, err := s.storage.GetScenario(ctx, sheetID, name)
+		if err != nil {
+			return nil, err
+		}
+		if scenario == nil {
+			return nil, fmt.Errorf("%w: %s", ErrScenarioNotFound, name)
+		}
+		stored = append(stored, *scenario)
+	}
+
+	cells = lowerAll(cells)
+	if len(cells) == 0 {
+		for _, scenario := range stored {
+			if len(scenario.Cells) == 0 {
+				// a scenario reporting the whole sheet compares all of it
+				cells = nil
+				break
+			}
+			cells = appendMissing(cells, scenario.Cells...)
+		}
+	}
+	scenarios := []models.Scenario{{Cells: cells}}
+	for _, scenario := range stored {
+		compared := scenarioModel(scenario)
+		compared.Cells = cells
+		scenarios = append(scenarios, compared)
+	}
+	results, err := s.evaluateScenarios(ctx, sheetID, scenarios)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.ScenarioComparison{Scenarios: []string{}, Cells: []models.ComparedCell{}}
+	if len(cells) == 0 {
+		for _, result := range results {
+			for cellID := range result.Results {
+				cells = appendMissing(cells, cellID)
+			}
+		}
+		sort.Strings(cells)
+	}
+	for _, result := range results[1:] {
+		res.Scenarios = append(res.Scenarios, result.Name)
+	}
+	for _, cellID := range cells {
+		compared := models.ComparedCell{ID: cellID, Results: make(map[string]models.Cell)}
+		if actual, ok := results[0].Results[cellID]; ok {
+			compared.Actual = &actual
+		}
+		for _, result := range results[1:] {
+			if cell, ok := result.Results[cellID]; ok {
+				compared.Results[result.Name] = cell
+			}
+		}
+		res.Cells = append(res.Cells, compared)
+	}
+	return res, nil
+}
+
+// evaluateScenarios calculates every scenario in memory from the sheet as it is, writes wait meanwhile.
+func (s *excelLikeService) evaluateScenarios(ctx context.Context, sheetID string, scenarios []models.Scenario) ([]models.ScenarioResult, error) {
+	tx, err := s.storage.BeginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res := make([]models.ScenarioResult, len(scenarios))
+	var evalErr error
+	err = s.readGraph(ctx, func() {
+		for i, scenario := range scenarios {
+			var results map[string]models.Cell
+			if results, evalErr = s.evaluateScenario(ctx, tx, sheetID, scenario); evalErr != nil {
+				return
+			}
+			res[i] = models.ScenarioResult{Scenario: scenario, Results: results}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if evalErr != nil {
+		return nil, evalErr
+	}
+	return res, nil
+}
+
+// evaluateScenario calculates the reported cells of scenario with its overrides applied in memory, the results
+// are those a write of the overrides would store. The dependency graph must not change meanwhile.
+func (s *excelLikeService) evaluateScenario(ctx context.Context, tx *sql.Tx, sheetID string, scenario models.Scenario) (map[string]models.Cell, error) {
+	overrides := make(map[db.CellRef]string, len(scenario.Overrides))
+	for key, value := range scenario.Overrides {
+		overrides[scenarioCell(sheetID, key)] = value
+	}
+	reported := make([]db.CellRef, 0, len(scenario.Cells))
+	for _, cellID := range scenario.Cells {
+		reported = append(reported, db.CellRef{SheetID: sheetID, CellID: cellID})
+	}
+	if len(reported) == 0 {
+		reported = s.graph.sheetCells(sheetID)
+		for cell := range overrides {
+			if cell.SheetID == sheetID {
+				reported = appendCell(reported, cell)
+			}
+		}
+	}
+
+	w, err := s.newWhatIf(ctx, tx, overrides, reported)
+	var results map[db.CellRef]db.Input
+	if err == nil {
+		results, err = w.evaluate(nil)
+	}
+	var overrideErr *overrideError
+	if errors.As(err, &overrideErr) {
+		return nil, fmt.Errorf("%w: override of %s: %v", ErrInvalidScenario, formatRef(overrideErr.cell, sheetID), overrideErr.err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]models.Cell, len(reported))
+	for _, cell := range reported {
+		input, ok := w.result(results, cell)
+		if !ok {
+			continue
+		}
+		// results calculated in memory keep the format of the stored cell
+		if stored, ok := w.stored[cell]; ok {
+			input.Format = stored.Format
+		}
+		res[cell.CellID] = cellResponse(input)
+	}
+	return res, nil
+}
+
+// normalizeScenario lower cases the cells of a scenario like the API does with cell IDs.
+func normalizeScenario(scenario *models.Scenario) models.Scenario {
+	res := models.Scenario{Name: scenario.Name, Overrides: make(map[string]string, len(scenario.Overrides)), Cells: lowerAll(scenario.Cells)}
+	for key, value := range scenario.Overrides {
+		res.Overrides[strings.ToLower(key)] = value
+	}
+	return res
+}
+
+// scenarioCell resolves an override key, "sheet!cell" overrides a cell of another sheet.
+func scenarioCell(sheetID, key string) db.CellRef {
+	if i := strings.Index(key, "!"); i > 0 {
+		return db.CellRef{SheetID: key[:i], CellID: key[i+1:]}
+	}
+	return db.CellRef{SheetID: sheetID, CellID: key}
+}
+
+func scenarioModel(scenario db.Scenario) models.Scenario {
+	return models.Scenario{Name: scenario.Name, Overrides: scenario.Overrides, Cells: scenario.Cells}
+}
+
+func lowerAll(values []string) []string {
+	var res []string
+	for _, value := range values {
+		res = appendMissing(res, strings.ToLower(value))
+	}
+	return res
+}
+
+// appendMissing appends the values not in slice yet.
+func appendMissing(slice []string, values ...string) []string {
+	for _, value := range values {
+		if !contains(slice, value) {
+			slice = append(slice, value)
+		}
+	}
+	return slice
+}
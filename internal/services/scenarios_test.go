@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+	"dev-challenge/internal/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcelLikeService_evaluateScenario(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+	storage.EXPECT().GetSheetSettings(gomock.Any(), tx, gomock.Any()).DoAndReturn(defaultSettings).AnyTimes()
+	sales := db.CellPattern{SheetID: "sheet1", Pattern: "sales_*"}
+	growth := db.Input{SheetID: "sheet1", CellID: "growth", Value: "0.05", Result: 0.05, ResultType: "number"}
+	revenue := db.Input{SheetID: "sheet1", CellID: "revenue", Value: "=100*(1+growth)", Result: 105.0, ResultType: "number",
+		Format: "0.0", UsedParams: sheet1Cells("growth")}
+	sales1 := db.Input{SheetID: "sheet1", CellID: "sales_1", Value: "10", Result: 10, ResultType: "number"}
+	salesTotal := db.Input{SheetID: "sheet1", CellID: "sales_total", Value: "=sum(sales_*)", Result: 10, ResultType: "number",
+		UsedPatterns: []db.CellPattern{sales}}
+
+	tests := []struct {
+		name          string
+		scenario      models.Scenario
+		mockBehavior  func()
+		expected      map[string]models.Cell
+		expectedError string
+	}{
+		{
+			name:     "Override recalculates the reported cells",
+			scenario: models.Scenario{Overrides: map[string]string{"growth": "0.5"}, Cells: []string{"revenue"}},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"revenue"}).
+					Return(map[string]db.Input{"revenue": revenue}, nil)
+			},
+			expected: map[string]models.Cell{
+				"revenue": {Value: "=100*(1+growth)", Result: 150.0, Type: "number", Format: "0.0", Formatted: "150.0"},
+			},
+		},
+		{
+			name:     "Whole sheet",
+			scenario: models.Scenario{Overrides: map[string]string{"growth": "0.5"}},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"growth", "revenue", "sales_1", "sales_total"}).
+					Return(map[string]db.Input{"growth": growth, "revenue": revenue, "sales_1": sales1, "sales_total": salesTotal}, nil)
+			},
+			expected: map[string]models.Cell{
+				"growth":      {Value: "0.5", Result: 0.5, Type: "number", Formatted: "0.5"},
+				"revenue":     {Value: "=100*(1+growth)", Result: 150.0, Type: "number", Format: "0.0", Formatted: "150.0"},
+				"sales_1":     {Value: "10", Result: 10.0, Type: "number", Formatted: "10"},
+				"sales_total": {Value: "=sum(sales_*)", Result: 10.0, Type: "number", Formatted: "10"},
+			},
+		},
+		{
+			name:     "Override of a new cell matched by a pattern",
+			scenario: models.Scenario{Overrides: map[string]string{"sales_2": "5"}, Cells: []string{"sales_total"}},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputByPattern(gomock.Any(), tx, sales).
+					Return(map[string]db.Input{"sales_1": sales1, "sales_total": salesTotal}, nil)
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"sales_total"}).
+					Return(map[string]db.Input{"sales_total": salesTotal}, nil)
+			},
+			expected: map[string]models.Cell{
+				"sales_total": {Value: "=sum(sales_*)", Result: 15.0, Type: "number", Formatted: "15"},
+			},
+		},
+		{
+			name:     "Wrong override",
+			scenario: models.Scenario{Overrides: map[string]string{"growth": "=1/0"}, Cells: []string{"revenue"}},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"revenue"}).
+					Return(map[string]db.Input{"revenue": revenue}, nil)
+			},
+			expectedError: "invalid scenario: override of growth: division by zero",
+		},
+		{
+			name:          "Override making a cycle",
+			scenario:      models.Scenario{Overrides: map[string]string{"growth": "=revenue"}},
+			expectedError: "invalid scenario: override of growth: circular reference: growth -> revenue -> growth",
+		},
+		{
+			name:     "Storage error",
+			scenario: models.Scenario{Overrides: map[string]string{"growth": "0.05"}, Cells: []string{"revenue"}},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"revenue"}).Return(nil, errors.New("some DB error"))
+			},
+			expectedError: "some DB error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &excelLikeService{storage: storage, functions: NewDefaultFunctionRegistry(), graph: graphOf(growth, revenue, sales1, salesTotal)}
+			if tt.mockBehavior != nil {
+				tt.mockBehavior()
+			}
+			got, err := s.evaluateScenario(context.TODO(), tx, "sheet1", tt.scenario)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func Test_normalizeScenario(t *testing.T) {
+	got := normalizeScenario(&models.Scenario{
+		Name:      "growth",
+		Overrides: map[string]string{"Growth": "0.05", "Other!Rate": "=A1"},
+		Cells:     []string{"Revenue", "revenue", "Profit"},
+	})
+	assert.Equal(t, models.Scenario{
+		Name:      "growth",
+		Overrides: map[string]string{"growth": "0.05", "other!rate": "=A1"},
+		Cells:     []string{"revenue", "profit"},
+	}, got)
+	assert.Equal(t, db.CellRef{SheetID: "other", CellID: "rate"}, scenarioCell("sheet1", "other!rate"))
+	assert.Equal(t, db.CellRef{SheetID: "sheet1", CellID: "rate"}, scenarioCell("sheet1", "rate"))
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+// ErrInvalidSettings is returned for sheet settings with an unknown mode, rounding or a precision out of range.
+var ErrInvalidSettings = errors.New("invalid sheet settings")
+
+// MissingRefsPolicy tells how formulas treat references to cells that don't exist yet.
+type MissingRefsPolicy string
+
+const (
+	MissingAsRefError MissingRefsPolicy = "ref"
+	MissingAsZero     MissingRefsPolicy = "zero"
+)
+
+func (s *excelLikeService) GetSheetSettings(ctx context.Context, sheetID string) (*models.SheetSettings, error) {
+	settings, err := s.storage.GetSheetSettings(ctx, nil, sheetID)
+	if err != nil {
+		return nil, err
+	}
+	return settingsResponse(settings), nil
+}
+
+func (s *excelLikeService) UpdateSheetSettingsTX(ctx context.Context, sheetID string, update *models.SheetSettings) (*models.SheetSettings, error) {
+	var resp *models.SheetSettings
+	err := s.inTransaction(ctx, func(tx *sql.Tx) (err error) {
+		resp, err = s.updateSheetSettings(ctx, tx, sheetID, update)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// updateSheetSettings stores the changed settings and recalculates the sheet with them, cells of other sheets
+// using the sheet are recalculated as well.
+func (s *excelLikeService) updateSheetSettings(ctx context.Context, tx *sql.Tx, sheetID string, update *models.SheetSettings) (*models.SheetSettings, error) {
+	settings, err := s.storage.GetSheetSettings(ctx, tx, sheetID)
+	if err != nil {
+		return nil, err
+	}
+	if update.Mode != "" {
+		settings.NumberMode = update.Mode
+	}
+	if update.Precision != nil {
+		settings.Precision = *update.Precision
+	}
+	if update.Rounding != "" {
+		settings.Rounding = update.Rounding
+	}
+	if update.MissingRefs != "" {
+		settings.MissingRefs = update.MissingRefs
+	}
+	if err := validateSettings(settings); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.SaveSheetSettings(ctx, tx, settings); err != nil {
+		return nil, err
+	}
+	if err := s.loadGraph(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := s.recalculate(ctx, tx, newCalculation(), s.graph.sheetCells(sheetID)); err != nil {
+		return nil, err
+	}
+	return settingsResponse(settings), nil
+}
+
+func validateSettings(settings db.SheetSettings) error {
+	switch NumberMode(settings.NumberMode) {
+	case ModeFloat, ModeDecimal:
+	default:
+		return fmt.Errorf("%w: mode %q is neither %q nor %q", ErrInvalidSettings, settings.NumberMode, ModeFloat, ModeDecimal)
+	}
+	if settings.Precision < 0 || settings.Precision > maxDecimalPrecision {
+		return fmt.Errorf("%w: precision must be between 0 and %d", ErrInvalidSettings, maxDecimalPrecision)
+	}
+	switch RoundingMode(settings.Rounding) {
+	case RoundHalfUp, RoundHalfEven:
+	default:
+		return fmt.Errorf("%w: rounding %q is neither %q nor %q", ErrInvalidSettings, settings.Rounding, RoundHalfUp, RoundHalfEven)
+	}
+	switch MissingRefsPolicy(settings.MissingRefs) {
+	case MissingAsRefError, MissingAsZero:
+	default:
+		return fmt.Errorf("%w: missing_refs %q is neither %q nor %q", ErrInvalidSettings, settings.MissingRefs, MissingAsRefError, MissingAsZero)
+	}
+	return nil
+}
+
+func settingsResponse(settings db.SheetSettings) *models.SheetSettings {
+	precision := settings.Precision
+	return &models.SheetSettings{Mode: settings.NumberMode, Precision: &precision, Rounding: settings.Rounding, MissingRefs: settings.MissingRefs}
+}
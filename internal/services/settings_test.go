@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+	"dev-challenge/internal/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcelLikeService_updateSheetSettings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+
+	s := &excelLikeService{
+		storage:   storage,
+		functions: NewDefaultFunctionRegistry(),
+		graph: graphOf(
+			db.Input{SheetID: "sheet1", CellID: "a", Value: "0.125"},
+			db.Input{SheetID: "sheet1", CellID: "b", Value: "=a*2", UsedParams: []db.CellRef{{SheetID: "sheet1", CellID: "a"}}},
+		),
+	}
+
+	precision := 2
+	outOfRange := 31
+	decimal := db.SheetSettings{SheetID: "sheet1", NumberMode: "decimal", Precision: 2, Rounding: "half_up", MissingRefs: "ref"}
+
+	tests := []struct {
+		name          string
+		update        *models.SheetSettings
+		mockBehavior  func()
+		expectedData  *models.SheetSettings
+		expectedError string
+	}{
+		{
+			name:   "Decimal mode recalculates the sheet",
+			update: &models.SheetSettings{Mode: "decimal"},
+			mockBehavior: func() {
+				// b is calculated from the rounded result of a, which isn't read back from storage
+				gomock.InOrder(
+					storage.EXPECT().GetSheetSettings(gomock.Any(), tx, "sheet1").Return(db.DefaultSheetSettings("sheet1"), nil),
+					storage.EXPECT().SaveSheetSettings(gomock.Any(), tx, decimal).Return(nil),
+					storage.EXPECT().GetSheetSettings(gomock.Any(), tx, "sheet1").Return(decimal, nil),
+					storage.EXPECT().SaveCellResult(gomock.Any(), tx, db.Input{
+						SheetID:    "sheet1",
+						CellID:     "a",
+						Value:      "0.125",
+						Result:     0.13,
+						ResultType: "number",
+						ResultText: "0.13",
+						UsedParams: []db.CellRef{},
+					}).Return(nil),
+					storage.EXPECT().SaveCellResult(gomock.Any(), tx, db.Input{
+						SheetID:    "sheet1",
+						CellID:     "b",
+						Value:      "=a*2",
+						Result:     0.26,
+						ResultType: "number",
+						ResultText: "0.26",
+						UsedParams: []db.CellRef{{SheetID: "sheet1", CellID: "a"}},
+					}).Return(nil),
+				)
+			},
+			expectedData: &models.SheetSettings{Mode: "decimal", Precision: &precision, Rounding: "half_up", MissingRefs: "ref"},
+		},
+		{
+			name:   "Unknown mode",
+			update: &models.SheetSettings{Mode: "exact"},
+			mockBehavior: func() {
+				storage.EXPECT().GetSheetSettings(gomock.Any(), tx, "sheet1").Return(db.DefaultSheetSettings("sheet1"), nil)
+			},
+			expectedError: `invalid sheet settings: mode "exact" is neither "float" nor "decimal"`,
+		},
+		{
+			name:   "Precision out of range",
+			update: &models.SheetSettings{Precision: &outOfRange},
+			mockBehavior: func() {
+				storage.EXPECT().GetSheetSettings(gomock.Any(), tx, "sheet1").Return(db.DefaultSheetSettings("sheet1"), nil)
+			},
+			expectedError: "invalid sheet settings: precision must be between 0 and 30",
+		},
+		{
+			name:   "Unknown rounding",
+			update: &models.SheetSettings{Rounding: "down"},
+			mockBehavior: func() {
+				storage.EXPECT().GetSheetSettings(gomock.Any(), tx, "sheet1").Return(db.DefaultSheetSettings("sheet1"), nil)
+			},
+			expectedError: `invalid sheet settings: rounding "down" is neither "half_up" nor "half_even"`,
+		},
+		{
+			name:   "Unknown missing references policy",
+			update: &models.SheetSettings{MissingRefs: "empty"},
+			mockBehavior: func() {
+				storage.EXPECT().GetSheetSettings(gomock.Any(), tx, "sheet1").Return(db.DefaultSheetSettings("sheet1"), nil)
+			},
+			expectedError: `invalid sheet settings: missing_refs "empty" is neither "ref" nor "zero"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior()
+
+			data, err := s.updateSheetSettings(context.TODO(), tx, "sheet1", tt.update)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedData, data)
+		})
+	}
+}
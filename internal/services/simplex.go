@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	errInfeasible = errors.New("no feasible solution")
+	errUnbounded  = errors.New("objective is unbounded")
+)
+
+const simplexEpsilon = 1e-9
+
+// linearRow is the constraint coef·x op rhs of a linear program, op being "<=", ">=" or "=".
+type linearRow struct {
+	coef []float64
+	op   string
+	rhs  float64
+}
+
+// maximizeLinear maximizes objective·x subject to rows over free variables with the two-phase simplex method.
+// Every variable is split into the difference of two non-negative ones, Bland's rule keeps degenerate
+// programs from cycling.
+func maximizeLinear(objective []float64, rows []linearRow) ([]float64, error) {
+	n := len(objective)
+	var slacks, artificials int
+	for _, row := range rows {
+		switch normalizedOp(row) {
+		case "<=":
+			slacks++
+		case ">=":
+			slacks++
+			artificials++
+		default:
+			artificials++
+		}
+	}
+	// columns: x+ (n), x- (n), slacks, artificials, rhs
+	cols := 2*n + slacks + artificials
+	firstArtificial := 2*n + slacks
+	t := &tableau{rows: make([][]float64, len(rows)), basis: make([]int, len(rows)), cols: cols}
+	slack, artificial := 2*n, firstArtificial
+	for i, row := range rows {
+		sign := 1.0
+		if row.rhs < 0 {
+			sign = -1
+		}
+		r := make([]float64, cols+1)
+		for j, c := range row.coef {
+			r[j], r[n+j] = sign*c, -sign*c
+		}
+		r[cols] = sign * row.rhs
+		switch normalizedOp(row) {
+		case "<=":
+			r[slack] = 1
+			t.basis[i] = slack
+			slack++
+		case ">=":
+			r[slack] = -1
+			slack++
+			r[artificial] = 1
+			t.basis[i] = artificial
+			artificial++
+		default:
+			r[artificial] = 1
+			t.basis[i] = artificial
+			artificial++
+		}
+		t.rows[i] = r
+	}
+
+	if artificials > 0 {
+		cost := make([]float64, cols)
+		for j := firstArtificial; j < cols; j++ {
+			cost[j] = -1
+		}
+		if err := t.optimize(cost, cols); err != nil {
+			return nil, err
+		}
+		if t.value(cost) < -simplexEpsilon*math.Max(1, t.scale()) {
+			return nil, errInfeasible
+		}
+		t.dropArtificials(firstArtificial)
+	}
+
+	cost := make([]float64, cols)
+	for j, c := range objective {
+		cost[j], cost[n+j] = c, -c
+	}
+	if err := t.optimize(cost, firstArtificial); err != nil {
+		return nil, err
+	}
+	x := make([]float64, n)
+	for i, col := range t.basis {
+		switch {
+		case col < n:
+			x[col] += t.rows[i][cols]
+		case col < 2*n:
+			x[col-n] -= t.rows[i][cols]
+		}
+	}
+	return x, nil
+}
+
+// normalizedOp is the op of row once its rhs is made non-negative.
+func normalizedOp(row linearRow) string {
+	if row.rhs >= 0 {
+		return row.op
+	}
+	switch row.op {
+	case "<=":
+		return ">="
+	case ">=":
+		return "<="
+	}
+	return row.op
+}
+
+// tableau holds the constraint rows of a simplex, the last column of each row is its rhs.
+type tableau struct {
+	rows  [][]float64
+	basis []int
+	cols  int
+}
+
+// optimize pivots until no column below limit improves the maximized cost.
+func (t *tableau) optimize(cost []float64, limit int) error {
+	maxPivots := 50 * (len(t.rows) + t.cols)
+	for pivots := 0; pivots < maxPivots; pivots++ {
+		entering := -1
+		for j := 0; j < limit && entering < 0; j++ {
+			if t.reducedCost(cost, j) > simplexEpsilon {
+				entering = j
+			}
+		}
+		if entering < 0 {
+			return nil
+		}
+		leaving := -1
+		var best float64
+		for i, row := range t.rows {
+			if row[entering] <= simplexEpsilon {
+				continue
+			}
+			ratio := row[t.cols] / row[entering]
+			if leaving < 0 || ratio < best-simplexEpsilon || (ratio <= best+simplexEpsilon && t.basis[i] < t.basis[leaving]) {
+				leaving, best = i, ratio
+			}
+		}
+		if leaving < 0 {
+			return errUnbounded
+		}
+		t.pivot(leaving, entering)
+	}
+	return errors.New("simplex did not converge")
+}
+
+func (t *tableau) reducedCost(cost []float64, col int) float64 {
+	res := cost[col]
+	for i, row := range t.rows {
+		res -= cost[t.basis[i]] * row[col]
+	}
+	return res
+}
+
+func (t *tableau) value(cost []float64) float64 {
+	var res float64
+	for i, row := range t.rows {
+		res += cost[t.basis[i]] * row[t.cols]
+	}
+	return res
+}
+
+// scale is the largest rhs, it keeps the feasibility check relative to the size of the program.
+func (t *tableau) scale() float64 {
+	var res float64
+	for _, row := range t.rows {
+		res = math.Max(res, math.Abs(row[t.cols]))
+	}
+	return res
+}
+
+func (t *tableau) pivot(r, c int) {
+	pivotRow := t.rows[r]
+	p := pivotRow[c]
+	for j := range pivotRow {
+		pivotRow[j] /= p
+	}
+	for i, row := range t.rows {
+		if i == r || row[c] == 0 {
+			continue
+		}
+		f := row[c]
+		for j := range row {
+			row[j] -= f * pivotRow[j]
+		}
+	}
+	t.basis[r] = c
+}
+
+// dropArtificials pivots artificial columns, at zero after a feasible phase one, out of the basis.
+// Rows without another column to pivot on are redundant and keep their artificial at zero.
+func (t *tableau) dropArtificials(firstArtificial int) {
+	for i, row := range t.rows {
+		if t.basis[i] < firstArtificial {
+			continue
+		}
+		for j := 0; j < firstArtificial; j++ {
+			if math.Abs(row[j]) > simplexEpsilon {
+				t.pivot(i, j)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_maximizeLinear(t *testing.T) {
+	tests := []struct {
+		name          string
+		objective     []float64
+		rows          []linearRow
+		expected      []float64
+		expectedError string
+	}{
+		{
+			name:      "Production plan",
+			objective: []float64{3, 5},
+			rows: []linearRow{
+				{coef: []float64{1, 0}, op: "<=", rhs: 4},
+				{coef: []float64{0, 2}, op: "<=", rhs: 12},
+				{coef: []float64{3, 2}, op: "<=", rhs: 18},
+				{coef: []float64{1, 0}, op: ">=", rhs: 0},
+				{coef: []float64{0, 1}, op: ">=", rhs: 0},
+			},
+			expected: []float64{2, 6},
+		},
+		{
+			name:      "Minimizing with equality and negative values",
+			objective: []float64{-1, -1},
+			rows: []linearRow{
+				{coef: []float64{1, -1}, op: "=", rhs: -3},
+				{coef: []float64{1, 0}, op: ">=", rhs: -5},
+				{coef: []float64{0, 1}, op: ">=", rhs: 0},
+			},
+			expected: []float64{-3, 0},
+		},
+		{
+			name:      "Degenerate",
+			objective: []float64{10, -57, -9, -24},
+			rows: []linearRow{
+				{coef: []float64{0.5, -5.5, -2.5, 9}, op: "<=", rhs: 0},
+				{coef: []float64{0.5, -1.5, -0.5, 1}, op: "<=", rhs: 0},
+				{coef: []float64{1, 0, 0, 0}, op: "<=", rhs: 1},
+				{coef: []float64{1, 0, 0, 0}, op: ">=", rhs: 0},
+				{coef: []float64{0, 1, 0, 0}, op: ">=", rhs: 0},
+				{coef: []float64{0, 0, 1, 0}, op: ">=", rhs: 0},
+				{coef: []float64{0, 0, 0, 1}, op: ">=", rhs: 0},
+			},
+			expected: []float64{1, 0, 1, 0},
+		},
+		{
+			name:      "Redundant equality",
+			objective: []float64{1, 0},
+			rows: []linearRow{
+				{coef: []float64{1, 1}, op: "=", rhs: 2},
+				{coef: []float64{2, 2}, op: "=", rhs: 4},
+				{coef: []float64{1, 0}, op: ">=", rhs: 0},
+				{coef: []float64{0, 1}, op: ">=", rhs: 0},
+				{coef: []float64{1, 0}, op: ">=", rhs: 1.5},
+			},
+			expected: []float64{2, 0},
+		},
+		{
+			name:      "Infeasible",
+			objective: []float64{1},
+			rows: []linearRow{
+				{coef: []float64{1}, op: ">=", rhs: 5},
+				{coef: []float64{1}, op: "<=", rhs: 3},
+			},
+			expectedError: "no feasible solution",
+		},
+		{
+			name:          "Unbounded",
+			objective:     []float64{1, 1},
+			rows:          []linearRow{{coef: []float64{1, -1}, op: "<=", rhs: 1}},
+			expectedError: "objective is unbounded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := maximizeLinear(tt.objective, tt.rows)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDeltaSlice(t, tt.expected, got, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+// ErrInvalidSimulation is returned for simulations that can't be run, like a distribution missing a parameter.
+var ErrInvalidSimulation = errors.New("invalid simulation")
+
+const (
+	defaultSimulationIterations = 1000
+	maxSimulationIterations     = 100000
+	defaultHistogramBins        = 10
+	maxHistogramBins            = 1000
+)
+
+var defaultPercentiles = []float64{5, 25, 50, 75, 95}
+
+// simulationParams is a validated simulation, samplers follow the order of the inputs.
+type simulationParams struct {
+	inputs      []string
+	samplers    []sampler
+	outputs     []string
+	iterations  int
+	seed        int64
+	bins        int
+	percentiles []float64
+}
+
+// sampler draws a value of a distribution.
+type sampler func(r *rand.Rand) float64
+
+// Simulate evaluates the sheet once per iteration with the uncertain inputs drawn from their distributions
+// and returns the statistics of the outputs. Iterations are calculated in memory, nothing is written.
+// The sheet is read once before the first iteration, so writes only wait for that read.
+func (s *excelLikeService) Simulate(ctx context.Context, sheetID string, request *models.Simulation) (*models.SimulationResult, error) {
+	params, err := simulationParamsOf(request)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.prepareWhatIf(ctx, func(tx *sql.Tx) (*whatIf, error) {
+		return s.simulationOf(ctx, tx, sheetID, params)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return simulate(ctx, w, sheetID, params)
+}
+
+func simulationParamsOf(request *models.Simulation) (simulationParams, error) {
+	params := simulationParams{iterations: request.Iterations, bins: request.Bins, percentiles: request.Percentiles}
+	switch {
+	case len(request.Inputs) == 0:
+		return params, fmt.Errorf("%w: inputs are required", ErrInvalidSimulation)
+	case len(request.Outputs) == 0:
+		return params, fmt.Errorf("%w: outputs are required", ErrInvalidSimulation)
+	case request.Iterations < 0 || request.Iterations > maxSimulationIterations:
+		return params, fmt.Errorf("%w: iterations must be between 1 and %d, 0 or none means %d", ErrInvalidSimulation,
+			maxSimulationIterations, defaultSimulationIterations)
+	case request.Bins < 0 || request.Bins > maxHistogramBins:
+		return params, fmt.Errorf("%w: bins must be between 1 and %d, 0 or none means %d", ErrInvalidSimulation, maxHistogramBins, defaultHistogramBins)
+	}
+	for _, input := range request.Inputs {
+		cellID := strings.ToLower(input.Cell)
+		if cellID == "" {
+			return params, fmt.Errorf("%w: input cell is required", ErrInvalidSimulation)
+		}
+		if contains(params.inputs, cellID) {
+			return params, fmt.Errorf("%w: input %s is repeated", ErrInvalidSimulation, cellID)
+		}
+		sample, err := samplerOf(input)
+		if err != nil {
+			return params, fmt.Errorf("%w: %s: %v", ErrInvalidSimulation, cellID, err)
+		}
+		params.inputs, params.samplers = append(params.inputs, cellID), append(params.samplers, sample)
+	}
+	params.outputs = lowerAll(request.Outputs)
+	for _, p := range params.percentiles {
+		if p < 0 || p > 100 {
+			return params, fmt.Errorf("%w: percentiles must be between 0 and 100", ErrInvalidSimulation)
+		}
+	}
+	if params.iterations == 0 {
+		params.iterations = defaultSimulationIterations
+	}
+	if params.bins == 0 {
+		params.bins = defaultHistogramBins
+	}
+	if len(params.percentiles) == 0 {
+		params.percentiles = defaultPercentiles
+	}
+	params.seed = time.Now().UnixNano()
+	if request.Seed != nil {
+		params.seed = *request.Seed
+	}
+	return params, nil
+}
+
+// samplerOf checks the parameters of the distribution of input.
+func samplerOf(input models.UncertainInput) (sampler, error) {
+	switch strings.ToLower(input.Distribution) {
+	case "normal", "lognormal":
+		if input.Mean == nil || input.StdDev == nil {
+			return nil, errors.New("the distribution needs mean and stddev")
+		}
+		if *input.StdDev < 0 {
+			return nil, errors.New("stddev must not be negative")
+		}
+		mean, stddev := *input.Mean, *input.StdDev
+		if strings.EqualFold(input.Distribution, "lognormal") {
+			return func(r *rand.Rand) float64 { return math.Exp(mean + stddev*r.NormFloat64()) }, nil
+		}
+		return func(r *rand.Rand) float64 { return mean + stddev*r.NormFloat64() }, nil
+	case "uniform":
+		if input.Min == nil || input.Max == nil {
+			return nil, errors.New("the distribution needs min and max")
+		}
+		min, max := *input.Min, *input.Max
+		if min > max {
+			return nil, errors.New("min must not be greater than max")
+		}
+		return func(r *rand.Rand) float64 { return min + (max-min)*r.Float64() }, nil
+	case "triangular":
+		if input.Min == nil || input.Mode == nil || input.Max == nil {
+			return nil, errors.New("the distribution needs min, mode and max")
+		}
+		min, mode, max := *input.Min, *input.Mode, *input.Max
+		if min > mode || mode > max || min == max {
+			return nil, errors.New("min, mode and max must be in order and min less than max")
+		}
+		return func(r *rand.Rand) float64 {
+			u := r.Float64()
+			if u < (mode-min)/(max-min) {
+				return min + math.Sqrt(u*(max-min)*(mode-min))
+			}
+			return max - math.Sqrt((1-u)*(max-min)*(max-mode))
+		}, nil
+	}
+	return nil, errors.New("distribution must be normal, uniform, triangular or lognormal")
+}
+
+// simulationOf prepares the evaluation of the outputs with the inputs overridden, reading stored cells within
+// tx. Only the cells between the inputs and the outputs are recalculated. The dependency graph must not change
+// meanwhile.
+func (s *excelLikeService) simulationOf(ctx context.Context, tx *sql.Tx, sheetID string, params simulationParams) (*whatIf, error) {
+	overrides := make(map[db.CellRef]string, len(params.inputs))
+	for _, cellID := range params.inputs {
+		cell := db.CellRef{SheetID: sheetID, CellID: cellID}
+		value, ok := s.graph.value(cell)
+		if !ok {
+			return nil, fmt.Errorf("%w: cell %s not found", ErrInvalidSimulation, cellID)
+		}
+		overrides[cell] = value
+	}
+	outputs := make([]db.CellRef, len(params.outputs))
+	for i, cellID := range params.outputs {
+		outputs[i] = db.CellRef{SheetID: sheetID, CellID: cellID}
+		if _, ok := s.graph.value(outputs[i]); !ok {
+			return nil, fmt.Errorf("%w: cell %s not found", ErrInvalidSimulation, cellID)
+		}
+	}
+	return s.newWhatIf(ctx, tx, overrides, outputs)
+}
+
+// simulate runs the iterations from w alone, it reads neither storage nor the dependency graph.
+func simulate(ctx context.Context, w *whatIf, sheetID string, params simulationParams) (*models.SimulationResult, error) {
+	r := rand.New(rand.NewSource(params.seed))
+	samples := make([][]float64, len(params.outputs))
+	errs := make([]int, len(params.outputs))
+	for iteration := 0; iteration < params.iterations; iteration++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fixed := make(map[db.CellRef]db.Input, len(params.inputs))
+		for i, cellID := range params.inputs {
+			cell := db.CellRef{SheetID: sheetID, CellID: cellID}
+			fixed[cell] = numberResult(cell, params.samplers[i](r))
+		}
+		results, err := w.evaluate(fixed)
+		if err != nil {
+			return nil, err
+		}
+		for i, cellID := range params.outputs {
+			cell := db.CellRef{SheetID: sheetID, CellID: cellID}
+			input, _ := w.result(results, cell)
+			result := inputValue(cell, input)
+			if result.Type != TypeNumber || math.IsNaN(result.Number) || math.IsInf(result.Number, 0) {
+				errs[i]++
+				continue
+			}
+			samples[i] = append(samples[i], result.Number)
+		}
+	}
+
+	res := &models.SimulationResult{Iterations: params.iterations, Seed: params.seed, Outputs: make(map[string]models.OutputStats, len(params.outputs))}
+	for i, cellID := range params.outputs {
+		stats := summarize(samples[i], params.bins, params.percentiles)
+		stats.Errors = errs[i]
+		res.Outputs[cellID] = stats
+	}
+	return res, nil
+}
+
+// summarize computes the statistics of samples. Percentiles interpolate between the closest ranks like
+// PERCENTILE.INC, the histogram splits [min, max] into bins of equal width.
+func summarize(samples []float64, bins int, percentiles []float64) models.OutputStats {
+	res := models.OutputStats{Percentiles: make(map[string]float64, len(percentiles)), Histogram: []models.HistogramBin{}}
+	n := len(samples)
+	if n == 0 {
+		return res
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	var sum float64
+	for _, x := range sorted {
+		sum += x
+	}
+	res.Mean, res.Min, res.Max = sum/float64(n), sorted[0], sorted[n-1]
+	if n > 1 {
+		var squares float64
+		for _, x := range sorted {
+			squares += (x - res.Mean) * (x - res.Mean)
+		}
+		res.StdDev = math.Sqrt(squares / float64(n-1))
+	}
+	for _, p := range percentiles {
+		rank := p / 100 * float64(n-1)
+		lower := int(math.Floor(rank))
+		value := sorted[lower]
+		if lower+1 < n {
+			value += (rank - float64(lower)) * (sorted[lower+1] - sorted[lower])
+		}
+		res.Percentiles["p"+strconv.FormatFloat(p, 'f', -1, 64)] = value
+	}
+
+	if res.Min == res.Max {
+		return models.OutputStats{Mean: res.Mean, Min: res.Min, Max: res.Max, Percentiles: res.Percentiles,
+			Histogram: []models.HistogramBin{{From: res.Min, To: res.Max, Count: n}}}
+	}
+	width := (res.Max - res.Min) / float64(bins)
+	for i := 0; i < bins; i++ {
+		bin := models.HistogramBin{From: res.Min + float64(i)*width, To: res.Min + float64(i+1)*width}
+		if i == bins-1 {
+			bin.To = res.Max
+		}
+		res.Histogram = append(res.Histogram, bin)
+	}
+	for _, x := range sorted {
+		i := int((x - res.Min) / width)
+		if i >= bins {
+			i = bins - 1
+		}
+		res.Histogram[i].Count++
+	}
+	return res
+}
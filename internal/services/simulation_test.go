@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"math/rand"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+	"dev-challenge/internal/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_samplerOf(t *testing.T) {
+	zero, one, two, ten := 0.0, 1.0, 2.0, 10.0
+	tests := []struct {
+		name          string
+		input         models.UncertainInput
+		mean          float64
+		stddev        float64
+		min           float64
+		max           float64
+		expectedError string
+	}{
+		{
+			name:   "Normal",
+			input:  models.UncertainInput{Distribution: "normal", Mean: &ten, StdDev: &two},
+			mean:   10,
+			stddev: 2,
+			min:    math.Inf(-1),
+			max:    math.Inf(1),
+		},
+		{
+			name:   "Uniform",
+			input:  models.UncertainInput{Distribution: "Uniform", Min: &zero, Max: &ten},
+			mean:   5,
+			stddev: 10 / math.Sqrt(12),
+			min:    0,
+			max:    10,
+		},
+		{
+			name:   "Triangular",
+			input:  models.UncertainInput{Distribution: "triangular", Min: &zero, Mode: &two, Max: &ten},
+			mean:   4,
+			stddev: math.Sqrt((100 + 4 - 20) / 18.0),
+			min:    0,
+			max:    10,
+		},
+		{
+			name:   "Lognormal",
+			input:  models.UncertainInput{Distribution: "lognormal", Mean: &zero, StdDev: &one},
+			mean:   math.Exp(0.5),
+			stddev: math.Sqrt((math.E - 1) * math.E),
+			min:    0,
+			max:    math.Inf(1),
+		},
+		{
+			name:          "Missing stddev",
+			input:         models.UncertainInput{Distribution: "normal", Mean: &ten},
+			expectedError: "the distribution needs mean and stddev",
+		},
+		{
+			name:          "Wrong range",
+			input:         models.UncertainInput{Distribution: "uniform", Min: &ten, Max: &zero},
+			expectedError: "min must not be greater than max",
+		},
+		{
+			name:          "Mode out of range",
+			input:         models.UncertainInput{Distribution: "triangular", Min: &zero, Mode: &ten, Max: &two},
+			expectedError: "min, mode and max must be in order and min less than max",
+		},
+		{
+			name:          "Unknown",
+			input:         models.UncertainInput{Distribution: "poisson", Mean: &one},
+			expectedError: "distribution must be normal, uniform, triangular or lognormal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample, err := samplerOf(tt.input)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			r := rand.New(rand.NewSource(1))
+			samples := make([]float64, 100000)
+			for i := range samples {
+				samples[i] = sample(r)
+				assert.True(t, samples[i] >= tt.min && samples[i] <= tt.max)
+			}
+			stats := summarize(samples, 1, nil)
+			assert.InDelta(t, tt.mean, stats.Mean, tt.stddev*0.02)
+			assert.InDelta(t, tt.stddev, stats.StdDev, tt.stddev*0.03)
+		})
+	}
+}
+
+func Test_summarize(t *testing.T) {
+	tests := []struct {
+		name        string
+		samples     []float64
+		bins        int
+		percentiles []float64
+		expected    models.OutputStats
+	}{
+		{
+			name:        "Samples",
+			samples:     []float64{4, 1, 3, 2, 5},
+			bins:        2,
+			percentiles: []float64{0, 12.5, 50, 100},
+			expected: models.OutputStats{Mean: 3, StdDev: math.Sqrt(2.5), Min: 1, Max: 5,
+				Percentiles: map[string]float64{"p0": 1, "p12.5": 1.5, "p50": 3, "p100": 5},
+				Histogram:   []models.HistogramBin{{From: 1, To: 3, Count: 2}, {From: 3, To: 5, Count: 3}},
+			},
+		},
+		{
+			name:        "Constant",
+			samples:     []float64{7, 7},
+			bins:        10,
+			percentiles: []float64{95},
+			expected: models.OutputStats{Mean: 7, Min: 7, Max: 7, Percentiles: map[string]float64{"p95": 7},
+				Histogram: []models.HistogramBin{{From: 7, To: 7, Count: 2}},
+			},
+		},
+		{
+			name:        "No samples",
+			bins:        10,
+			percentiles: []float64{50},
+			expected:    models.OutputStats{Percentiles: map[string]float64{}, Histogram: []models.HistogramBin{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, summarize(tt.samples, tt.bins, tt.percentiles))
+		})
+	}
+}
+
+func Test_simulationParamsOf(t *testing.T) {
+	one, two := 1.0, 2.0
+	seed := int64(7)
+	price := models.UncertainInput{Cell: "Price", Distribution: "uniform", Min: &one, Max: &two}
+	tests := []struct {
+		name          string
+		request       models.Simulation
+		expectedError string
+	}{
+		{
+			name:    "Defaults",
+			request: models.Simulation{Inputs: []models.UncertainInput{price}, Outputs: []string{"Profit"}, Seed: &seed},
+		},
+		{
+			name:          "No inputs",
+			request:       models.Simulation{Outputs: []string{"profit"}},
+			expectedError: "invalid simulation: inputs are required",
+		},
+		{
+			name:          "No outputs",
+			request:       models.Simulation{Inputs: []models.UncertainInput{price}},
+			expectedError: "invalid simulation: outputs are required",
+		},
+		{
+			name:          "Too many iterations",
+			request:       models.Simulation{Inputs: []models.UncertainInput{price}, Outputs: []string{"profit"}, Iterations: 1000000},
+			expectedError: "invalid simulation: iterations must be between 1 and 100000, 0 or none means 1000",
+		},
+		{
+			name:          "Too many bins",
+			request:       models.Simulation{Inputs: []models.UncertainInput{price}, Outputs: []string{"profit"}, Bins: 1001},
+			expectedError: "invalid simulation: bins must be between 1 and 1000, 0 or none means 10",
+		},
+		{
+			name:          "Repeated input",
+			request:       models.Simulation{Inputs: []models.UncertainInput{price, {Cell: "price"}}, Outputs: []string{"profit"}},
+			expectedError: "invalid simulation: input price is repeated",
+		},
+		{
+			name:          "Wrong distribution",
+			request:       models.Simulation{Inputs: []models.UncertainInput{{Cell: "price", Distribution: "uniform", Min: &one}}, Outputs: []string{"profit"}},
+			expectedError: "invalid simulation: price: the distribution needs min and max",
+		},
+		{
+			name:          "Wrong percentile",
+			request:       models.Simulation{Inputs: []models.UncertainInput{price}, Outputs: []string{"profit"}, Percentiles: []float64{101}},
+			expectedError: "invalid simulation: percentiles must be between 0 and 100",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := simulationParamsOf(&tt.request)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"price"}, got.inputs)
+			assert.Equal(t, []string{"profit"}, got.outputs)
+			assert.Equal(t, defaultSimulationIterations, got.iterations)
+			assert.Equal(t, defaultHistogramBins, got.bins)
+			assert.Equal(t, defaultPercentiles, got.percentiles)
+			assert.Equal(t, seed, got.seed)
+		})
+	}
+}
+
+func TestExcelLikeService_simulate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+	storage.EXPECT().GetSheetSettings(gomock.Any(), tx, gomock.Any()).DoAndReturn(defaultSettings).AnyTimes()
+	graph := []db.Input{
+		{SheetID: "sheet1", CellID: "price", Value: "10", Result: 10, ResultType: "number"},
+		{SheetID: "sheet1", CellID: "qty", Value: "5", Result: 5, ResultType: "number"},
+		{SheetID: "sheet1", CellID: "revenue", Value: "=price*qty", Result: 50, ResultType: "number", UsedParams: sheet1Cells("price", "qty")},
+		{SheetID: "sheet1", CellID: "profit", Value: "=revenue-20", Result: 30, ResultType: "number", UsedParams: sheet1Cells("revenue")},
+		{SheetID: "sheet1", CellID: "ratio", Value: "=1/(price-3)", Result: 1.0 / 7, ResultType: "number", UsedParams: sheet1Cells("price")},
+	}
+	three := 3.0
+	seed := int64(1)
+
+	tests := []struct {
+		name          string
+		request       models.Simulation
+		mockBehavior  func()
+		expected      *models.SimulationResult
+		expectedError string
+	}{
+		{
+			name: "Constant input",
+			request: models.Simulation{Inputs: []models.UncertainInput{{Cell: "price", Distribution: "uniform", Min: &three, Max: &three}},
+				Outputs: []string{"Profit", "qty", "ratio"}, Iterations: 3, Seed: &seed, Percentiles: []float64{50}},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"qty", "profit", "ratio"}).
+					Return(map[string]db.Input{"qty": graph[1], "profit": graph[3], "ratio": graph[4]}, nil)
+			},
+			expected: &models.SimulationResult{Iterations: 3, Seed: 1, Outputs: map[string]models.OutputStats{
+				"profit": {Mean: -5, Min: -5, Max: -5, Percentiles: map[string]float64{"p50": -5}, Histogram: []models.HistogramBin{{From: -5, To: -5, Count: 3}}},
+				"qty":    {Mean: 5, Min: 5, Max: 5, Percentiles: map[string]float64{"p50": 5}, Histogram: []models.HistogramBin{{From: 5, To: 5, Count: 3}}},
+				"ratio":  {Percentiles: map[string]float64{}, Histogram: []models.HistogramBin{}, Errors: 3},
+			}},
+		},
+		{
+			name: "Missing output",
+			request: models.Simulation{Inputs: []models.UncertainInput{{Cell: "price", Distribution: "uniform", Min: &three, Max: &three}},
+				Outputs: []string{"margin"}, Seed: &seed},
+			mockBehavior:  func() {},
+			expectedError: "invalid simulation: cell margin not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &excelLikeService{storage: storage, functions: NewDefaultFunctionRegistry(), graph: graphOf(graph...)}
+			tt.mockBehavior()
+			params, err := simulationParamsOf(&tt.request)
+			assert.NoError(t, err)
+			w, err := s.simulationOf(context.TODO(), tx, "sheet1", params)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			got, err := simulate(context.TODO(), w, "sheet1", params)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,612 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+var (
+	// ErrInvalidSolve is returned for optimizations that can't be run, like a variable cell holding a formula.
+	ErrInvalidSolve = errors.New("invalid solve")
+	// ErrNoSolution is returned when the constraints can't be met or the objective has no optimum.
+	ErrNoSolution = errors.New("no solution")
+
+	errNotLinear = errors.New("model isn't linear")
+	errNotNumber = errors.New("not a number")
+)
+
+const (
+	solverSimplex   = "simplex"
+	solverNonlinear = "nonlinear"
+
+	defaultSolverTolerance  = 1e-6
+	defaultSolverIterations = 1000
+	maxSolverIterations     = 10000
+)
+
+// solverProblem is an optimization over the variables of a model. Missing bounds are infinite,
+// ops and values are the constraints on the results of the model.
+type solverProblem struct {
+	maximize       bool
+	start          []float64
+	min, max       []float64
+	ops            []string
+	values         []float64
+	method         string
+	tolerance      float64
+	maxEvaluations int
+}
+
+func (p solverProblem) bounded() bool {
+	for j := range p.min {
+		if math.IsInf(p.min[j], 0) || math.IsInf(p.max[j], 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// solverEval evaluates the model for values of the variables, it returns the objective
+// and the results constrained by the ops of the problem.
+type solverEval func(x []float64) (objective float64, results []float64, err error)
+
+// SolveTX optimizes the objective cell by changing the variable cells. The model is evaluated in memory from
+// the sheet as it is, the found values are written only when the request asks to commit them.
+func (s *excelLikeService) SolveTX(ctx context.Context, sheetID string, request *models.Solve) (*models.SolveResult, error) {
+	problem, err := solverProblemOf(request)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.prepareWhatIf(ctx, func(tx *sql.Tx) (*whatIf, error) {
+		return s.solverModelOf(ctx, tx, sheetID, request, &problem)
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := solve(ctx, w, sheetID, request, problem)
+	if err != nil {
+		return nil, err
+	}
+	if request.Commit {
+		err = s.inTransaction(ctx, func(tx *sql.Tx) error {
+			for _, variable := range request.Variables {
+				cellID := strings.ToLower(variable.Cell)
+				if _, err := s.addCellInput(ctx, tx, newCalculation(), sheetID, cellID, &models.Data{Value: numberInput(res.Variables[cellID])}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	res.Committed = request.Commit
+	return res, nil
+}
+
+func solverProblemOf(request *models.Solve) (solverProblem, error) {
+	problem := solverProblem{method: strings.ToLower(request.Method), tolerance: request.Tolerance, maxEvaluations: request.MaxIterations}
+	switch strings.ToLower(request.Goal) {
+	case "max":
+		problem.maximize = true
+	case "min":
+	default:
+		return problem, fmt.Errorf("%w: goal must be max or min", ErrInvalidSolve)
+	}
+	switch {
+	case request.Objective == "":
+		return problem, fmt.Errorf("%w: objective cell is required", ErrInvalidSolve)
+	case len(request.Variables) == 0:
+		return problem, fmt.Errorf("%w: variable cells are required", ErrInvalidSolve)
+	case problem.method != "" && problem.method != solverSimplex && problem.method != solverNonlinear:
+		return problem, fmt.Errorf("%w: method must be simplex or nonlinear", ErrInvalidSolve)
+	case request.Tolerance < 0:
+		return problem, fmt.Errorf("%w: tolerance must not be negative", ErrInvalidSolve)
+	case request.MaxIterations < 0 || request.MaxIterations > maxSolverIterations:
+		return problem, fmt.Errorf("%w: max_iterations must be between 1 and %d, 0 or none means %d", ErrInvalidSolve,
+			maxSolverIterations, defaultSolverIterations)
+	}
+	seen := make(map[string]bool)
+	for _, variable := range request.Variables {
+		cellID := strings.ToLower(variable.Cell)
+		switch {
+		case cellID == "":
+			return problem, fmt.Errorf("%w: variable cell is required", ErrInvalidSolve)
+		case seen[cellID]:
+			return problem, fmt.Errorf("%w: variable %s is repeated", ErrInvalidSolve, cellID)
+		case variable.Min != nil && variable.Max != nil && *variable.Min > *variable.Max:
+			return problem, fmt.Errorf("%w: min of %s is greater than its max", ErrInvalidSolve, cellID)
+		}
+		seen[cellID] = true
+		min, max := math.Inf(-1), math.Inf(1)
+		if variable.Min != nil {
+			min = *variable.Min
+		}
+		if variable.Max != nil {
+			max = *variable.Max
+		}
+		problem.min, problem.max = append(problem.min, min), append(problem.max, max)
+	}
+	for _, constraint := range request.Constraints {
+		if constraint.Cell == "" {
+			return problem, fmt.Errorf("%w: constraint cell is required", ErrInvalidSolve)
+		}
+		if constraint.Op != "<=" && constraint.Op != ">=" && constraint.Op != "=" {
+			return problem, fmt.Errorf("%w: op of %s must be <=, >= or =", ErrInvalidSolve, strings.ToLower(constraint.Cell))
+		}
+		problem.ops, problem.values = append(problem.ops, constraint.Op), append(problem.values, constraint.Value)
+	}
+	if problem.tolerance == 0 {
+		problem.tolerance = defaultSolverTolerance
+	}
+	if problem.maxEvaluations == 0 {
+		problem.maxEvaluations = defaultSolverIterations
+	}
+	return problem, nil
+}
+
+// solverModelOf checks the cells of request and prepares the evaluation of the objective and the constrained
+// cells for the variables, reading stored cells within tx. It sets the start of problem to the values of the
+// variables. The dependency graph must not change meanwhile.
+func (s *excelLikeService) solverModelOf(ctx context.Context, tx *sql.Tx, sheetID string, request *models.Solve, problem *solverProblem) (*whatIf, error) {
+	cellIDs := []string{strings.ToLower(request.Objective)}
+	for _, constraint := range request.Constraints {
+		cellIDs = appendMissing(cellIDs, strings.ToLower(constraint.Cell))
+	}
+	reported := make([]db.CellRef, len(cellIDs))
+	for i, cellID := range cellIDs {
+		reported[i] = db.CellRef{SheetID: sheetID, CellID: cellID}
+		if _, ok := s.graph.value(reported[i]); !ok {
+			return nil, fmt.Errorf("%w: cell %s not found", ErrInvalidSolve, cellID)
+		}
+	}
+	overrides := make(map[db.CellRef]string, len(request.Variables))
+	problem.start = nil
+	for j, variable := range request.Variables {
+		cell := db.CellRef{SheetID: sheetID, CellID: strings.ToLower(variable.Cell)}
+		value, ok := s.graph.value(cell)
+		if !ok {
+			return nil, fmt.Errorf("%w: variable cell %s not found", ErrInvalidSolve, cell.CellID)
+		}
+		if strings.HasPrefix(value, "=") {
+			return nil, fmt.Errorf("%w: variable cell %s holds a formula", ErrInvalidSolve, cell.CellID)
+		}
+		start, ok := parseNumber(value)
+		if !ok {
+			return nil, fmt.Errorf("%w: variable cell %s doesn't hold a number", ErrInvalidSolve, cell.CellID)
+		}
+		problem.start = append(problem.start, math.Min(math.Max(start, problem.min[j]), problem.max[j]))
+		overrides[cell] = value
+	}
+	return s.newWhatIf(ctx, tx, overrides, reported)
+}
+
+// solve optimizes the model prepared in w, every evaluation calculates it in memory for values of the variables
+// and reads the objective and the constrained cells.
+func solve(ctx context.Context, w *whatIf, sheetID string, request *models.Solve, problem solverProblem) (*models.SolveResult, error) {
+	objective := strings.ToLower(request.Objective)
+	cellIDs := []string{objective}
+	for _, constraint := range request.Constraints {
+		cellIDs = appendMissing(cellIDs, strings.ToLower(constraint.Cell))
+	}
+	variables := make([]string, len(request.Variables))
+	for j, variable := range request.Variables {
+		variables[j] = strings.ToLower(variable.Cell)
+	}
+
+	var evaluations int
+	results := make(map[string]float64, len(cellIDs))
+	eval := func(x []float64) (float64, []float64, error) {
+		evaluations++
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+		fixed := make(map[db.CellRef]db.Input, len(variables))
+		for j, cellID := range variables {
+			cell := db.CellRef{SheetID: sheetID, CellID: cellID}
+			fixed[cell] = numberResult(cell, x[j])
+		}
+		calculated, err := w.evaluate(fixed)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, cellID := range cellIDs {
+			cell := db.CellRef{SheetID: sheetID, CellID: cellID}
+			input, _ := w.result(calculated, cell)
+			value := inputValue(cell, input)
+			if value.Type != TypeNumber {
+				return 0, nil, fmt.Errorf("%w: %s is %s", errNotNumber, cellID, value.AsText())
+			}
+			results[cellID] = value.Number
+		}
+		constrained := make([]float64, len(request.Constraints))
+		for i, constraint := range request.Constraints {
+			constrained[i] = results[strings.ToLower(constraint.Cell)]
+		}
+		return results[objective], constrained, nil
+	}
+
+	x, method, err := optimize(eval, problem)
+	if err != nil {
+		return nil, err
+	}
+	value, constrained, err := eval(x)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoSolution, err)
+	}
+	res := &models.SolveResult{
+		Method:      method,
+		Status:      "optimal",
+		Objective:   value,
+		Variables:   make(map[string]float64, len(variables)),
+		Constraints: make([]models.ConstraintResult, len(request.Constraints)),
+		Iterations:  evaluations,
+	}
+	if method == solverNonlinear {
+		res.Status = "locally_optimal"
+	}
+	for j, cellID := range variables {
+		res.Variables[cellID] = x[j]
+	}
+	for i, constraint := range request.Constraints {
+		slack := constraint.Value - constrained[i]
+		if constraint.Op == ">=" {
+			slack = -slack
+		}
+		constraint.Cell = strings.ToLower(constraint.Cell)
+		res.Constraints[i] = models.ConstraintResult{SolverConstraint: constraint, Result: constrained[i], Slack: slack}
+	}
+	return res, nil
+}
+
+// optimize solves linear models by simplex and others by the bounded nonlinear method, unless the problem
+// asks for a method. It returns the found values of the variables and the method used.
+func optimize(eval solverEval, p solverProblem) ([]float64, string, error) {
+	if p.method != solverNonlinear {
+		x, err := solveLinear(eval, p)
+		switch {
+		case err == nil:
+			return x, solverSimplex, nil
+		case !errors.Is(err, errNotLinear):
+			return nil, "", err
+		case p.method == solverSimplex:
+			return nil, "", fmt.Errorf("%w: %v, the nonlinear method can solve it", ErrInvalidSolve, err)
+		}
+	}
+	if !p.bounded() {
+		reason := "the nonlinear method needs min and max for every variable"
+		if p.method == "" {
+			reason = errNotLinear.Error() + ", " + reason
+		}
+		return nil, "", fmt.Errorf("%w: %s", ErrInvalidSolve, reason)
+	}
+	x, err := minimizeBounded(eval, p)
+	return x, solverNonlinear, err
+}
+
+// solveLinear measures the coefficients of the model by moving each variable by one from the start,
+// checks them at another point and after solving, and solves the linear program by simplex.
+func solveLinear(eval solverEval, p solverProblem) ([]float64, error) {
+	n := len(p.start)
+	objective0, results0, err := eval(p.start)
+	if err != nil {
+		return nil, noSolution(err)
+	}
+	objective := make([]float64, n)
+	coefs := make([][]float64, len(results0))
+	for i := range coefs {
+		coefs[i] = make([]float64, n)
+	}
+	for j := 0; j < n; j++ {
+		x := append([]float64(nil), p.start...)
+		x[j]++
+		value, results, err := eval(x)
+		if err != nil {
+			return nil, noSolution(err)
+		}
+		objective[j] = value - objective0
+		for i := range results {
+			coefs[i][j] = results[i] - results0[i]
+		}
+	}
+	// predicted tells whether the model gives at x what the coefficients predict
+	predicted := func(x []float64) (bool, error) {
+		value, results, err := eval(x)
+		if err != nil {
+			return false, noSolution(err)
+		}
+		ok := nearlyEqual(value, objective0+shiftedDot(objective, x, p.start))
+		for i := range results {
+			ok = ok && nearlyEqual(results[i], results0[i]+shiftedDot(coefs[i], x, p.start))
+		}
+		return ok, nil
+	}
+	check := append([]float64(nil), p.start...)
+	for j := range check {
+		check[j] += float64(j + 2)
+	}
+	if ok, err := predicted(check); err != nil || !ok {
+		if err == nil {
+			err = errNotLinear
+		}
+		return nil, err
+	}
+
+	var rows []linearRow
+	for i, op := range p.ops {
+		rows = append(rows, linearRow{coef: coefs[i], op: op, rhs: p.values[i] - results0[i] + dot(coefs[i], p.start)})
+	}
+	for j := 0; j < n; j++ {
+		unit := make([]float64, n)
+		unit[j] = 1
+		if !math.IsInf(p.min[j], 0) {
+			rows = append(rows, linearRow{coef: unit, op: ">=", rhs: p.min[j]})
+		}
+		if !math.IsInf(p.max[j], 0) {
+			rows = append(rows, linearRow{coef: unit, op: "<=", rhs: p.max[j]})
+		}
+	}
+	if !p.maximize {
+		for j := range objective {
+			objective[j] = -objective[j]
+		}
+	}
+	x, err := maximizeLinear(objective, rows)
+	if err != nil {
+		return nil, noSolution(err)
+	}
+	for j := range x {
+		x[j] = snap(x[j])
+	}
+	if ok, err := predicted(x); err != nil || !ok {
+		if err == nil {
+			err = errNotLinear
+		}
+		return nil, err
+	}
+	return x, nil
+}
+
+// minimizeBounded runs Nelder-Mead within the bounds of the variables, scaled to [0, 1], on the objective
+// with a quadratic penalty for violated constraints. The penalty grows each round, starting from the best point
+// so far. Points where the model isn't a number are avoided. The result is a local optimum.
+func minimizeBounded(eval solverEval, p solverProblem) ([]float64, error) {
+	n := len(p.start)
+	toX := func(u []float64) []float64 {
+		x := make([]float64, n)
+		for j := range u {
+			x[j] = p.min[j] + u[j]*(p.max[j]-p.min[j])
+		}
+		return x
+	}
+	sign := 1.0
+	if p.maximize {
+		sign = -1
+	}
+	evaluations := 0
+	var penalty float64
+	f := func(u []float64) (float64, error) {
+		evaluations++
+		value, results, err := eval(toX(u))
+		if errors.Is(err, errNotNumber) {
+			return math.Inf(1), nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		res := sign * value
+		for i, result := range results {
+			v := violation(p.ops[i], result, p.values[i])
+			res += penalty * v * v
+		}
+		return res, nil
+	}
+
+	u := make([]float64, n)
+	for j := range u {
+		if p.max[j] > p.min[j] {
+			u[j] = (p.start[j] - p.min[j]) / (p.max[j] - p.min[j])
+		}
+	}
+	scale := 1.0
+	if value, _, err := eval(p.start); err == nil {
+		scale = math.Max(1, math.Abs(value))
+	}
+	rounds := 1
+	if len(p.ops) > 0 {
+		rounds = 4
+	}
+	for round := 0; round < rounds; round++ {
+		penalty = scale * math.Pow(100, float64(round+1))
+		budget := (p.maxEvaluations - evaluations) / (rounds - round)
+		var err error
+		if u, err = nelderMead(f, u, budget); err != nil {
+			return nil, err
+		}
+	}
+
+	x := toX(u)
+	for j := range x {
+		x[j] = snap(x[j])
+	}
+	_, results, err := eval(x)
+	if err != nil {
+		return nil, noSolution(err)
+	}
+	for i, result := range results {
+		if violation(p.ops[i], result, p.values[i]) > p.tolerance*math.Max(1, math.Abs(p.values[i])) {
+			return nil, fmt.Errorf("%w: no feasible solution found", ErrNoSolution)
+		}
+	}
+	return x, nil
+}
+
+// nelderMead minimizes f from start within the unit cube, points leaving it are clamped back.
+// It stops when the simplex collapses or after budget calls of f and returns the best point.
+func nelderMead(f func(u []float64) (float64, error), start []float64, budget int) ([]float64, error) {
+	n := len(start)
+	clamp := func(u []float64) []float64 {
+		for j := range u {
+			u[j] = math.Min(math.Max(u[j], 0), 1)
+		}
+		return u
+	}
+	points := make([][]float64, n+1)
+	values := make([]float64, n+1)
+	points[0] = clamp(append([]float64(nil), start...))
+	for j := 1; j <= n; j++ {
+		points[j] = append([]float64(nil), points[0]...)
+		if points[j][j-1] += 0.1; points[j][j-1] > 1 {
+			points[j][j-1] -= 0.2
+		}
+	}
+	calls := 0
+	for j := range points {
+		var err error
+		if values[j], err = f(points[j]); err != nil {
+			return nil, err
+		}
+		calls++
+	}
+	// along moves from the centroid c away from the worst point by t
+	along := func(c, worst []float64, t float64) []float64 {
+		res := make([]float64, n)
+		for j := range res {
+			res[j] = c[j] + t*(c[j]-worst[j])
+		}
+		return clamp(res)
+	}
+	for calls < budget {
+		sortSimplex(points, values)
+		if simplexDiameter(points) <= 1e-10 {
+			break
+		}
+		c := make([]float64, n)
+		for _, point := range points[:n] {
+			for j := range c {
+				c[j] += point[j] / float64(n)
+			}
+		}
+		worst := points[n]
+		reflected := along(c, worst, 1)
+		fr, err := f(reflected)
+		if err != nil {
+			return nil, err
+		}
+		calls++
+		switch {
+		case fr < values[0]:
+			expanded := along(c, worst, 2)
+			fe, err := f(expanded)
+			if err != nil {
+				return nil, err
+			}
+			calls++
+			if fe < fr {
+				points[n], values[n] = expanded, fe
+			} else {
+				points[n], values[n] = reflected, fr
+			}
+		case fr < values[n-1]:
+			points[n], values[n] = reflected, fr
+		default:
+			contracted := along(c, worst, -0.5)
+			fc, err := f(contracted)
+			if err != nil {
+				return nil, err
+			}
+			calls++
+			if fc < values[n] {
+				points[n], values[n] = contracted, fc
+				continue
+			}
+			for i := 1; i <= n; i++ {
+				for j := range points[i] {
+					points[i][j] = points[0][j] + (points[i][j]-points[0][j])/2
+				}
+				if values[i], err = f(points[i]); err != nil {
+					return nil, err
+				}
+				calls++
+			}
+		}
+	}
+	sortSimplex(points, values)
+	return points[0], nil
+}
+
+func sortSimplex(points [][]float64, values []float64) {
+	for i := 1; i < len(points); i++ {
+		for j := i; j > 0 && values[j] < values[j-1]; j-- {
+			points[j], points[j-1] = points[j-1], points[j]
+			values[j], values[j-1] = values[j-1], values[j]
+		}
+	}
+}
+
+func simplexDiameter(points [][]float64) float64 {
+	var res float64
+	for _, point := range points[1:] {
+		for j := range point {
+			res = math.Max(res, math.Abs(point[j]-points[0][j]))
+		}
+	}
+	return res
+}
+
+// violation is how far result is outside the constraint op value.
+func violation(op string, result, value float64) float64 {
+	switch op {
+	case "<=":
+		return math.Max(0, result-value)
+	case ">=":
+		return math.Max(0, value-result)
+	}
+	return math.Abs(result - value)
+}
+
+func noSolution(err error) error {
+	if errors.Is(err, errNotNumber) || errors.Is(err, errInfeasible) || errors.Is(err, errUnbounded) {
+		return fmt.Errorf("%w: %v", ErrNoSolution, err)
+	}
+	return err
+}
+
+func dot(a, b []float64) float64 {
+	var res float64
+	for i := range a {
+		res += a[i] * b[i]
+	}
+	return res
+}
+
+// shiftedDot is coef·(x-origin).
+func shiftedDot(coef, x, origin []float64) float64 {
+	var res float64
+	for i := range coef {
+		res += coef[i] * (x[i] - origin[i])
+	}
+	return res
+}
+
+func nearlyEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-7*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+// snap rounds x to 12 significant digits, dropping the noise the methods leave in values like 2.5000000000000004.
+func snap(x float64) float64 {
+	res, err := strconv.ParseFloat(strconv.FormatFloat(x, 'g', 12, 64), 64)
+	if err != nil {
+		return x
+	}
+	return res
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+	"dev-challenge/internal/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_optimize(t *testing.T) {
+	inf := math.Inf(1)
+	tests := []struct {
+		name           string
+		eval           solverEval
+		problem        solverProblem
+		expected       []float64
+		expectedMethod string
+		expectedError  string
+	}{
+		{
+			name: "Linear production plan",
+			eval: func(x []float64) (float64, []float64, error) {
+				return 3*x[0] + 5*x[1], []float64{x[0], 2 * x[1], 3*x[0] + 2*x[1]}, nil
+			},
+			problem: solverProblem{maximize: true, start: []float64{0, 0}, min: []float64{0, 0}, max: []float64{inf, inf},
+				ops: []string{"<=", "<=", "<="}, values: []float64{4, 12, 18}},
+			expected:       []float64{2, 6},
+			expectedMethod: solverSimplex,
+		},
+		{
+			name: "Nonlinear with a constraint",
+			eval: func(x []float64) (float64, []float64, error) {
+				return (x[0]-1)*(x[0]-1) + (x[1]-2)*(x[1]-2), []float64{x[0] + x[1]}, nil
+			},
+			problem:        solverProblem{start: []float64{0, 0}, min: []float64{-5, -5}, max: []float64{5, 5}, ops: []string{"<="}, values: []float64{2}},
+			expected:       []float64{0.5, 1.5},
+			expectedMethod: solverNonlinear,
+		},
+		{
+			name: "Nonlinear maximum",
+			eval: func(x []float64) (float64, []float64, error) {
+				return x[0] * (10 - x[0]), nil, nil
+			},
+			problem:        solverProblem{maximize: true, start: []float64{1}, min: []float64{0}, max: []float64{10}},
+			expected:       []float64{5},
+			expectedMethod: solverNonlinear,
+		},
+		{
+			name: "Nonlinear avoids errors",
+			eval: func(x []float64) (float64, []float64, error) {
+				if x[0] < 1 {
+					return 0, nil, errNotNumber
+				}
+				return x[0], nil, nil
+			},
+			problem:        solverProblem{method: solverNonlinear, start: []float64{2}, min: []float64{0}, max: []float64{3}},
+			expected:       []float64{1},
+			expectedMethod: solverNonlinear,
+		},
+		{
+			name: "Nonlinear without bounds",
+			eval: func(x []float64) (float64, []float64, error) {
+				return x[0] * x[0], nil, nil
+			},
+			problem:       solverProblem{start: []float64{1}, min: []float64{0}, max: []float64{inf}},
+			expectedError: "invalid solve: model isn't linear, the nonlinear method needs min and max for every variable",
+		},
+		{
+			name: "Simplex on a nonlinear model",
+			eval: func(x []float64) (float64, []float64, error) {
+				return x[0] * x[0], nil, nil
+			},
+			problem:       solverProblem{method: solverSimplex, start: []float64{1}, min: []float64{0}, max: []float64{5}},
+			expectedError: "invalid solve: model isn't linear, the nonlinear method can solve it",
+		},
+		{
+			name: "Infeasible",
+			eval: func(x []float64) (float64, []float64, error) {
+				return x[0], []float64{x[0]}, nil
+			},
+			problem:       solverProblem{maximize: true, start: []float64{0}, min: []float64{0}, max: []float64{5}, ops: []string{">="}, values: []float64{10}},
+			expectedError: "no solution: no feasible solution",
+		},
+		{
+			name: "Unbounded",
+			eval: func(x []float64) (float64, []float64, error) {
+				return x[0] + x[1], []float64{x[0] - x[1]}, nil
+			},
+			problem:       solverProblem{maximize: true, start: []float64{0, 0}, min: []float64{0, 0}, max: []float64{inf, inf}, ops: []string{"<="}, values: []float64{1}},
+			expectedError: "no solution: objective is unbounded",
+		},
+		{
+			name: "Nonlinear infeasible",
+			eval: func(x []float64) (float64, []float64, error) {
+				return x[0] * x[0], []float64{x[0]}, nil
+			},
+			problem:       solverProblem{start: []float64{1}, min: []float64{0}, max: []float64{5}, ops: []string{">="}, values: []float64{10}},
+			expectedError: "no solution: no feasible solution found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := tt.problem
+			problem.tolerance, problem.maxEvaluations = defaultSolverTolerance, defaultSolverIterations
+			got, method, err := optimize(tt.eval, problem)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedMethod, method)
+			assert.InDeltaSlice(t, tt.expected, got, 1e-4)
+		})
+	}
+}
+
+func Test_solverProblemOf(t *testing.T) {
+	lo, hi := 0.0, 1.0
+	inf := math.Inf(1)
+	tests := []struct {
+		name          string
+		request       models.Solve
+		expected      solverProblem
+		expectedError string
+	}{
+		{
+			name: "Defaults",
+			request: models.Solve{Objective: "profit", Goal: "MAX", Variables: []models.SolverVariable{{Cell: "x", Min: &lo}, {Cell: "y", Min: &lo, Max: &hi}},
+				Constraints: []models.SolverConstraint{{Cell: "labor", Op: "<=", Value: 40}}},
+			expected: solverProblem{maximize: true, min: []float64{0, 0}, max: []float64{inf, 1}, ops: []string{"<="}, values: []float64{40},
+				tolerance: defaultSolverTolerance, maxEvaluations: defaultSolverIterations},
+		},
+		{
+			name:          "Wrong goal",
+			request:       models.Solve{Objective: "profit", Goal: "best"},
+			expectedError: "invalid solve: goal must be max or min",
+		},
+		{
+			name:          "No variables",
+			request:       models.Solve{Objective: "profit", Goal: "min"},
+			expectedError: "invalid solve: variable cells are required",
+		},
+		{
+			name:          "Repeated variable",
+			request:       models.Solve{Objective: "profit", Goal: "min", Variables: []models.SolverVariable{{Cell: "x"}, {Cell: "X"}}},
+			expectedError: "invalid solve: variable x is repeated",
+		},
+		{
+			name:          "Wrong bounds",
+			request:       models.Solve{Objective: "profit", Goal: "min", Variables: []models.SolverVariable{{Cell: "x", Min: &hi, Max: &lo}}},
+			expectedError: "invalid solve: min of x is greater than its max",
+		},
+		{
+			name: "Wrong op",
+			request: models.Solve{Objective: "profit", Goal: "min", Variables: []models.SolverVariable{{Cell: "x"}},
+				Constraints: []models.SolverConstraint{{Cell: "labor", Op: "<"}}},
+			expectedError: "invalid solve: op of labor must be <=, >= or =",
+		},
+		{
+			name:          "Wrong method",
+			request:       models.Solve{Objective: "profit", Goal: "min", Variables: []models.SolverVariable{{Cell: "x"}}, Method: "genetic"},
+			expectedError: "invalid solve: method must be simplex or nonlinear",
+		},
+		{
+			name:          "Too many iterations",
+			request:       models.Solve{Objective: "profit", Goal: "min", Variables: []models.SolverVariable{{Cell: "x"}}, MaxIterations: 10001},
+			expectedError: "invalid solve: max_iterations must be between 1 and 10000, 0 or none means 1000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := solverProblemOf(&tt.request)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestExcelLikeService_solve(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+	storage.EXPECT().GetSheetSettings(gomock.Any(), tx, gomock.Any()).DoAndReturn(defaultSettings).AnyTimes()
+	graph := []db.Input{
+		{SheetID: "sheet1", CellID: "x", Value: "1", Result: 1, ResultType: "number"},
+		{SheetID: "sheet1", CellID: "y", Value: "1", Result: 1, ResultType: "number"},
+		{SheetID: "sheet1", CellID: "profit", Value: "=3*x+5*y", Result: 8, ResultType: "number", UsedParams: sheet1Cells("x", "y")},
+		{SheetID: "sheet1", CellID: "machine", Value: "=3*x+2*y", Result: 5, ResultType: "number", UsedParams: sheet1Cells("x", "y")},
+		{SheetID: "sheet1", CellID: "label", Value: "plan", ResultText: "plan", ResultType: "text"},
+	}
+	lo, four, six := 0.0, 4.0, 6.0
+
+	tests := []struct {
+		name          string
+		request       models.Solve
+		mockBehavior  func()
+		expected      *models.SolveResult
+		expectedError string
+	}{
+		{
+			name: "Linear plan",
+			request: models.Solve{Objective: "Profit", Goal: "max",
+				Variables:   []models.SolverVariable{{Cell: "x", Min: &lo, Max: &four}, {Cell: "y", Min: &lo, Max: &six}},
+				Constraints: []models.SolverConstraint{{Cell: "machine", Op: "<=", Value: 18}}},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"profit", "machine"}).
+					Return(map[string]db.Input{"profit": graph[2], "machine": graph[3]}, nil)
+			},
+			expected: &models.SolveResult{
+				Method: "simplex", Status: "optimal", Objective: 36,
+				Variables: map[string]float64{"x": 2, "y": 6},
+				Constraints: []models.ConstraintResult{{
+					SolverConstraint: models.SolverConstraint{Cell: "machine", Op: "<=", Value: 18}, Result: 18, Slack: 0,
+				}},
+				Iterations: 6,
+			},
+		},
+		{
+			name:          "Missing objective",
+			request:       models.Solve{Objective: "revenue", Goal: "max", Variables: []models.SolverVariable{{Cell: "x"}}},
+			expectedError: "invalid solve: cell revenue not found",
+		},
+		{
+			name:          "Formula variable",
+			request:       models.Solve{Objective: "profit", Goal: "max", Variables: []models.SolverVariable{{Cell: "machine"}}},
+			expectedError: "invalid solve: variable cell machine holds a formula",
+		},
+		{
+			name:          "Text variable",
+			request:       models.Solve{Objective: "profit", Goal: "max", Variables: []models.SolverVariable{{Cell: "label"}}},
+			expectedError: "invalid solve: variable cell label doesn't hold a number",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &excelLikeService{storage: storage, functions: NewDefaultFunctionRegistry(), graph: graphOf(graph...)}
+			if tt.mockBehavior != nil {
+				tt.mockBehavior()
+			}
+			problem, err := solverProblemOf(&tt.request)
+			assert.NoError(t, err)
+			w, err := s.solverModelOf(context.TODO(), tx, "sheet1", &tt.request, &problem)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			got, err := solve(context.TODO(), w, "sheet1", &tt.request, problem)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -1,151 +1,590 @@
 package services
 
 import (
-	"errors"
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
-	"regexp"
-	"sort"
-	"strconv"
+	"math/big"
 	"strings"
 	"unicode"
+
+	"dev-challenge/db"
 )
 
-func extractParams(expr string) []string {
-	if strings.HasPrefix(expr, "=-") {
-		expr = "=" + expr[2:]
+// extractParams returns the unique cells referenced by the formula tree in order of appearance,
+// references without a sheet belong to sheetID.
+func extractParams(root node, sheetID string) []db.CellRef {
+	params := make([]db.CellRef, 0)
+	seen := make(map[db.CellRef]bool)
+	walk(root, func(n node) {
+		if ref, ok := n.(*refNode); ok {
+			cell := resolveRef(ref, sheetID)
+			if !seen[cell] {
+				seen[cell] = true
+				params = append(params, cell)
+			}
+		}
+	})
+	return params
+}
+
+// extractRanges returns the unique ranges used by the formula tree, ranges without a sheet belong to sheetID.
+func extractRanges(root node, sheetID string) []db.CellRange {
+	var ranges []db.CellRange
+	seen := make(map[db.CellRange]bool)
+	walk(root, func(n node) {
+		if rng, ok := n.(*rangeNode); ok {
+			cells := resolveRange(rng, sheetID)
+			if !seen[cells] {
+				seen[cells] = true
+				ranges = append(ranges, cells)
+			}
+		}
+	})
+	return ranges
+}
+
+// extractPatterns returns the unique wildcard patterns used by the formula tree, patterns without a sheet
+// belong to sheetID.
+func extractPatterns(root node, sheetID string) []db.CellPattern {
+	var patterns []db.CellPattern
+	seen := make(map[db.CellPattern]bool)
+	walk(root, func(n node) {
+		if pattern, ok := n.(*patternNode); ok {
+			cells := resolvePattern(pattern, sheetID)
+			if !seen[cells] {
+				seen[cells] = true
+				patterns = append(patterns, cells)
+			}
+		}
+	})
+	return patterns
+}
+
+// extractDependencies returns the cells, ranges and patterns used by the formula tree.
+func extractDependencies(root node, sheetID string) db.Dependencies {
+	return db.Dependencies{
+		Cells:    extractParams(root, sheetID),
+		Ranges:   extractRanges(root, sheetID),
+		Patterns: extractPatterns(root, sheetID),
 	}
+}
 
-	// Match all sequences of word characters
-	re := regexp.MustCompile(`\b\w+\b`)
-	matches := re.FindAllString(expr, -1)
+// withRangeCells appends all cells covered by ranges to cells skipping duplicates.
+func withRangeCells(cells []db.CellRef, ranges []db.CellRange) []db.CellRef {
+	groups := make([][]db.CellRef, 0, len(ranges))
+	for _, cellRange := range ranges {
+		groups = append(groups, rangeCells(cellRange))
+	}
+	return appendCells(cells, groups...)
+}
+
+func appendCell(cells []db.CellRef, cell db.CellRef) []db.CellRef {
+	for _, c := range cells {
+		if c == cell {
+			return cells
+		}
+	}
+	return append(cells, cell)
+}
 
-	uniqueMatches := make(map[string]bool)
-	for _, match := range matches {
-		index := strings.Index(expr, match)
-		if index == 0 || (!unicode.IsLetter(rune(expr[index-1])) && !unicode.IsDigit(rune(expr[index-1]))) {
-			if _, err := strconv.Atoi(match); err != nil { // If not purely numeric
-				uniqueMatches[match] = true
+// appendCells appends cells of all groups to cells skipping duplicates.
+func appendCells(cells []db.CellRef, groups ...[]db.CellRef) []db.CellRef {
+	if len(groups) == 0 {
+		return cells
+	}
+	seen := make(map[db.CellRef]bool, len(cells))
+	res := make([]db.CellRef, 0, len(cells))
+	for _, cell := range cells {
+		seen[cell] = true
+		res = append(res, cell)
+	}
+	for _, group := range groups {
+		for _, cell := range group {
+			if !seen[cell] {
+				seen[cell] = true
+				res = append(res, cell)
 			}
 		}
 	}
+	return res
+}
 
-	params := make([]string, 0, len(uniqueMatches))
-	for param := range uniqueMatches {
-		params = append(params, param)
+func resolveRange(rng *rangeNode, sheetID string) db.CellRange {
+	cells := rng.cells
+	if cells.SheetID == "" {
+		cells.SheetID = sheetID
 	}
+	return cells
+}
 
-	return params
+func resolvePattern(pattern *patternNode, sheetID string) db.CellPattern {
+	cells := pattern.cells
+	if cells.SheetID == "" {
+		cells.SheetID = sheetID
+	}
+	return cells
 }
 
-func replaceParamsWithValues(expr string, params map[string]string) string {
-	keys := make([]string, 0, len(params))
-	for k := range params {
-		keys = append(keys, k)
+func resolveRef(ref *refNode, sheetID string) db.CellRef {
+	if ref.sheetID != "" {
+		sheetID = ref.sheetID
 	}
+	return db.CellRef{SheetID: sheetID, CellID: ref.cellID}
+}
 
-	// Sort the keys based on length, longest first
-	sort.Slice(keys, func(i, j int) bool {
-		return len(keys[i]) > len(keys[j])
-	})
+// walk calls fn for every node of the tree in depth-first order.
+func walk(n node, fn func(node)) {
+	fn(n)
+	for _, child := range nodeChildren(n) {
+		walk(child, fn)
+	}
+}
 
-	// Replace based on sorted keys
-	for _, k := range keys {
-		expr = strings.ReplaceAll(expr, k, params[k])
+// nodeChildren lists the operands of an operator or the arguments of a function call.
+func nodeChildren(n node) []node {
+	switch n := n.(type) {
+	case *unaryNode:
+		return []node{n.operand}
+	case *binaryNode:
+		return []node{n.left, n.right}
+	case *callNode:
+		return n.args
 	}
+	return nil
+}
+
+// evaluator computes formula trees of a cell on sheetID, resolving cell references against values.
+// Cells of ranges missing in values are treated as empty, patterns expand into the cells listed in patterns.
+// With decimal set numbers are calculated exactly, see Value.Dec. References to cells missing in values
+// fail with #REF!, unless missingAsZero is set and they aren't in deleted. A non-nil trace records the outcome
+// of every evaluated node.
+type evaluator struct {
+	sheetID       string
+	values        map[db.CellRef]Value
+	patterns      map[db.CellPattern][]db.CellRef
+	functions     *FunctionRegistry
+	decimal       bool
+	missingAsZero bool
+	deleted       map[db.CellRef]bool
+	trace         map[node]tracedValue
+}
 
-	return expr
+// tracedValue is the outcome of a traced node, ranges and patterns expanded into arguments have no value.
+type tracedValue struct {
+	value Value
+	err   error
 }
 
-func calculate(expr string) (float64, error) {
-	trimmedExpr := strings.TrimPrefix(cleanExpression(expr), "=")
-	expression, err := parser.ParseExpr(trimmedExpr)
+func (e *evaluator) eval(n node) (Value, error) {
+	value, err := e.evalNode(n)
+	if err == nil {
+		value = e.exact(value)
+	}
+	e.traced(n, value, err)
 	if err != nil {
-		return 0, err
+		return Value{}, err
+	}
+	return value, nil
+}
+
+func (e *evaluator) traced(n node, value Value, err error) {
+	if e.trace != nil {
+		e.trace[n] = tracedValue{value: value, err: err}
+	}
+}
+
+// exact makes numbers exact in decimal mode, otherwise exact numbers of sheets in decimal mode
+// are calculated as float64 like any other number.
+func (e *evaluator) exact(v Value) Value {
+	if v.Type != TypeNumber || e.decimal == (v.Dec != nil) {
+		return v
+	}
+	if !e.decimal {
+		return NumberValue(v.Number)
 	}
-	return eval(expression)
+	if d := ratFromFloat(v.Number); d != nil {
+		return DecimalValue(d)
+	}
+	return v
 }
 
-func eval(expr ast.Expr) (float64, error) {
-	switch e := expr.(type) {
-	case *ast.BinaryExpr:
-		x, err := eval(e.X)
+func (e *evaluator) evalNode(n node) (Value, error) {
+	switch n := n.(type) {
+	case *numberNode:
+		if e.decimal {
+			if d := ratFromText(n.text); d != nil {
+				return DecimalValue(d), nil
+			}
+		}
+		return NumberValue(n.value), nil
+	case *boolNode:
+		return BoolValue(n.value), nil
+	case *textNode:
+		return TextValue(n.value), nil
+	case *refNode:
+		cell := resolveRef(n, e.sheetID)
+		value, ok := e.values[cell]
+		if !ok && e.deleted[cell] {
+			return Value{}, newFormulaError(ErrRef, "referenced cell %q was deleted", formatRef(cell, e.sheetID))
+		}
+		if !ok && e.missingAsZero {
+			return NumberValue(0), nil
+		}
+		if !ok {
+			return Value{}, newFormulaError(ErrRef, "referenced cell %q not found", formatRef(cell, e.sheetID))
+		}
+		if value.Type == TypeError {
+			return Value{}, value.Err
+		}
+		return value, nil
+	case *unaryNode:
+		value, err := e.eval(n.operand)
+		if err != nil || n.op == "+" {
+			return value, err
+		}
+		if value.Dec != nil {
+			return DecimalValue(new(big.Rat).Neg(value.Dec)), nil
+		}
+		number, err := value.AsNumber()
+		if err != nil {
+			return Value{}, err
+		}
+		return NumberValue(-number), nil
+	case *binaryNode:
+		x, err := e.eval(n.left)
 		if err != nil {
-			return 0, err
+			return Value{}, err
 		}
-		y, err := eval(e.Y)
+		y, err := e.eval(n.right)
 		if err != nil {
-			return 0, err
-		}
-		switch e.Op {
-		case token.ADD:
-			return x + y, nil
-		case token.SUB:
-			return x - y, nil
-		case token.MUL:
-			return x * y, nil
-		case token.QUO:
-			if y == 0 {
-				return 0, errors.New("division by zero")
+			return Value{}, err
+		}
+		return applyBinary(n.op, x, y)
+	case *callNode:
+		return e.evalCall(n)
+	case *rangeNode:
+		return Value{}, newFormulaError(ErrValue, "range %s at position %d can only be used as a function argument", formatRange(resolveRange(n, e.sheetID), e.sheetID), n.pos)
+	case *patternNode:
+		return Value{}, newFormulaError(ErrValue, "pattern %s at position %d can only be used as a function argument", formatPattern(resolvePattern(n, e.sheetID), e.sheetID), n.pos)
+	default:
+		return Value{}, fmt.Errorf("expression type %T not supported", n)
+	}
+}
+
+func (e *evaluator) evalCall(call *callNode) (Value, error) {
+	fn, ok := e.functions.Lookup(call.name)
+	if !ok {
+		return Value{}, newFormulaError(ErrName, "unknown function %q at position %d", call.name, call.pos)
+	}
+	if len(call.args) < fn.MinArgs() || (fn.MaxArgs() >= 0 && len(call.args) > fn.MaxArgs()) {
+		return Value{}, newFormulaError(ErrValue, "wrong number of arguments for %s: %d", fn.Name, len(call.args))
+	}
+
+	if fn.CallLazy != nil {
+		args := make([]LazyArg, len(call.args))
+		for i, arg := range call.args {
+			i, arg := i, arg
+			args[i] = func() (Value, error) {
+				return e.evalArg(fn, i, arg)
 			}
-			return x / y, nil
-		default:
-			return 0, fmt.Errorf("unsupported binary operator: %v", e.Op)
-		}
-	case *ast.ParenExpr:
-		return eval(e.X)
-	case *ast.BasicLit:
-		if e.Kind == token.INT || e.Kind == token.FLOAT {
-			return strconv.ParseFloat(e.Value, 64)
-		}
-	case *ast.UnaryExpr:
-		// For unary minus
-		if e.Op == token.SUB {
-			value, err := eval(e.X)
+		}
+		return fn.CallLazy(args)
+	}
+
+	args := make([]Value, 0, len(call.args))
+	for i, arg := range call.args {
+		argType := fn.argType(i)
+		if cells, ok := e.expand(arg); ok && fn.Variadic && i >= len(fn.Args)-1 {
+			// ranges and patterns expand into the values of their non-empty cells,
+			// numeric and logical arguments skip text cells like spreadsheets do
+			for _, cell := range cells {
+				value, ok := e.values[cell]
+				if ok && value.Type == TypeError {
+					e.traced(arg, Value{}, value.Err)
+					return Value{}, value.Err
+				}
+				if ok && acceptsCell(argType, value) {
+					converted, err := convertArg(value, argType)
+					if err != nil {
+						e.traced(arg, Value{}, err)
+						return Value{}, err
+					}
+					args = append(args, converted)
+				}
+			}
+			e.traced(arg, Value{}, nil)
+			continue
+		}
+		value, err := e.evalArg(fn, i, arg)
+		if err != nil {
+			return Value{}, err
+		}
+		args = append(args, value)
+	}
+
+	if fn.CallValues != nil {
+		return fn.CallValues(args)
+	}
+	if e.decimal && fn.CallDecimal != nil {
+		decimals := make([]*big.Rat, len(args))
+		for i, arg := range args {
+			d, err := arg.AsDecimal()
 			if err != nil {
-				return 0, err
+				return Value{}, err
 			}
-			return -value, nil
+			decimals[i] = d
 		}
-		return 0, fmt.Errorf("unary operation %v not supported", e.Op)
-	default:
-		return 0, fmt.Errorf("expression type %T not supported", e)
+		result, err := fn.CallDecimal(decimals)
+		if err != nil {
+			return Value{}, err
+		}
+		return DecimalValue(result), nil
+	}
+	numbers := make([]float64, len(args))
+	for i, arg := range args {
+		numbers[i] = arg.Number
 	}
-	return 0, nil
+	result, err := fn.Call(numbers)
+	if err != nil {
+		return Value{}, err
+	}
+	return NumberValue(result), nil
 }
 
-func cleanExpression(expr string) string {
-	re := regexp.MustCompile(`(\+|\-)+`)
-	expr = re.ReplaceAllStringFunc(expr, func(s string) string {
-		if strings.Count(s, "-")%2 == 0 {
-			return "+"
+// evalArg evaluates the i-th argument of fn and converts it to the declared type.
+func (e *evaluator) evalArg(fn Function, i int, arg node) (Value, error) {
+	value, err := e.eval(arg)
+	if err != nil {
+		return Value{}, err
+	}
+	converted, err := convertArg(value, fn.argType(i))
+	if err != nil {
+		return Value{}, fmt.Errorf("argument %d of %s: %w", i+1, fn.Name, err)
+	}
+	return converted, nil
+}
+
+// convertArg converts a value to the declared argument type, ArgAny keeps it as is.
+func convertArg(value Value, argType ArgType) (Value, error) {
+	switch argType {
+	case ArgNumber:
+		if value.Type == TypeNumber {
+			return value, nil
 		}
-		return "-"
-	})
-	return strings.Replace(expr, "=+", "=", 1)
+		number, err := value.AsNumber()
+		return NumberValue(number), err
+	case ArgText:
+		return TextValue(value.AsText()), nil
+	case ArgBoolean:
+		b, err := value.AsBool()
+		return BoolValue(b), err
+	}
+	return value, nil
 }
 
-func isValid(str string) bool {
-	_, intErr := strconv.Atoi(str)
-	_, floatErr := strconv.ParseFloat(str, 64)
+// acceptsCell reports whether a cell of a range or pattern is passed to an argument of argType.
+func acceptsCell(argType ArgType, value Value) bool {
+	switch argType {
+	case ArgNumber:
+		return value.Type == TypeNumber
+	case ArgBoolean:
+		return value.Type != TypeText
+	}
+	return true
+}
 
-	if intErr != nil && floatErr != nil {
-		if !strings.HasPrefix(str, "=") {
-			return false
+// expand lists the cells of a range or pattern argument, ok is false for any other node.
+func (e *evaluator) expand(n node) (cells []db.CellRef, ok bool) {
+	switch n := n.(type) {
+	case *rangeNode:
+		return rangeCells(resolveRange(n, e.sheetID)), true
+	case *patternNode:
+		return e.patterns[resolvePattern(n, e.sheetID)], true
+	}
+	return nil, false
+}
+
+func applyBinary(op string, left, right Value) (Value, error) {
+	switch op {
+	case "&":
+		return TextValue(left.AsText() + right.AsText()), nil
+	case "=", "<>", "<", "<=", ">", ">=":
+		return BoolValue(applyComparison(op, compareValues(left, right))), nil
+	}
+	if left.Dec != nil || right.Dec != nil {
+		return applyDecimalValues(op, left, right)
+	}
+	x, err := left.AsNumber()
+	if err != nil {
+		return Value{}, err
+	}
+	y, err := right.AsNumber()
+	if err != nil {
+		return Value{}, err
+	}
+	result, err := applyArithmetic(op, x, y)
+	if err != nil {
+		return Value{}, err
+	}
+	return NumberValue(result), nil
+}
+
+func applyDecimalValues(op string, left, right Value) (Value, error) {
+	x, err := left.AsDecimal()
+	if err != nil {
+		return Value{}, err
+	}
+	y, err := right.AsDecimal()
+	if err != nil {
+		return Value{}, err
+	}
+	result, err := applyDecimal(op, x, y)
+	if err != nil {
+		return Value{}, err
+	}
+	return DecimalValue(result), nil
+}
+
+// applyComparison turns the result of compareValues into the outcome of a comparison operator.
+func applyComparison(op string, cmp int) bool {
+	switch op {
+	case "=":
+		return cmp == 0
+	case "<>":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func applyArithmetic(op string, x, y float64) (float64, error) {
+	switch op {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/":
+		if y == 0 {
+			return 0, newFormulaError(ErrDivZero, "division by zero")
 		}
+		return x / y, nil
+	default:
+		return 0, fmt.Errorf("unsupported binary operator: %v", op)
+	}
+}
+
+// isValid rejects empty values and formulas with doubled operators, any other value is a number or text.
+func isValid(str string) bool {
+	if !strings.HasPrefix(str, "=") {
+		return str != ""
 	}
 	forbidden := []string{"++", "--", "//", "**"}
 
+	unquoted := stripQuoted(str)
 	for _, combo := range forbidden {
-		if strings.Contains(str, combo) {
+		if strings.Contains(unquoted, combo) {
 			return false
 		}
 	}
 	return true
 }
 
+// stripQuoted drops quoted references like 'a--b' and string literals like "a--b",
+// so their content isn't mistaken for operators.
+func stripQuoted(str string) string {
+	var sb strings.Builder
+	var quote rune
+	for _, r := range str {
+		switch {
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote = r
+			sb.WriteRune(' ')
+		case r == quote:
+			quote = 0
+		case quote == 0:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// normalizeValue lower-cases numbers and formulas, so references and function names are case-insensitive,
+// while text cells and string literals of formulas keep their case.
+func normalizeValue(str string) string {
+	if !strings.HasPrefix(str, "=") {
+		if _, ok := parseNumber(str); ok {
+			return strings.ToLower(str)
+		}
+		return str
+	}
+	var sb strings.Builder
+	var quote rune
+	for _, r := range str {
+		switch {
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote = r
+		case r == quote:
+			quote = 0
+		}
+		if quote == '"' || r == '"' {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return sb.String()
+}
+
+// formatRef renders cell the way it is referenced from a formula on sheetID.
+func formatRef(cell db.CellRef, sheetID string) string {
+	if cell.SheetID == sheetID {
+		return cell.CellID
+	}
+	return cell.String()
+}
+
+// formatPattern renders p the way it is referenced from a formula on sheetID.
+func formatPattern(p db.CellPattern, sheetID string) string {
+	if p.SheetID != sheetID {
+		return p.SheetID + "!" + p.Pattern
+	}
+	return p.Pattern
+}
+
+// matchPattern reports whether cellID matches a wildcard pattern, the same way SQLite GLOB does
+// for patterns made of literal characters, "*" and "?".
+func matchPattern(pattern, cellID string) bool {
+	p, c := []rune(pattern), []rune(cellID)
+	// star and mark remember the last "*" and the cell position it currently stands for
+	i, j, star, mark := 0, 0, -1, 0
+	for j < len(c) {
+		switch {
+		case i < len(p) && p[i] == '*':
+			star, mark = i, j
+			i++
+		case i < len(p) && (p[i] == '?' || p[i] == c[j]):
+			i++
+			j++
+		case star >= 0:
+			mark++
+			i, j = star+1, mark
+		default:
+			return false
+		}
+	}
+	for i < len(p) && p[i] == '*' {
+		i++
+	}
+	return i == len(p)
+}
+
 func contains(slice []string, value string) bool {
 	for _, v := range slice {
 		if v == value {
@@ -3,9 +3,51 @@ package services
 import (
 	"math"
 	"reflect"
+	"strings"
 	"testing"
+
+	"dev-challenge/db"
 )
 
+// sheetValues keys values by cells of sheetID, keys like "other!x" belong to other sheets.
+// Strings become text values, bools boolean values and any other value a number.
+func sheetValues(sheetID string, values map[string]any) map[db.CellRef]Value {
+	res := make(map[db.CellRef]Value, len(values))
+	for key, value := range values {
+		switch v := value.(type) {
+		case string:
+			res[parseTestRef(sheetID, key)] = TextValue(v)
+		case int:
+			res[parseTestRef(sheetID, key)] = NumberValue(float64(v))
+		case float64:
+			res[parseTestRef(sheetID, key)] = NumberValue(v)
+		case bool:
+			res[parseTestRef(sheetID, key)] = BoolValue(v)
+		}
+	}
+	return res
+}
+
+func parseTestRef(sheetID, key string) db.CellRef {
+	if i := strings.Index(key, "!"); i >= 0 {
+		return db.CellRef{SheetID: key[:i], CellID: key[i+1:]}
+	}
+	return db.CellRef{SheetID: sheetID, CellID: key}
+}
+
+// matchValues resolves patterns of the formula tree against cells of values the way storage does.
+func matchValues(root node, values map[db.CellRef]Value) map[db.CellPattern][]db.CellRef {
+	res := make(map[db.CellPattern][]db.CellRef)
+	for _, pattern := range extractPatterns(root, "sheet1") {
+		for cell := range values {
+			if cell.SheetID == pattern.SheetID && matchPattern(pattern.Pattern, cell.CellID) {
+				res[pattern] = append(res[pattern], cell)
+			}
+		}
+	}
+	return res
+}
+
 func Test_extractParams(t *testing.T) {
 	type args struct {
 		expr string
@@ -36,105 +78,64 @@ func Test_extractParams(t *testing.T) {
 			want: []string{},
 		},
 		{
-			name: "Empty expression",
-			args: args{expr: ""},
-			want: []string{},
+			name: "Repeated params",
+			args: args{expr: "=x+y*x"},
+			want: []string{"x", "y"},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := extractParams(tt.args.expr); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("extractParams() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func Test_replaceParamsWithValues(t *testing.T) {
-	type args struct {
-		expr   string
-		params map[string]string
-	}
-	tests := []struct {
-		name string
-		args args
-		want string
-	}{
 		{
-			name: "Different lengths, no prefix",
-			args: args{
-				expr:   "x+y",
-				params: map[string]string{"x": "10", "y": "20"},
-			},
-			want: "10+20",
+			name: "Param containing number",
+			args: args{expr: "=1x+x1+1"},
+			want: []string{"1x", "x1"},
 		},
 		{
-			name: "Numbers with params",
-			args: args{
-				expr:   "2*x+y-3",
-				params: map[string]string{"x": "10", "y": "20"},
-			},
-			want: "2*10+20-3",
+			name: "Quoted param with operators",
+			args: args{expr: "='a+b'-'123'"},
+			want: []string{"a+b", "123"},
 		},
 		{
-			name: "One key is a prefix of another",
-			args: args{
-				expr:   "xy+x",
-				params: map[string]string{"xy": "15", "x": "10"},
-			},
-			want: "15+10",
-		},
-		{
-			name: "Key containing number",
-			args: args{
-				expr:   "1x+x1+1",
-				params: map[string]string{"1x": "15", "x1": "10"},
-			},
-			want: "15+10+1",
-		},
-		{
-			name: "No replacements",
-			args: args{
-				expr:   "1+2",
-				params: map[string]string{},
-			},
-			want: "1+2",
+			name: "Params inside function arguments",
+			args: args{expr: "=SUM(a, MAX(b, a), 1)+c"},
+			want: []string{"a", "b", "c"},
 		},
 		{
-			name: "Empty expression",
-			args: args{
-				expr:   "",
-				params: map[string]string{},
-			},
-			want: "",
+			name: "Params of other sheets",
+			args: args{expr: "=other!x+x+'my sheet'!'a+b'+sheet1!x"},
+			want: []string{"other!x", "x", "my sheet!a+b"},
 		},
 		{
-			name: "Empty params map",
-			args: args{
-				expr:   "x+y",
-				params: map[string]string{},
-			},
-			want: "x+y",
+			name: "Scientific notation is not a param",
+			args: args{expr: "=2e3+2e+3"},
+			want: []string{},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := replaceParamsWithValues(tt.args.expr, tt.args.params); got != tt.want {
-				t.Errorf("replaceParamsWithValues() = %v, want %v", got, tt.want)
+			root, err := parseFormula(tt.args.expr)
+			if err != nil {
+				t.Fatalf("parseFormula() error = %v", err)
+			}
+			got := make([]string, 0)
+			for _, cell := range extractParams(root, "sheet1") {
+				got = append(got, formatRef(cell, "sheet1"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractParams() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_calculate(t *testing.T) {
+func Test_eval(t *testing.T) {
 	type args struct {
-		expr string
+		expr   string
+		values map[string]any
 	}
 	tests := []struct {
-		name    string
-		args    args
-		want    float64
-		wantErr bool
+		name     string
+		args     args
+		want     float64
+		wantText string
+		wantErr  bool
 	}{
 		{
 			name: "Addition",
@@ -282,60 +283,213 @@ func Test_calculate(t *testing.T) {
 			args:    args{expr: "=-"},
 			wantErr: true,
 		},
+		{
+			name: "Plain number",
+			args: args{expr: "-75"},
+			want: -75,
+		},
+		{
+			name: "Mixed unary signs",
+			args: args{expr: "=+-75"},
+			want: -75,
+		},
+		{
+			name: "Negated negative param",
+			args: args{expr: "=-b", values: map[string]any{"b": -2}},
+			want: 2,
+		},
+		{
+			name: "Params one containing another",
+			args: args{expr: "=par+param", values: map[string]any{"par": 1, "param": 10}},
+			want: 11,
+		},
+		{
+			name: "Numeric and operator params are quoted",
+			args: args{expr: "='123'+'a+b'+1", values: map[string]any{"123": 1, "a+b": 2}},
+			want: 4,
+		},
+		{
+			name: "Scientific notation",
+			args: args{expr: "=2e3+1.5E-1"},
+			want: 2000.15,
+		},
+		{
+			name: "Params keep full precision",
+			args: args{expr: "=x*1000000000", values: map[string]any{"x": 0.0000001}},
+			want: 100,
+		},
+		{
+			name: "Params of other sheets",
+			args: args{expr: "=assumptions!tax_rate*revenue", values: map[string]any{"assumptions!tax_rate": 0.2, "revenue": 50}},
+			want: 10,
+		},
+		{
+			name:    "Missing param of other sheet",
+			args:    args{expr: "=other!x+1", values: map[string]any{"x": 1}},
+			wantErr: true,
+		},
+		{
+			name:    "Missing param",
+			args:    args{expr: "=x+1"},
+			wantErr: true,
+		},
+		{
+			name: "Range skips empty cells",
+			args: args{expr: "=sum(a1:a3)*count(a1:b3)", values: map[string]any{"a1": 1, "a3": 2, "b2": 4}},
+			want: 9,
+		},
+		{
+			name: "Range of other sheet mixed with arguments",
+			args: args{expr: "=max(data!a1:b1, 3)", values: map[string]any{"data!a1": 5, "a1": 7}},
+			want: 5,
+		},
+		{
+			name: "Pattern",
+			args: args{expr: "=sum(sales_*)+count(q?, other!x*)", values: map[string]any{"sales_jan": 1, "sales_feb": 2, "q1": 3, "q10": 4, "other!x1": 5}},
+			want: 5,
+		},
+		{
+			name:    "Pattern outside of function",
+			args:    args{expr: "=sum(1)+sales_?", values: map[string]any{"sales_1": 1}},
+			wantErr: true,
+		},
+		{
+			name:     "Text cell",
+			args:     args{expr: "'=Not a formula"},
+			wantText: "=Not a formula",
+		},
+		{
+			name:     "Concatenation of text and numbers",
+			args:     args{expr: `="Total: " & a*2 & " ""EUR"""`, values: map[string]any{"a": 1.25}},
+			wantText: `Total: 2.5 "EUR"`,
+		},
+		{
+			name: "Numeric text in arithmetic",
+			args: args{expr: "=a+1", values: map[string]any{"a": " 2 "}},
+			want: 3,
+		},
+		{
+			name:    "Text in arithmetic",
+			args:    args{expr: "=a+1", values: map[string]any{"a": "label"}},
+			wantErr: true,
+		},
+		{
+			name:     "Unary plus keeps text",
+			args:     args{expr: "=+a", values: map[string]any{"a": "label"}},
+			wantText: "label",
+		},
+		{
+			name: "Numeric functions skip text cells of ranges",
+			args: args{expr: "=sum(a1:a3)+count(a1:a3)", values: map[string]any{"a1": 1, "a2": "n/a", "a3": 2}},
+			want: 5,
+		},
+		{
+			name:    "Text argument of numeric function",
+			args:    args{expr: "=abs(a)", values: map[string]any{"a": "label"}},
+			wantErr: true,
+		},
+		{
+			name:    "Average of empty range",
+			args:    args{expr: "=average(a1:a3)"},
+			wantErr: true,
+		},
+		{
+			name:    "Range outside of function",
+			args:    args{expr: "=a1:a3+1", values: map[string]any{"a1": 1}},
+			wantErr: true,
+		},
+		{
+			name: "Booleans in arithmetic",
+			args: args{expr: "=(a>1)*10 + true", values: map[string]any{"a": 2}},
+			want: 11,
+		},
+		{
+			name:     "Boolean concatenated as text",
+			args:     args{expr: `="paid: " & (a=b)`, values: map[string]any{"a": "Yes", "b": "yes"}},
+			wantText: "paid: TRUE",
+		},
+		{
+			name:    "Range in comparison",
+			args:    args{expr: "=a1:a2>1", values: map[string]any{"a1": 1}},
+			wantErr: true,
+		},
+		{
+			name:    "Range as non-variadic argument",
+			args:    args{expr: "=abs(a1:a3)", values: map[string]any{"a1": 1}},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := calculate(tt.args.expr)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("calculate() error = %v, wantErr %v", err, tt.wantErr)
-				return
+			root, err := parseFormula(tt.args.expr)
+			if err == nil {
+				var got Value
+				values := sheetValues("sheet1", tt.args.values)
+				got, err = (&evaluator{sheetID: "sheet1", values: values, patterns: matchValues(root, values), functions: NewDefaultFunctionRegistry()}).eval(root)
+				want := NumberValue(tt.want)
+				if tt.wantText != "" {
+					want = TextValue(tt.wantText)
+				}
+				if err == nil && got != want {
+					t.Errorf("eval() got = %v, want %v", got, want)
+				}
 			}
-			if got != tt.want {
-				t.Errorf("calculate() got = %v, want %v", got, tt.want)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("eval() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func Test_cleanExpression(t *testing.T) {
-	type args struct {
-		expr string
-	}
+func Test_evalMissingAsZero(t *testing.T) {
 	tests := []struct {
 		name string
-		args args
-		want string
+		expr string
+		want Value
 	}{
-		{
-			name: "No replacements no signs",
-			args: args{expr: "=x+y"},
-			want: "=x+y",
-		},
-		{
-			name: "No replacements - present",
-			args: args{expr: "=-x+y"},
-			want: "=-x+y",
-		},
-		{
-			name: "Empty expression",
-			args: args{expr: ""},
-			want: "",
-		},
-		{
-			name: "-- replacement",
-			args: args{expr: "=--x+y"},
-			want: "=x+y",
-		},
-		{
-			name: "=+ replacement",
-			args: args{expr: "=+x+y"},
-			want: "=x+y",
-		},
+		{name: "Missing cell counts as zero", expr: "=a+1", want: NumberValue(1)},
+		{name: "Missing cell of other sheet", expr: "=other!b*2+a", want: NumberValue(0)},
+		{name: "Missing cell in text", expr: `="total: " & a`, want: TextValue("total: 0")},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := cleanExpression(tt.args.expr); got != tt.want {
-				t.Errorf("cleanExpression() = %v, want %v", got, tt.want)
+			root, err := parseFormula(tt.expr)
+			if err != nil {
+				t.Fatalf("parseFormula() error = %v", err)
+			}
+			got, err := (&evaluator{sheetID: "sheet1", values: map[db.CellRef]Value{}, functions: NewDefaultFunctionRegistry(), missingAsZero: true}).eval(root)
+			if err != nil {
+				t.Fatalf("eval() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("eval() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_matchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		cellID  string
+		want    bool
+	}{
+		{pattern: "sales_*", cellID: "sales_jan", want: true},
+		{pattern: "sales_*", cellID: "sales_", want: true},
+		{pattern: "sales_*", cellID: "total_sales", want: false},
+		{pattern: "*_jan", cellID: "sales_jan", want: true},
+		{pattern: "*_jan", cellID: "sales_jan_2", want: false},
+		{pattern: "*a*b*", cellID: "xaybz", want: true},
+		{pattern: "*a*b", cellID: "xabab", want: true},
+		{pattern: "q?", cellID: "q1", want: true},
+		{pattern: "q?", cellID: "q10", want: false},
+		{pattern: "*", cellID: "", want: true},
+		{pattern: "?", cellID: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.cellID, func(t *testing.T) {
+			if got := matchPattern(tt.pattern, tt.cellID); got != tt.want {
+				t.Errorf("matchPattern() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -366,9 +520,14 @@ func Test_isValid(t *testing.T) {
 			want: true,
 		},
 		{
-			name: "String neither starts with = nor a valid number",
+			name: "Text",
 			args: args{str: "x+123"},
-			want: false,
+			want: true,
+		},
+		{
+			name: "String literal containing forbidden pattern",
+			args: args{str: `="a--b"&1`},
+			want: true,
 		},
 		{
 			name: "String with forbidden pattern ++",
@@ -395,6 +554,11 @@ func Test_isValid(t *testing.T) {
 			args: args{str: ""},
 			want: false,
 		},
+		{
+			name: "Quoted reference containing forbidden pattern",
+			args: args{str: "='a--b'+1"},
+			want: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -405,6 +569,26 @@ func Test_isValid(t *testing.T) {
 	}
 }
 
+func Test_normalizeValue(t *testing.T) {
+	tests := []struct {
+		str  string
+		want string
+	}{
+		{str: "2E3", want: "2e3"},
+		{str: "Paid", want: "Paid"},
+		{str: "'12", want: "'12"},
+		{str: `=UPPER(Name) & " Ltd" & 'Other Cell'`, want: `=upper(name) & " Ltd" & 'other cell'`},
+		{str: `="Say ""Hi"""&A`, want: `="Say ""Hi"""&a`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			if got := normalizeValue(tt.str); got != tt.want {
+				t.Errorf("normalizeValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_contains(t *testing.T) {
 	type args struct {
 		slice []string
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+// errDryRun rolls back the transaction of a validated write.
+var errDryRun = errors.New("dry run")
+
+// ValidateCellTX runs a v2 write with the recalculation of its dependents and always rolls it back.
+// The result tells what the write would store or why it would be rejected.
+func (s *excelLikeService) ValidateCellTX(ctx context.Context, sheetID, cellID string, inputData *models.CellInput) (*models.Validation, error) {
+	var res *models.Validation
+	err := s.inTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if res, err = s.validateCell(ctx, tx, sheetID, cellID, inputData); err != nil {
+			return err
+		}
+		return errDryRun
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return res, nil
+}
+
+// validateCell writes the cell within tx, which the caller has to roll back. Errors rejecting the write
+// become a validation result, other errors are returned.
+func (s *excelLikeService) validateCell(ctx context.Context, tx *sql.Tx, sheetID, cellID string, inputData *models.CellInput) (*models.Validation, error) {
+	ref := db.CellRef{SheetID: sheetID, CellID: cellID}
+	cell, err := s.addCell(ctx, tx, sheetID, cellID, inputData, true)
+	var formulaErr *FormulaError
+	var cycleErr *CircularReferenceError
+	switch {
+	case err == nil:
+		res := &models.Validation{Valid: true, Cell: cell}
+		if cellErr, ok := cell.Result.(models.CellError); ok {
+			validationErr, err := s.evaluationError(ctx, tx, ref, inputData.Value)
+			if err != nil {
+				return nil, err
+			}
+			if validationErr == nil {
+				validationErr = &models.ValidationError{Code: cellErr.Code, Message: cellErr.Message}
+			}
+			res.Errors = []models.ValidationError{*validationErr}
+		}
+		return res, nil
+	case errors.As(err, &cycleErr):
+		return invalidCell(models.ValidationError{Code: string(ErrCircular), Message: cycleErr.Error(), Cycle: cycleErr.Path}), nil
+	case errors.Is(err, ErrInvalidFormat):
+		return invalidCell(models.ValidationError{Code: string(ErrValue), Message: err.Error()}), nil
+	case errors.As(err, &formulaErr):
+		if formulaErr.Position == 0 {
+			validationErr, err := s.evaluationError(ctx, tx, ref, inputData.Value)
+			if err != nil {
+				return nil, err
+			}
+			if validationErr != nil {
+				return invalidCell(*validationErr), nil
+			}
+		}
+		return invalidCell(models.ValidationError{
+			Code:     string(formulaErr.Code),
+			Message:  formulaErr.Message,
+			Cell:     formulaErr.Cell,
+			Position: formulaErr.Position,
+		}), nil
+	}
+	return nil, err
+}
+
+func invalidCell(err models.ValidationError) *models.Validation {
+	return &models.Validation{Errors: []models.ValidationError{err}}
+}
+
+// evaluationError evaluates value as the formula of cell again tracing it, to find the node raising its error.
+// It returns nil when value isn't a formula that fails.
+func (s *excelLikeService) evaluationError(ctx context.Context, tx *sql.Tx, cell db.CellRef, value string) (*models.ValidationError, error) {
+	if !isValid(value) {
+		return nil, nil
+	}
+	formula, err := parseFormula(normalizeValue(value))
+	if err != nil {
+		return nil, nil
+	}
+	e, _, err := s.newEvaluator(ctx, tx, newCalculation(), cell, extractDependencies(formula, cell.SheetID))
+	if err != nil {
+		return nil, err
+	}
+	e.trace = make(map[node]tracedValue)
+	if _, err := e.eval(formula); err != nil {
+		formulaErr := asFormulaError(err)
+		return &models.ValidationError{
+			Code:     string(formulaErr.Code),
+			Message:  formulaErr.Message,
+			Cell:     formulaErr.Cell,
+			Position: e.errorOrigin(formula).Pos(),
+		}, nil
+	}
+	return nil, nil
+}
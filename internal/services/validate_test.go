@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"dev-challenge/db"
+	mock_db "dev-challenge/db/mock"
+	"dev-challenge/internal/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcelLikeService_validateCell(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock_db.NewMockStorage(ctrl)
+	tx := &sql.Tx{}
+	storage.EXPECT().GetSheetSettings(gomock.Any(), tx, gomock.Any()).DoAndReturn(defaultSettings).AnyTimes()
+	format := "0.0.0"
+
+	tests := []struct {
+		name          string
+		cellID        string
+		inputData     *models.CellInput
+		graph         []db.Input
+		mockBehavior  func()
+		expected      *models.Validation
+		expectedError string
+	}{
+		{
+			name:      "Valid write with dependents",
+			cellID:    "a",
+			inputData: &models.CellInput{Value: "0"},
+			graph:     []db.Input{formulaCell("a"), {SheetID: "sheet1", CellID: "b", Value: "=10/a", UsedParams: sheet1Cells("a")}},
+			mockBehavior: func() {
+				storage.EXPECT().AddCellInput(gomock.Any(), tx, gomock.Any()).Return(&models.Data{Value: "0"}, false, nil)
+				storage.EXPECT().SaveCellResult(gomock.Any(), tx, gomock.Any()).Return(nil)
+				storage.EXPECT().GetInput(gomock.Any(), tx, "sheet1", "a").Return(&db.Input{SheetID: "sheet1", CellID: "a", Value: "0", ResultType: "number"}, nil)
+				storage.EXPECT().GetInput(gomock.Any(), tx, "sheet1", "b").Return(&db.Input{SheetID: "sheet1", CellID: "b", Value: "=10/a",
+					ResultType: "error", ResultText: "#DIV/0!", ResultError: "division by zero"}, nil)
+			},
+			expected: &models.Validation{Valid: true, Cell: &models.Cell{
+				Value: "0", Result: float64(0), Type: "number", Formatted: "0",
+				Dependents: []models.RecalculatedCell{{Sheet: "sheet1", ID: "b", Cell: models.Cell{
+					Value: "=10/a", Result: models.CellError{Code: "#DIV/0!", Message: "division by zero"}, Type: "error", Formatted: "#DIV/0!",
+				}}},
+			}},
+		},
+		{
+			name:      "Valid write storing #REF!",
+			cellID:    "c",
+			inputData: &models.CellInput{Value: "=1+zz"},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"zz"}).Return(nil, nil).Times(2)
+				storage.EXPECT().AddCellInput(gomock.Any(), tx, gomock.Any()).Return(&models.Data{Value: "=1+zz"}, false, nil)
+				storage.EXPECT().GetInput(gomock.Any(), tx, "sheet1", "c").Return(&db.Input{SheetID: "sheet1", CellID: "c", Value: "=1+zz",
+					ResultType: "error", ResultText: "#REF!", ResultError: "referenced cell \"zz\" not found"}, nil)
+			},
+			expected: &models.Validation{
+				Valid: true,
+				Cell: &models.Cell{Value: "=1+zz", Result: models.CellError{Code: "#REF!", Message: "referenced cell \"zz\" not found"},
+					Type: "error", Formatted: "#REF!", Dependents: []models.RecalculatedCell{}},
+				Errors: []models.ValidationError{{Code: "#REF!", Message: "referenced cell \"zz\" not found", Position: 3}},
+			},
+		},
+		{
+			name:      "Syntax error",
+			cellID:    "c",
+			inputData: &models.CellInput{Value: "=a+(b*"},
+			expected: &models.Validation{Errors: []models.ValidationError{
+				{Code: "#NAME?", Message: "unexpected end of formula", Position: 6},
+			}},
+		},
+		{
+			name:      "Evaluation error",
+			cellID:    "c",
+			inputData: &models.CellInput{Value: "=2+1/0"},
+			expected: &models.Validation{Errors: []models.ValidationError{
+				{Code: "#DIV/0!", Message: "division by zero", Position: 4},
+			}},
+		},
+		{
+			name:      "Circular reference",
+			cellID:    "b",
+			inputData: &models.CellInput{Value: "=a+1"},
+			graph:     []db.Input{{SheetID: "sheet1", CellID: "a", Value: "=b", UsedParams: sheet1Cells("b")}},
+			expected: &models.Validation{Errors: []models.ValidationError{
+				{Code: "#CIRCULAR!", Message: "circular reference: b -> a -> b", Cycle: []string{"b", "a", "b"}},
+			}},
+		},
+		{
+			name:      "Invalid format",
+			cellID:    "c",
+			inputData: &models.CellInput{Value: "1", Format: &format},
+			expected: &models.Validation{Errors: []models.ValidationError{
+				{Code: "#VALUE!", Message: "invalid format \"0.0.0\""},
+			}},
+		},
+		{
+			name:      "Storage error",
+			cellID:    "b",
+			inputData: &models.CellInput{Value: "=a+1"},
+			mockBehavior: func() {
+				storage.EXPECT().GetCellInputBatch(gomock.Any(), tx, "sheet1", []string{"a"}).Return(nil, errors.New("some DB error"))
+			},
+			expectedError: "some DB error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &excelLikeService{storage: storage, functions: NewDefaultFunctionRegistry(), graph: graphOf(tt.graph...)}
+			if tt.mockBehavior != nil {
+				tt.mockBehavior()
+			}
+			got, err := s.validateCell(context.TODO(), tx, "sheet1", tt.cellID, tt.inputData)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"dev-challenge/db"
+	"dev-challenge/internal/models"
+)
+
+// ValueType is the type of a cell result or of an intermediate formula value.
+type ValueType string
+
+const (
+	TypeNumber  ValueType = "number"
+	TypeText    ValueType = "text"
+	TypeBoolean ValueType = "boolean"
+	TypeError   ValueType = "error"
+)
+
+// Value is a typed formula value, Number is set for numbers and Text for text.
+// Booleans keep 1 or 0 in Number, so they can be used in arithmetic like TRUE+1.
+// Errors are only loaded from cells in an error state, Err tells where the error comes from.
+// Numbers of sheets in decimal mode carry their exact value in Dec.
+type Value struct {
+	Type   ValueType
+	Number float64
+	Text   string
+	Err    *FormulaError
+	Dec    *big.Rat
+}
+
+// NumberValue wraps a number.
+func NumberValue(number float64) Value {
+	return Value{Type: TypeNumber, Number: number}
+}
+
+// TextValue wraps a text.
+func TextValue(text string) Value {
+	return Value{Type: TypeText, Text: text}
+}
+
+// BoolValue wraps a boolean.
+func BoolValue(b bool) Value {
+	if b {
+		return Value{Type: TypeBoolean, Number: 1}
+	}
+	return Value{Type: TypeBoolean}
+}
+
+// AsNumber converts v to a number, texts are converted only when they hold a number like " 12.5 ".
+func (v Value) AsNumber() (float64, error) {
+	if v.Type != TypeText {
+		return v.Number, nil
+	}
+	number, ok := parseNumber(strings.TrimSpace(v.Text))
+	if !ok {
+		return 0, newFormulaError(ErrValue, "text %q is not a number", v.Text)
+	}
+	return number, nil
+}
+
+// AsBool converts v to a boolean, numbers are true unless zero and texts have to be "TRUE" or "FALSE".
+func (v Value) AsBool() (bool, error) {
+	if v.Type != TypeText {
+		return v.Number != 0, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(v.Text)) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, newFormulaError(ErrValue, "text %q is not a boolean", v.Text)
+}
+
+// AsText renders v as text, numbers are written in the shortest form like 2, 0.5 or 1250000
+// and booleans as TRUE or FALSE.
+func (v Value) AsText() string {
+	switch v.Type {
+	case TypeText:
+		return v.Text
+	case TypeBoolean:
+		if v.Number != 0 {
+			return "TRUE"
+		}
+		return "FALSE"
+	case TypeError:
+		return string(v.Err.Code)
+	}
+	if v.Dec != nil {
+		return formatDecimal(v.Dec)
+	}
+	return strconv.FormatFloat(v.Number, 'f', -1, 64)
+}
+
+// compareValues orders two values the way spreadsheets do: numbers come before texts and texts before
+// booleans, texts are compared case-insensitively. It returns -1, 0 or 1.
+func compareValues(x, y Value) int {
+	if rx, ry := typeRank(x.Type), typeRank(y.Type); rx != ry {
+		if rx < ry {
+			return -1
+		}
+		return 1
+	}
+	if x.Type == TypeText {
+		return strings.Compare(strings.ToLower(x.Text), strings.ToLower(y.Text))
+	}
+	if x.Dec != nil && y.Dec != nil {
+		return x.Dec.Cmp(y.Dec)
+	}
+	switch {
+	case x.Number < y.Number:
+		return -1
+	case x.Number > y.Number:
+		return 1
+	}
+	return 0
+}
+
+func typeRank(t ValueType) int {
+	switch t {
+	case TypeText:
+		return 1
+	case TypeBoolean:
+		return 2
+	}
+	return 0
+}
+
+// parseNumber parses the number literals accepted in cells, an optional sign followed by digits like 2, -.5 or 2e3.
+func parseNumber(str string) (float64, bool) {
+	number, err := strconv.ParseFloat(str, 64)
+	if err != nil || !numberPattern.MatchString(strings.ToLower(strings.TrimLeft(str, "+-"))) {
+		return 0, false
+	}
+	return number, true
+}
+
+// inputValue returns the stored result of cell. Errors of cells in an error state point to the cell
+// they come from, so formulas referencing them report the original failure. Numbers of sheets in decimal
+// mode keep their exact digits in ResultText.
+func inputValue(cell db.CellRef, input db.Input) Value {
+	switch ValueType(input.ResultType) {
+	case TypeText:
+		return TextValue(input.ResultText)
+	case TypeBoolean:
+		return BoolValue(input.Result != 0)
+	case TypeError:
+		origin := input.ErrorCell
+		if origin == "" {
+			origin = cell.String()
+		}
+		return Value{Type: TypeError, Err: &FormulaError{Code: ErrorCode(input.ResultText), Message: input.ResultError, Cell: origin}}
+	}
+	if input.ResultText != "" {
+		if d, ok := new(big.Rat).SetString(input.ResultText); ok {
+			return DecimalValue(d)
+		}
+	}
+	return NumberValue(input.Result)
+}
+
+// typedResult converts v to the typed result of the v2 API.
+func typedResult(v Value) any {
+	switch v.Type {
+	case TypeText:
+		return v.Text
+	case TypeBoolean:
+		return v.Number != 0
+	case TypeError:
+		return models.CellError{Code: string(v.Err.Code), Message: v.Err.Error()}
+	}
+	if v.Dec != nil {
+		return json.Number(formatDecimal(v.Dec))
+	}
+	if math.IsInf(v.Number, 0) || math.IsNaN(v.Number) {
+		// JSON has no infinite numbers
+		return v.AsText()
+	}
+	return v.Number
+}
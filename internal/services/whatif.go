@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"dev-challenge/db"
+)
+
+// whatIf evaluates cells in memory with some cells overridden, nothing is written. newWhatIf takes what
+// the evaluation needs from the dependency graph and storage at once, so evaluate neither reads storage nor
+// needs the graph and may run without holding any lock.
+type whatIf struct {
+	// order lists the overridden cells and the cells they change, each after the cells it uses
+	order      []db.CellRef
+	values     map[db.CellRef]string
+	formulas   map[db.CellRef]node
+	deps       map[db.CellRef]db.Dependencies
+	patterns   map[db.CellRef]map[db.CellPattern][]db.CellRef
+	overridden map[db.CellRef]bool
+	// deleted marks the deleted cells the formulas of the ordered cells reference
+	deleted  map[db.CellRef]bool
+	settings map[string]db.SheetSettings
+	// stored keeps the stored results of the cells the ordered cells use and of the reported cells
+	stored    map[db.CellRef]db.Input
+	functions *FunctionRegistry
+}
+
+// overrideError rejects the overridden value of cell, like a wrong formula or one closing a loop.
+type overrideError struct {
+	cell db.CellRef
+	err  error
+}
+
+func (e *overrideError) Error() string {
+	return e.err.Error()
+}
+
+func (e *overrideError) Unwrap() error {
+	return e.err
+}
+
+// prepareWhatIf runs prepare under the read lock within a transaction of its own. Both end with prepare, so
+// the prepared evaluation runs without holding anything.
+func (s *excelLikeService) prepareWhatIf(ctx context.Context, prepare func(tx *sql.Tx) (*whatIf, error)) (*whatIf, error) {
+	tx, err := s.storage.BeginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var w *whatIf
+	var prepErr error
+	if err := s.readGraph(ctx, func() {
+		w, prepErr = prepare(tx)
+	}); err != nil {
+		return nil, err
+	}
+	return w, prepErr
+}
+
+// newWhatIf prepares the evaluation of reported cells with the values of overrides written to their cells.
+// Only cells the overrides change and the reported cells use are calculated. It reads the graph and storage
+// within tx, the graph must not change meanwhile.
+func (s *excelLikeService) newWhatIf(ctx context.Context, tx *sql.Tx, overrides map[db.CellRef]string, reported []db.CellRef) (*whatIf, error) {
+	w := &whatIf{
+		values:     make(map[db.CellRef]string),
+		formulas:   make(map[db.CellRef]node),
+		deps:       make(map[db.CellRef]db.Dependencies),
+		patterns:   make(map[db.CellRef]map[db.CellPattern][]db.CellRef),
+		overridden: make(map[db.CellRef]bool, len(overrides)),
+		deleted:    make(map[db.CellRef]bool),
+		settings:   make(map[string]db.SheetSettings),
+		stored:     make(map[db.CellRef]db.Input),
+		functions:  s.functions,
+	}
+	roots := make([]db.CellRef, 0, len(overrides))
+	for cell := range overrides {
+		roots = append(roots, cell)
+	}
+	sortCells(roots)
+	for _, cell := range roots {
+		value, formula, err := parseInput(overrides[cell])
+		if err != nil {
+			return nil, &overrideError{cell: cell, err: err}
+		}
+		w.values[cell], w.formulas[cell], w.overridden[cell] = value, formula, true
+		w.deps[cell] = extractDependencies(formula, cell.SheetID)
+	}
+
+	// the cells depending on the overrides keep their stored formulas
+	cells := make([]db.CellRef, 0, len(roots))
+	dependents := make(map[db.CellRef][]db.CellRef)
+	queue := append([]db.CellRef(nil), roots...)
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		if _, ok := dependents[cell]; ok {
+			continue
+		}
+		if !w.overridden[cell] {
+			value, ok := s.graph.value(cell)
+			if !ok {
+				continue
+			}
+			// stored formulas were validated when written
+			formula, err := parseFormula(value)
+			if err != nil {
+				return nil, err
+			}
+			w.values[cell], w.formulas[cell] = value, formula
+			w.deps[cell] = extractDependencies(formula, cell.SheetID)
+		}
+		dependents[cell] = s.graph.directDependents(cell)
+		cells = append(cells, cell)
+		queue = append(queue, dependents[cell]...)
+	}
+
+	precedents := make(map[db.CellRef][]db.CellRef, len(cells))
+	for _, cell := range cells {
+		for _, dependent := range dependents[cell] {
+			if _, ok := dependents[dependent]; ok && !w.overridden[dependent] {
+				precedents[dependent] = append(precedents[dependent], cell)
+			}
+		}
+		if w.overridden[cell] {
+			// an overridden formula may use other cells than the stored one
+			for _, other := range cells {
+				if uses(w.deps[cell], cell, other) {
+					precedents[cell] = append(precedents[cell], other)
+				}
+			}
+		}
+	}
+	order, err := w.sort(cells, precedents)
+	if err != nil {
+		return nil, err
+	}
+
+	needed := make(map[db.CellRef]bool)
+	queue = append(append([]db.CellRef(nil), roots...), reported...)
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		if !needed[cell] {
+			needed[cell] = true
+			queue = append(queue, precedents[cell]...)
+		}
+	}
+	for _, cell := range order {
+		if needed[cell] {
+			w.order = append(w.order, cell)
+			for ref := range s.graph.deletedRefs(w.deps[cell]) {
+				w.deleted[ref] = true
+			}
+		}
+	}
+	if err := w.load(ctx, s.storage, tx, reported); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// uses tells whether the formula of cell with deps uses other, a pattern never makes a cell use itself.
+func uses(deps db.Dependencies, cell, other db.CellRef) bool {
+	for _, ref := range deps.Cells {
+		if ref == other {
+			return true
+		}
+	}
+	for _, r := range deps.Ranges {
+		if rangeContains(r, other) {
+			return true
+		}
+	}
+	for _, p := range deps.Patterns {
+		if other != cell && p.SheetID == other.SheetID && matchPattern(p.Pattern, other.CellID) {
+			return true
+		}
+	}
+	return false
+}
+
+// sort orders cells after the cells they use, an override closing a loop is rejected.
+func (w *whatIf) sort(cells []db.CellRef, precedents map[db.CellRef][]db.CellRef) ([]db.CellRef, error) {
+	next := make(map[db.CellRef][]db.CellRef, len(cells))
+	inDegree := make(map[db.CellRef]int, len(cells))
+	for _, cell := range cells {
+		for _, precedent := range precedents[cell] {
+			next[precedent] = append(next[precedent], cell)
+			inDegree[cell]++
+		}
+	}
+	sortCells(cells)
+	var ready []db.CellRef
+	for _, cell := range cells {
+		if inDegree[cell] == 0 {
+			ready = append(ready, cell)
+		}
+	}
+	order := make([]db.CellRef, 0, len(cells))
+	for len(ready) > 0 {
+		cell := ready[0]
+		ready = ready[1:]
+		order = append(order, cell)
+		for _, dependent := range next[cell] {
+			if inDegree[dependent]--; inDegree[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	if len(order) == len(cells) {
+		return order, nil
+	}
+
+	// stored formulas never form a loop, so one of the overrides does
+	for _, cell := range cells {
+		if !w.overridden[cell] {
+			continue
+		}
+		if path := findCycle(precedents, cell, precedents[cell]); path != nil {
+			formatted := make([]string, len(path))
+			for i, ref := range path {
+				formatted[i] = formatRef(ref, cell.SheetID)
+			}
+			return nil, &overrideError{cell: cell, err: &CircularReferenceError{Path: formatted}}
+		}
+	}
+	return order, nil
+}
+
+// load reads the settings of the sheets of the ordered cells, the cells their patterns match and the stored
+// results of the cells they use and of the reported cells.
+func (w *whatIf) load(ctx context.Context, storage db.Storage, tx *sql.Tx, reported []db.CellRef) error {
+	matches := make(map[db.CellPattern]map[string]db.Input)
+	ordered := make(map[db.CellRef]bool, len(w.order))
+	for _, cell := range w.order {
+		ordered[cell] = true
+	}
+	var cells []db.CellRef
+	for _, cell := range w.order {
+		if _, ok := w.settings[cell.SheetID]; !ok {
+			settings, err := storage.GetSheetSettings(ctx, tx, cell.SheetID)
+			if err != nil {
+				return err
+			}
+			w.settings[cell.SheetID] = settings
+		}
+		deps := w.deps[cell]
+		cells = withRangeCells(appendCells(cells, deps.Cells), deps.Ranges)
+		if len(deps.Patterns) == 0 {
+			continue
+		}
+		w.patterns[cell] = make(map[db.CellPattern][]db.CellRef, len(deps.Patterns))
+		for _, pattern := range deps.Patterns {
+			results, ok := matches[pattern]
+			if !ok {
+				var err error
+				if results, err = storage.GetCellInputByPattern(ctx, tx, pattern); err != nil {
+					return err
+				}
+				matches[pattern] = results
+			}
+			var matched []db.CellRef
+			for cellID, input := range results {
+				ref := db.CellRef{SheetID: pattern.SheetID, CellID: cellID}
+				w.stored[ref] = input
+				if ref != cell {
+					matched = append(matched, ref)
+				}
+			}
+			// overrides may create cells the pattern matches
+			for ref := range w.overridden {
+				if _, ok := results[ref.CellID]; !ok && uses(db.Dependencies{Patterns: []db.CellPattern{pattern}}, cell, ref) {
+					matched = append(matched, ref)
+				}
+			}
+			sort.Slice(matched, func(i, j int) bool {
+				return matched[i].CellID < matched[j].CellID
+			})
+			w.patterns[cell][pattern] = matched
+		}
+	}
+
+	var used []db.CellRef
+	for _, cell := range cells {
+		if !ordered[cell] {
+			used = append(used, cell)
+		}
+	}
+	stored, err := storedResults(ctx, storage, tx, appendCells(used, reported))
+	if err != nil {
+		return err
+	}
+	for cell, input := range stored {
+		w.stored[cell] = input
+	}
+	return nil
+}
+
+// evaluate calculates the ordered cells, fixed are results of cells given instead of calculated, like drawn
+// inputs of simulations. Overridden formulas failing with anything but #REF! are rejected like writes are.
+func (w *whatIf) evaluate(fixed map[db.CellRef]db.Input) (map[db.CellRef]db.Input, error) {
+	calc := &calculation{settings: w.settings, results: make(map[db.CellRef]db.Input, len(fixed)+len(w.order))}
+	for cell, input := range fixed {
+		calc.results[cell] = input
+	}
+	for _, cell := range w.order {
+		if _, ok := fixed[cell]; ok {
+			continue
+		}
+		deps := w.deps[cell]
+		values := make(map[db.CellRef]Value)
+		for _, ref := range appendCells(withRangeCells(deps.Cells, deps.Ranges), mapValues(w.patterns[cell])...) {
+			if input, ok := w.result(calc.results, ref); ok {
+				values[ref] = inputValue(ref, input)
+			}
+		}
+		settings := w.settings[cell.SheetID]
+		e := evaluatorOf(cell, settings, w.functions, values, w.patterns[cell])
+		e.deleted = w.deleted
+		if _, err := evaluate(calc, e, settings, cell, w.values[cell], w.formulas[cell], deps, !w.overridden[cell]); err != nil {
+			return nil, &overrideError{cell: cell, err: err}
+		}
+	}
+	return calc.results, nil
+}
+
+// result returns the result of cell among the results of evaluate or the stored ones, false when there is
+// no such cell.
+func (w *whatIf) result(results map[db.CellRef]db.Input, cell db.CellRef) (db.Input, bool) {
+	if input, ok := results[cell]; ok {
+		return input, true
+	}
+	input, ok := w.stored[cell]
+	return input, ok
+}
+
+// numberResult is the result of cell holding x as if the user wrote it, evaluate takes it as fixed.
+func numberResult(cell db.CellRef, x float64) db.Input {
+	return db.Input{SheetID: cell.SheetID, CellID: cell.CellID, Value: numberInput(x), Result: x, ResultType: string(TypeNumber)}
+}
+
+func mapValues(patterns map[db.CellPattern][]db.CellRef) [][]db.CellRef {
+	res := make([][]db.CellRef, 0, len(patterns))
+	for _, cells := range patterns {
+		res = append(res, cells)
+	}
+	return res
+}
+
+// storedResults reads the stored results of cells, grouping them by sheet. Missing cells are omitted.
+func storedResults(ctx context.Context, storage db.Storage, tx *sql.Tx, cells []db.CellRef) (map[db.CellRef]db.Input, error) {
+	var sheets []string
+	bySheet := make(map[string][]string)
+	for _, cell := range cells {
+		if _, ok := bySheet[cell.SheetID]; !ok {
+			sheets = append(sheets, cell.SheetID)
+		}
+		bySheet[cell.SheetID] = append(bySheet[cell.SheetID], cell.CellID)
+	}
+	res := make(map[db.CellRef]db.Input, len(cells))
+	for _, sheetID := range sheets {
+		results, err := storage.GetCellInputBatch(ctx, tx, sheetID, bySheet[sheetID])
+		if err != nil {
+			return nil, err
+		}
+		for cellID, input := range results {
+			res[db.CellRef{SheetID: sheetID, CellID: cellID}] = input
+		}
+	}
+	return res, nil
+}